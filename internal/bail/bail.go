@@ -1,6 +1,12 @@
 package bail
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
 
 type Struct struct {
 	err error
@@ -28,7 +34,75 @@ func (s *Struct) Recover2(err *error, prefix string) {
 	}
 }
 
+// Bail out of the loader, recording where it bailed from. Errors that already
+// bailed keep their original position.
 func (s *Struct) Bail(err error) {
-	s.err = err
+	var bailErr *Error
+	if errors.As(err, &bailErr) {
+		s.err = err
+		panic(bail{})
+	}
+	bailErr = &Error{Err: err}
+	if _, file, line, ok := runtime.Caller(1); ok {
+		bailErr.Position = Position{file, line}
+	}
+	s.err = bailErr
 	panic(bail{})
 }
+
+// Error is an error that bailed out of a loader
+type Error struct {
+	Generator string   // Name of the generator, e.g. "controller"
+	File      string   // Path of the file being generated
+	Position  Position // Where the loader bailed
+	Err       error
+}
+
+func (e *Error) Error() string {
+	msg := e.Err.Error()
+	// Errors that bailed within Err already include the position
+	var inner *Error
+	if e.Position.Line > 0 && !errors.As(e.Err, &inner) {
+		msg += " (" + e.Position.String() + ")"
+	}
+	if e.File != "" {
+		msg = fmt.Sprintf("unable to generate %q > %s", e.File, msg)
+	}
+	if e.Generator != "" {
+		msg = e.Generator + ": " + msg
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Position in the Go source code
+type Position struct {
+	Path string
+	Line int
+}
+
+// String returns the position relative to its package's parent directory, e.g.
+// "controller/loader.go:42"
+func (p Position) String() string {
+	rel := filepath.Join(filepath.Base(filepath.Dir(p.Path)), filepath.Base(p.Path))
+	return rel + ":" + strconv.Itoa(p.Line)
+}
+
+// Generating records the generator and file being generated when err bailed.
+// Errors that didn't bail are returned as-is.
+func Generating(err error, generator, file string) error {
+	var bailErr *Error
+	if !errors.As(err, &bailErr) || bailErr.Generator != "" || bailErr.File != "" {
+		return err
+	}
+	// Wrapped errors have already formatted their message, so wrap them again
+	if bailErr != err {
+		return &Error{generator, file, bailErr.Position, err}
+	}
+	bailErr.Generator = generator
+	bailErr.File = file
+	return err
+}
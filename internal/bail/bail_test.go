@@ -0,0 +1,108 @@
+package bail_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/livebud/bud/internal/bail"
+	"github.com/matryer/is"
+)
+
+type loader struct {
+	bail.Struct
+}
+
+func (l *loader) Load(err error) (result string, rerr error) {
+	defer l.Recover(&rerr)
+	l.load(err)
+	return "loaded", nil
+}
+
+func (l *loader) load(err error) {
+	if err != nil {
+		l.Bail(err)
+	}
+}
+
+func TestNoBail(t *testing.T) {
+	is := is.New(t)
+	result, err := (&loader{}).Load(nil)
+	is.NoErr(err)
+	is.Equal(result, "loaded")
+}
+
+func TestBail(t *testing.T) {
+	is := is.New(t)
+	_, err := (&loader{}).Load(fmt.Errorf("loader: unable to read %q. %w", "controller", fs.ErrNotExist))
+	is.True(err != nil)
+	is.Equal(err.Error(), `loader: unable to read "controller". file does not exist (bail/bail_test.go:25)`)
+	is.True(errors.Is(err, fs.ErrNotExist))
+	var bailErr *bail.Error
+	is.True(errors.As(err, &bailErr))
+	is.Equal(bailErr.Position.Line, 25)
+	is.Equal(bailErr.Generator, "")
+	is.Equal(bailErr.File, "")
+}
+
+func TestGenerating(t *testing.T) {
+	is := is.New(t)
+	_, err := (&loader{}).Load(errors.New("controller: unable to find struct for *User"))
+	err = bail.Generating(err, "controller", "bud/.app/controller/controller.go")
+	is.Equal(err.Error(), `controller: unable to generate "bud/.app/controller/controller.go" > controller: unable to find struct for *User (bail/bail_test.go:25)`)
+	var bailErr *bail.Error
+	is.True(errors.As(err, &bailErr))
+	is.Equal(bailErr.Generator, "controller")
+	is.Equal(bailErr.File, "bud/.app/controller/controller.go")
+	// The innermost provenance wins
+	err = bail.Generating(err, "web", "bud/.app/web/web.go")
+	is.True(errors.As(err, &bailErr))
+	is.Equal(bailErr.Generator, "controller")
+}
+
+func TestGeneratingNotBailed(t *testing.T) {
+	is := is.New(t)
+	err := bail.Generating(fs.ErrNotExist, "controller", "bud/.app/controller/controller.go")
+	is.Equal(err, fs.ErrNotExist)
+}
+
+func TestRecover2(t *testing.T) {
+	is := is.New(t)
+	l := &loader{}
+	err := func() (err error) {
+		defer l.Recover2(&err, "command: unable to parse")
+		l.load(fs.ErrNotExist)
+		return nil
+	}()
+	err = bail.Generating(err, "command", "bud/.cli/command/command.go")
+	is.Equal(err.Error(), `command: unable to generate "bud/.cli/command/command.go" > command: unable to parse > file does not exist (bail/bail_test.go:25)`)
+	is.True(errors.Is(err, fs.ErrNotExist))
+	var bailErr *bail.Error
+	is.True(errors.As(err, &bailErr))
+	is.Equal(bailErr.Generator, "command")
+	is.Equal(bailErr.Position.Line, 25)
+}
+
+func TestBailTwice(t *testing.T) {
+	is := is.New(t)
+	inner := &loader{}
+	_, err := inner.Load(fs.ErrNotExist)
+	is.True(err != nil)
+	// Bailing with an error that already bailed keeps the original position
+	outer := &loader{}
+	_, err = outer.Load(fmt.Errorf("outer: %w", err))
+	is.Equal(err.Error(), "outer: file does not exist (bail/bail_test.go:25)")
+}
+
+func TestPanic(t *testing.T) {
+	is := is.New(t)
+	defer func() {
+		is.Equal(recover(), "unexpected")
+	}()
+	l := &loader{}
+	func() (err error) {
+		defer l.Recover(&err)
+		panic("unexpected")
+	}()
+}
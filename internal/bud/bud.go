@@ -37,7 +37,13 @@ func defaultEnv(module *gomod.Module) (Env, error) {
 }
 
 func Find(dir string) (*Compiler, error) {
-	module, err := gomod.Find(dir)
+	// Persist the module cache directories across runs, next to the build cache
+	// TODO: use the user cache, once we have a way to clean up
+	cache, err := gomod.LoadCache(filepath.Join(os.TempDir(), "bud", "cache", "gomod.json"))
+	if err != nil {
+		return nil, err
+	}
+	module, err := gomod.Find(dir, gomod.WithCache(cache))
 	if err != nil {
 		return nil, err
 	}
@@ -107,7 +113,8 @@ func (c *Compiler) goBuild(ctx context.Context, module *gomod.Module, outPath st
 	return nil
 }
 
-func (c *Compiler) Compile(ctx context.Context, flag *bud.Flag) (p *Project, err error) {
+// Overlay loads the overlay with the generators for the project CLI
+func (c *Compiler) Overlay(ctx context.Context, flag *bud.Flag) (*overlay.FileSystem, error) {
 	// Load the overlay
 	overlay, err := c.loadOverlay(ctx, c.module)
 	if err != nil {
@@ -123,6 +130,16 @@ func (c *Compiler) Compile(ctx context.Context, flag *bud.Flag) (p *Project, err
 	overlay.FileGenerator("bud/.cli/command/command.go", command.New(overlay, c.module, parser))
 	overlay.FileGenerator("bud/.cli/generator/generator.go", generator.New(overlay, c.module, parser))
 	overlay.FileGenerator("bud/.cli/transform/transform.go", transform.New(c.module))
+	// The program is wired from the generated command and generator packages
+	overlay.DependsOn("bud/.cli/program/program.go", "bud/.cli/command/command.go", "bud/.cli/generator/generator.go")
+	return overlay, nil
+}
+
+func (c *Compiler) Compile(ctx context.Context, flag *bud.Flag) (p *Project, err error) {
+	overlay, err := c.Overlay(ctx, flag)
+	if err != nil {
+		return nil, err
+	}
 	// Sync the generators
 	if err := c.sync(ctx, overlay); err != nil {
 		return nil, err
@@ -1,10 +1,18 @@
 package bud
 
+import "sort"
+
 type Env map[string]string
 
+// List the environment sorted by key
 func (e Env) List() (env []string) {
-	for k, v := range e {
-		env = append(env, k+"="+v)
+	keys := make([]string, 0, len(e))
+	for k := range e {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, k+"="+e[k])
 	}
 	return env
 }
@@ -3,6 +3,7 @@ package cli
 import (
 	"context"
 	"errors"
+	"os"
 	"strings"
 
 	"github.com/livebud/bud/internal/command"
@@ -11,6 +12,8 @@ import (
 	"github.com/livebud/bud/internal/command/run"
 	"github.com/livebud/bud/internal/command/tool/cache"
 	"github.com/livebud/bud/internal/command/tool/di"
+	"github.com/livebud/bud/internal/command/tool/fs/trace"
+	"github.com/livebud/bud/internal/command/tool/fs/verify"
 	v8 "github.com/livebud/bud/internal/command/tool/v8"
 	v8client "github.com/livebud/bud/internal/command/tool/v8/client"
 	"github.com/livebud/bud/internal/command/version"
@@ -31,7 +34,7 @@ func Parse(args []string) int {
 func parse(args []string) error {
 	// $ bud
 	bud := new(command.Bud)
-	cli := commander.New("bud")
+	cli := commander.New("bud").Interspersed(false)
 	cli.Flag("chdir", "Change the working directory").Short('C').String(&bud.Dir).Default(".")
 	cli.Args("args").Strings(&bud.Args)
 	cli.Run(bud.Run)
@@ -89,6 +92,24 @@ func parse(args []string) error {
 			}
 		}
 
+		{ // $ bud tool fs
+			cli := cli.Command("fs", "Inspect the generated filesystem")
+
+			{ // $ bud tool fs trace
+				cmd := &trace.Command{Bud: bud, Stdout: os.Stdout}
+				cli := cli.Command("trace", "Trace the filesystem calls made by generators")
+				cli.Args("dirs").Strings(&cmd.Dirs).Default("bud/.cli")
+				cli.Run(cmd.Run)
+			}
+
+			{ // $ bud tool fs verify
+				cmd := &verify.Command{Bud: bud, Stdout: os.Stdout}
+				cli := cli.Command("verify", "Generate twice and diff to check the generators are deterministic")
+				cli.Args("dirs").Strings(&cmd.Dirs).Default("bud/.cli", "bud/.app")
+				cli.Run(cmd.Run)
+			}
+		}
+
 		{ // $ bud tool cache
 			cmd := &cache.Command{}
 			cli := cli.Command("cache", "Manage the build cache")
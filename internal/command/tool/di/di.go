@@ -80,7 +80,7 @@ func (c *Command) Run(ctx context.Context) error {
 	if c.Verbose {
 		fmt.Println(node.Print())
 	}
-	provider := node.Generate(imports.New(), "Load", fn.Target)
+	provider := node.Generate(imports.New(imports.WithLocal(module.Import())), "Load", fn.Target)
 	fmt.Fprintln(os.Stdout, provider.File())
 	return nil
 }
@@ -0,0 +1,48 @@
+package trace
+
+import (
+	"context"
+	"io"
+	"io/fs"
+
+	"github.com/livebud/bud/internal/bud"
+	"github.com/livebud/bud/internal/command"
+)
+
+type Command struct {
+	Bud    *command.Bud
+	Dirs   []string
+	Stdout io.Writer
+}
+
+// Run generates the directories and writes out the filesystem calls made by
+// each generator along with how long they took
+func (c *Command) Run(ctx context.Context) error {
+	compiler, err := bud.Find(c.Bud.Dir)
+	if err != nil {
+		return err
+	}
+	overlay, err := compiler.Overlay(ctx, &c.Bud.Flag)
+	if err != nil {
+		return err
+	}
+	tracer := overlay.Trace()
+	fsys := tracer.Wrap("", overlay)
+	for _, dir := range c.Dirs {
+		err := fs.WalkDir(fsys, dir, func(path string, de fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if de.IsDir() {
+				return nil
+			}
+			_, err = fs.ReadFile(fsys, path)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tracer.WriteTo(c.Stdout)
+	return err
+}
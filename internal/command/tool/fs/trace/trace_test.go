@@ -0,0 +1,30 @@
+package trace_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/livebud/bud/internal/command"
+	"github.com/livebud/bud/internal/command/tool/fs/trace"
+	"github.com/livebud/bud/internal/testdir"
+	"github.com/matryer/is"
+)
+
+func TestTrace(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New()
+	is.NoErr(td.Write(dir))
+	stdout := new(bytes.Buffer)
+	cmd := &trace.Command{
+		Bud:    &command.Bud{Dir: dir},
+		Dirs:   []string{"bud/.cli"},
+		Stdout: stdout,
+	}
+	is.NoErr(cmd.Run(ctx))
+	is.True(strings.HasPrefix(stdout.String(), "OP "))
+	is.True(strings.Contains(stdout.String(), "bud/.cli/main.go"))
+}
@@ -0,0 +1,131 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/livebud/bud/internal/bud"
+	"github.com/livebud/bud/internal/command"
+	"github.com/matthewmueller/diff"
+)
+
+type Command struct {
+	Bud    *command.Bud
+	Dirs   []string
+	Stdout io.Writer
+}
+
+// Run generates the directories twice from scratch and diffs the results.
+// Generators must be deterministic, otherwise unchanged projects would still
+// trigger syncs and cache misses. Directories within bud/.app are generated
+// by building the project with the compiled CLI.
+func (c *Command) Run(ctx context.Context) error {
+	compiler, err := bud.Find(c.Bud.Dir)
+	if err != nil {
+		return err
+	}
+	first, err := c.generate(ctx, compiler)
+	if err != nil {
+		return err
+	}
+	second, err := c.generate(ctx, compiler)
+	if err != nil {
+		return err
+	}
+	paths := map[string]bool{}
+	for path := range first {
+		paths[path] = true
+	}
+	for path := range second {
+		paths[path] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+	differ := 0
+	for _, path := range sorted {
+		a, b := first[path], second[path]
+		if a != nil && b != nil && bytes.Equal(a, b) {
+			continue
+		}
+		differ++
+		fmt.Fprintf(c.Stdout, "--- %s ---\n%s\n", path, diff.String(string(a), string(b)))
+	}
+	if differ > 0 {
+		return fmt.Errorf("verify: %d of %d generated files differ between runs", differ, len(sorted))
+	}
+	return nil
+}
+
+// generate the directories with a new overlay and a new build, so nothing is
+// cached from a previous run
+func (c *Command) generate(ctx context.Context, compiler *bud.Compiler) (map[string][]byte, error) {
+	var cliDirs, appDirs []string
+	for _, dir := range c.Dirs {
+		if dir == "bud/.app" || strings.HasPrefix(dir, "bud/.app/") {
+			appDirs = append(appDirs, dir)
+			continue
+		}
+		cliDirs = append(cliDirs, dir)
+	}
+	files := map[string][]byte{}
+	if len(cliDirs) > 0 {
+		fsys, err := compiler.Overlay(ctx, &c.Bud.Flag)
+		if err != nil {
+			return nil, err
+		}
+		if err := readDirs(fsys, files, cliDirs); err != nil {
+			return nil, err
+		}
+	}
+	if len(appDirs) > 0 {
+		// The app is generated by the project's CLI, so build the project
+		project, err := compiler.Compile(ctx, &c.Bud.Flag)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := project.Build(ctx); err != nil {
+			return nil, err
+		}
+		if err := readDirs(project.Module, files, appDirs); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// readDirs reads the files within dirs into files. Missing directories are
+// skipped, since not every project generates every directory.
+func readDirs(fsys fs.FS, files map[string][]byte, dirs []string) error {
+	for _, dir := range dirs {
+		err := fs.WalkDir(fsys, dir, func(path string, de fs.DirEntry, err error) error {
+			if err != nil {
+				if path == dir && errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if de.IsDir() {
+				return nil
+			}
+			data, err := fs.ReadFile(fsys, path)
+			if err != nil {
+				return err
+			}
+			files[path] = data
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
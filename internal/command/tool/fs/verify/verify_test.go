@@ -0,0 +1,33 @@
+package verify_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/livebud/bud/internal/command"
+	"github.com/livebud/bud/internal/command/tool/fs/verify"
+	"github.com/livebud/bud/internal/testdir"
+	"github.com/matryer/is"
+)
+
+func TestVerify(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New()
+	td.Files["controller/controller.go"] = `
+		package controller
+		type Controller struct {}
+		func (c *Controller) Index() string { return "hello" }
+	`
+	is.NoErr(td.Write(dir))
+	stdout := new(bytes.Buffer)
+	cmd := &verify.Command{
+		Bud:    &command.Bud{Dir: dir},
+		Dirs:   []string{"bud/.cli", "bud/.app"},
+		Stdout: stdout,
+	}
+	is.NoErr(cmd.Run(ctx))
+	is.Equal(stdout.String(), "")
+}
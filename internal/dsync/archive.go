@@ -0,0 +1,131 @@
+package dsync
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+
+	"github.com/livebud/bud/package/vfs"
+)
+
+// ToArchive returns a target that writes the synced files into the zip
+// archive, so a bundle can be built without writing to an intermediate
+// directory. The target starts empty, so every file is created. Closing the
+// zip writer is left to the caller.
+func ToArchive(w *zip.Writer) vfs.ReadWritable {
+	return &zipArchive{archive{dirs: map[string]bool{}}, w}
+}
+
+// ToTarArchive is like ToArchive, but writes into a tar archive
+func ToTarArchive(w *tar.Writer) vfs.ReadWritable {
+	return &tarArchive{archive{dirs: map[string]bool{}}, w}
+}
+
+// archive is an empty filesystem that records the directories it has written
+type archive struct {
+	dirs map[string]bool
+}
+
+func (a *archive) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (a *archive) RemoveAll(path string) error {
+	return nil
+}
+
+// mkdirAll calls mkdir for each directory that hasn't been written yet, from
+// the top-most directory down
+func (a *archive) mkdirAll(dir string, mkdir func(dir string) error) error {
+	dir = filepath.ToSlash(dir)
+	if dir == "." || dir == "/" || a.dirs[dir] {
+		return nil
+	}
+	if err := a.mkdirAll(path.Dir(dir), mkdir); err != nil {
+		return err
+	}
+	if err := mkdir(dir); err != nil {
+		return err
+	}
+	a.dirs[dir] = true
+	return nil
+}
+
+type zipArchive struct {
+	archive
+	w *zip.Writer
+}
+
+func (z *zipArchive) MkdirAll(dir string, perm fs.FileMode) error {
+	return z.mkdirAll(dir, func(dir string) error {
+		header := &zip.FileHeader{Name: dir + "/", Method: zip.Store, Modified: vfs.Now()}
+		header.SetMode(fs.ModeDir | perm)
+		_, err := z.w.CreateHeader(header)
+		return err
+	})
+}
+
+func (z *zipArchive) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	w, err := z.Create(name, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+func (z *zipArchive) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	header := &zip.FileHeader{Name: filepath.ToSlash(name), Method: zip.Deflate, Modified: vfs.Now()}
+	header.SetMode(perm)
+	w, err := z.w.CreateHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	return nopCloser{w}, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// tarArchive doesn't support streaming because tar headers need the size of
+// the file up front
+type tarArchive struct {
+	archive
+	w *tar.Writer
+}
+
+func (t *tarArchive) MkdirAll(dir string, perm fs.FileMode) error {
+	return t.mkdirAll(dir, func(dir string) error {
+		return t.w.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeDir,
+			Name:     dir + "/",
+			Mode:     int64(perm.Perm()),
+			ModTime:  vfs.Now(),
+		})
+	})
+}
+
+func (t *tarArchive) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	err := t.w.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     filepath.ToSlash(name),
+		Mode:     int64(perm.Perm()),
+		Size:     int64(len(data)),
+		ModTime:  vfs.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = t.w.Write(data)
+	return err
+}
@@ -0,0 +1,157 @@
+package dsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/livebud/bud/package/vfs"
+)
+
+// WithAtomic applies the operations all or nothing. Before an operation
+// changes the target, the paths it touches are staged in a journal. If an
+// operation fails partway through the sync, the journal is replayed to
+// restore the target to how it was before the sync.
+func WithAtomic() Option {
+	return func(o *option) {
+		o.atomic = true
+	}
+}
+
+func applyAtomic(ctx context.Context, opt *option, tfs vfs.ReadWritable, ops []Op) error {
+	journal := &journal{tfs: tfs}
+	for _, op := range ops {
+		if err := ctx.Err(); err != nil {
+			return journal.abort(err)
+		}
+		if err := journal.record(op); err != nil {
+			return journal.abort(err)
+		}
+		if err := applyOp(opt, tfs, op); err != nil {
+			return journal.abort(err)
+		}
+	}
+	return nil
+}
+
+// journal of the target before each operation was applied
+type journal struct {
+	tfs       vfs.ReadWritable
+	snapshots []*snapshot
+}
+
+// snapshot of a path in the target. Directories include everything inside.
+type snapshot struct {
+	path    string
+	entries []*snapshotEntry // empty if the path didn't exist
+}
+
+type snapshotEntry struct {
+	path    string
+	mode    fs.FileMode
+	modTime time.Time
+	data    []byte
+	link    string
+}
+
+// record the paths that the operation will change
+func (j *journal) record(op Op) error {
+	if op.Type == RenameType {
+		snapshot, err := j.snapshot(string(op.Data))
+		if err != nil {
+			return err
+		}
+		j.snapshots = append(j.snapshots, snapshot)
+	}
+	path := op.Path
+	if op.Type == CreateType || op.Type == SymlinkType {
+		// Missing parent directories are created, so record the top-most one
+		for dir := filepath.Dir(path); dir != "." && dir != "/"; dir = filepath.Dir(dir) {
+			if _, err := fs.Stat(j.tfs, dir); err == nil {
+				break
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			path = dir
+		}
+	}
+	snapshot, err := j.snapshot(path)
+	if err != nil {
+		return err
+	}
+	j.snapshots = append(j.snapshots, snapshot)
+	return nil
+}
+
+func (j *journal) snapshot(path string) (*snapshot, error) {
+	snapshot := &snapshot{path: path}
+	err := fs.WalkDir(j.tfs, path, func(path string, de fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := de.Info()
+		if err != nil {
+			return err
+		}
+		entry := &snapshotEntry{path: path, mode: info.Mode(), modTime: info.ModTime()}
+		switch {
+		case de.Type()&fs.ModeSymlink != 0:
+			entry.link = readlink(j.tfs, path)
+		case !de.IsDir():
+			data, err := fs.ReadFile(j.tfs, path)
+			if err != nil {
+				return err
+			}
+			entry.data = data
+		}
+		snapshot.entries = append(snapshot.entries, entry)
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// abort restores the snapshots in reverse order
+func (j *journal) abort(err error) error {
+	for i := len(j.snapshots) - 1; i >= 0; i-- {
+		if rerr := j.restore(j.snapshots[i]); rerr != nil {
+			return fmt.Errorf("dsync: unable to roll back %q. %s. %w", j.snapshots[i].path, rerr, err)
+		}
+	}
+	return err
+}
+
+func (j *journal) restore(snapshot *snapshot) error {
+	if err := j.tfs.RemoveAll(snapshot.path); err != nil {
+		return err
+	}
+	for _, entry := range snapshot.entries {
+		switch {
+		case entry.mode&fs.ModeSymlink != 0:
+			target, ok := j.tfs.(symlinker)
+			if !ok {
+				continue
+			}
+			if err := target.Symlink(entry.link, entry.path); err != nil {
+				return err
+			}
+		case entry.mode.IsDir():
+			if err := j.tfs.MkdirAll(entry.path, entry.mode.Perm()); err != nil {
+				return err
+			}
+		default:
+			if err := j.tfs.WriteFile(entry.path, entry.data, entry.mode.Perm()); err != nil {
+				return err
+			}
+			if err := chtimes(j.tfs, entry.path, entry.modTime); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
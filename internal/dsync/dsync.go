@@ -1,20 +1,48 @@
 package dsync
 
 import (
+	"context"
 	"errors"
 	"io/fs"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/livebud/bud/internal/dsync/set"
+	"github.com/livebud/bud/package/log"
 	"github.com/livebud/bud/package/vfs"
+	"github.com/monochromegane/go-gitignore"
 )
 
 type skipFunc = func(name string, isDir bool) bool
 
 type option struct {
-	Skip skipFunc
-	rel  func(path string) (string, error)
+	Skip           skipFunc
+	sfs            fs.FS
+	sdir           string
+	rel            func(path string) (string, error)
+	digests        *digestCache
+	followSymlinks bool
+	dirMode        fs.FileMode
+	fileMode       fs.FileMode
+	excludes       []gitignore.IgnoreMatcher
+	includes       []gitignore.IgnoreMatcher
+	noDelete       bool
+	atomic         bool
+	transforms     []transformFunc
+	streamSize     int64
+	continueOnErr  bool
+	errors         Errors   // errors collected while continuing on errors
+	skipped        []string // paths skipped during the sync
+	ignoreFiles    []string
+	ignores        map[string]gitignore.IgnoreMatcher // ignore files read during the sync
+	indexPath      string
+	index          *index // stamps from the last sync
+	basePath       string
+	bases          *bases // contents from the last sync when merging
+	keepEmptyDirs  bool
+	pruneEmptyDirs bool
+	log            log.Logger
 }
 
 type Option func(o *option)
@@ -30,6 +58,14 @@ func WithSkip(skips ...skipFunc) Option {
 	}
 }
 
+// WithoutDelete keeps files in the target that aren't in the source. This is
+// useful when the target also contains files that were written by hand.
+func WithoutDelete() Option {
+	return func(o *option) {
+		o.noDelete = true
+	}
+}
+
 func composeSkips(skips []skipFunc) skipFunc {
 	return func(name string, isDir bool) bool {
 		for _, skip := range skips {
@@ -51,22 +87,77 @@ func Rel(sdir, tdir string) func(path string) (string, error) {
 	}
 }
 
-// Dir syncs the source directory from the source filesystem to the target directory
-// in the target filesystem
-func Dir(sfs fs.FS, sdir string, tfs vfs.ReadWritable, tdir string, options ...Option) error {
+func newOption(sfs fs.FS, sdir, tdir string, options []Option) *option {
 	opt := &option{
-		Skip: func(name string, isDir bool) bool { return false },
-		rel:  Rel(sdir, tdir),
+		Skip:       func(name string, isDir bool) bool { return false },
+		sfs:        sfs,
+		sdir:       sdir,
+		rel:        Rel(sdir, tdir),
+		dirMode:    0755,
+		streamSize: defaultStreamSize,
 	}
 	for _, option := range options {
 		option(opt)
 	}
-	ops, err := diff(opt, sfs, sdir, tfs, tdir)
+	return opt
+}
+
+// Dir syncs the source directory from the source filesystem to the target directory
+// in the target filesystem
+func Dir(sfs fs.FS, sdir string, tfs vfs.ReadWritable, tdir string, options ...Option) (*Result, error) {
+	return DirContext(context.Background(), sfs, sdir, tfs, tdir, options...)
+}
+
+// DirContext is like Dir, but stops syncing when the context is canceled
+func DirContext(ctx context.Context, sfs fs.FS, sdir string, tfs vfs.ReadWritable, tdir string, options ...Option) (*Result, error) {
+	start := time.Now()
+	opt := newOption(sfs, sdir, tdir, options)
+	opt.loadIndex(tfs)
+	opt.loadBases(tfs)
+	ops, err := diff(ctx, opt, sfs, sdir, tfs, tdir)
+	if err != nil {
+		return nil, err
+	}
+	return finish(ctx, opt, tfs, ops, start)
+}
+
+// finish applies the operations and summarizes the result
+func finish(ctx context.Context, opt *option, tfs vfs.ReadWritable, ops []Op, start time.Time) (*Result, error) {
+	applied, err := apply(ctx, opt, tfs, ops)
+	if err != nil {
+		// The sync already failed, so removing the index is best-effort
+		opt.removeIndex(tfs)
+		return nil, err
+	}
+	if err := opt.saveIndex(tfs); err != nil {
+		return nil, err
+	}
+	if err := opt.saveBases(tfs, applied); err != nil {
+		return nil, err
+	}
+	result := summarize(applied)
+	result.Skipped = opt.skipped
+	result.Duration = time.Since(start)
+	if len(opt.errors) > 0 {
+		return result, opt.errors
+	}
+	return result, nil
+}
+
+// Diff returns the operations that Dir would apply to sync the source directory
+// to the target directory, without changing the target filesystem
+func Diff(sfs fs.FS, sdir string, tfs fs.FS, tdir string, options ...Option) ([]Op, error) {
+	opt := newOption(sfs, sdir, tdir, options)
+	opt.loadIndex(tfs)
+	opt.loadBases(tfs)
+	ops, err := diff(context.Background(), opt, sfs, sdir, tfs, tdir)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if len(opt.errors) > 0 {
+		return ops, opt.errors
 	}
-	err = apply(sfs, tfs, ops)
-	return err
+	return ops, nil
 }
 
 type OpType uint8
@@ -79,6 +170,12 @@ func (ot OpType) String() string {
 		return "update"
 	case DeleteType:
 		return "delete"
+	case SymlinkType:
+		return "symlink"
+	case RenameType:
+		return "rename"
+	case ConflictType:
+		return "conflict"
 	default:
 		return ""
 	}
@@ -88,19 +185,35 @@ const (
 	CreateType OpType = iota + 1
 	UpdateType
 	DeleteType
+	SymlinkType
+	RenameType
+	ConflictType
 )
 
+// Op is an operation on the target filesystem. Symlink operations store the
+// symlink's destination in Data and rename operations store the old path.
+// Conflict operations store the source's contents, but aren't applied.
+// Create operations with a directory mode create an empty directory.
 type Op struct {
 	Type OpType
 	Path string
 	Data []byte
+	Mode fs.FileMode // Permissions of created and updated files
+	// Modtime of the source file, applied to created and updated files
+	modTime time.Time
+	// Large files are copied from the source when the operation is applied
+	// instead of being read into Data
+	stream *stream
 }
 
 func (o Op) String() string {
 	return o.Type.String() + ":" + o.Path
 }
 
-func diff(opt *option, sfs fs.FS, sdir string, tfs vfs.ReadWritable, tdir string) (ops []Op, err error) {
+func diff(ctx context.Context, opt *option, sfs fs.FS, sdir string, tfs fs.FS, tdir string) (ops []Op, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	sourceEntries, err := fs.ReadDir(sfs, sdir)
 	if err != nil {
 		return nil, err
@@ -113,8 +226,19 @@ func diff(opt *option, sfs fs.FS, sdir string, tfs vfs.ReadWritable, tdir string
 	targetSet := set.New(targetEntries...)
 	creates := set.Difference(sourceSet, targetSet)
 	deletes := set.Difference(targetSet, sourceSet)
-	updates := set.Intersection(sourceSet, targetSet)
-	createOps, err := createOps(opt, sfs, sdir, creates.List())
+	renames := caseRenames(opt, sdir, creates, deletes)
+	// Use the source entries for updates, since the source decides the type
+	var updates []fs.DirEntry
+	for _, de := range sourceEntries {
+		if targetSet.Has(de) {
+			updates = append(updates, de)
+		}
+	}
+	renameOps, err := renameOps(ctx, opt, sfs, sdir, tfs, tdir, renames)
+	if err != nil {
+		return nil, err
+	}
+	createOps, err := createOps(ctx, opt, sfs, tfs, sdir, creates.List())
 	if err != nil {
 		return nil, err
 	}
@@ -122,141 +246,331 @@ func diff(opt *option, sfs fs.FS, sdir string, tfs vfs.ReadWritable, tdir string
 	if err != nil {
 		return nil, err
 	}
-	childOps, err := updateOps(opt, sfs, sdir, tfs, tdir, updates.List())
+	childOps, err := updateOps(ctx, opt, sfs, sdir, tfs, tdir, updates)
 	if err != nil {
 		return nil, err
 	}
+	ops = append(ops, renameOps...)
 	ops = append(ops, createOps...)
 	ops = append(ops, deleteOps...)
 	ops = append(ops, childOps...)
 	return ops, nil
 }
 
-func createOps(opt *option, sfs fs.FS, dir string, des []fs.DirEntry) (ops []Op, err error) {
+func createOps(ctx context.Context, opt *option, sfs, tfs fs.FS, dir string, des []fs.DirEntry) (ops []Op, err error) {
 	for _, de := range des {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		if de.Name() == "." {
 			continue
 		}
 		path := filepath.Join(dir, de.Name())
-		if opt.Skip(path, de.IsDir()) {
+		if opt.skip(path, de.IsDir()) {
 			continue
 		}
-		if !de.IsDir() {
-			data, err := fs.ReadFile(sfs, path)
-			if err != nil {
-				// Don't error out on files that don't exist
-				if errors.Is(err, fs.ErrNotExist) {
-					continue
-				}
-				return nil, err
-			}
-			rel, err := opt.rel(path)
-			if err != nil {
+		entryOps, err := createEntry(ctx, opt, sfs, tfs, path, de)
+		if err != nil {
+			if err := opt.fail(path, err); err != nil {
 				return nil, err
 			}
-			ops = append(ops, Op{CreateType, rel, data})
 			continue
 		}
-		des, err := fs.ReadDir(sfs, path)
+		ops = append(ops, entryOps...)
+	}
+	return ops, nil
+}
+
+func createEntry(ctx context.Context, opt *option, sfs, tfs fs.FS, path string, de fs.DirEntry) (ops []Op, err error) {
+	link, ok, err := symlink(opt, sfs, tfs, path, de)
+	if err != nil {
+		return nil, err
+	} else if ok {
+		rel, err := opt.rel(path)
 		if err != nil {
 			return nil, err
 		}
-		createOps, err := createOps(opt, sfs, path, des)
+		opt.debug("dsync: symlink", "path", rel, "reason", "missing")
+		return []Op{{Type: SymlinkType, Path: rel, Data: []byte(link)}}, nil
+	}
+	isDir, err := isDir(sfs, path, de)
+	if err != nil {
+		// Don't error out on broken symlinks
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !isDir {
+		op, err := opt.fileOp(CreateType, sfs, path, de)
 		if err != nil {
+			// Don't error out on files that don't exist
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, nil
+			}
 			return nil, err
 		}
-		ops = append(ops, createOps...)
+		opt.debug("dsync: create", "path", op.Path, "reason", "missing")
+		return []Op{op}, nil
+	}
+	des, err := fs.ReadDir(sfs, path)
+	if err != nil {
+		return nil, err
+	}
+	ops, err = createOps(ctx, opt, sfs, tfs, path, des)
+	if err != nil {
+		return nil, err
+	} else if len(ops) == 0 {
+		return emptyDir(opt, path)
 	}
 	return ops, nil
 }
 
 func deleteOps(opt *option, dir string, des []fs.DirEntry) (ops []Op, err error) {
+	if opt.noDelete {
+		return nil, nil
+	}
 	for _, de := range des {
 		// Don't allow the directory itself to be deleted
 		if de.Name() == "." {
 			continue
 		}
 		path := filepath.Join(dir, de.Name())
-		if opt.Skip(path, de.IsDir()) {
+		if opt.skip(path, de.IsDir()) {
 			continue
 		}
 		rel, err := opt.rel(path)
 		if err != nil {
 			return nil, err
 		}
-		ops = append(ops, Op{DeleteType, rel, nil})
+		// Don't delete the state that dsync keeps in the target
+		if opt.isState(rel) {
+			continue
+		}
+		opt.debug("dsync: delete", "path", rel, "reason", "not in source")
+		ops = append(ops, Op{Type: DeleteType, Path: rel})
 		continue
 	}
 	return ops, nil
 }
 
-func updateOps(opt *option, sfs fs.FS, sdir string, tfs vfs.ReadWritable, tdir string, des []fs.DirEntry) (ops []Op, err error) {
+func updateOps(ctx context.Context, opt *option, sfs fs.FS, sdir string, tfs fs.FS, tdir string, des []fs.DirEntry) (ops []Op, err error) {
 	for _, de := range des {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		if de.Name() == "." {
 			continue
 		}
 		path := filepath.Join(sdir, de.Name())
-		if opt.Skip(path, de.IsDir()) {
+		if opt.skip(path, de.IsDir()) {
 			continue
 		}
-		// Recurse directories
-		if de.IsDir() {
-			childOps, err := diff(opt, sfs, path, tfs, path)
-			if err != nil {
+		tpath := filepath.Join(tdir, de.Name())
+		entryOps, err := updateEntry(ctx, opt, sfs, path, tfs, tpath, de)
+		if err != nil {
+			if err := opt.fail(path, err); err != nil {
 				return nil, err
 			}
-			ops = append(ops, childOps...)
 			continue
 		}
-		// Otherwise, check if the file has changed
-		sourceStamp, err := stamp(sfs, path)
+		ops = append(ops, entryOps...)
+	}
+	return ops, nil
+}
+
+func updateEntry(ctx context.Context, opt *option, sfs fs.FS, path string, tfs fs.FS, tpath string, de fs.DirEntry) (ops []Op, err error) {
+	// Replicate symlinks that have changed
+	link, ok, err := symlink(opt, sfs, tfs, path, de)
+	if err != nil {
+		return nil, err
+	} else if ok {
+		if readlink(tfs, tpath) == link {
+			opt.debug("dsync: unchanged", "path", tpath, "reason", "link")
+			return nil, nil
+		}
+		rel, err := opt.rel(path)
 		if err != nil {
 			return nil, err
 		}
-		targetStamp, err := stamp(tfs, path)
+		opt.debug("dsync: symlink", "path", rel, "reason", "link")
+		return []Op{{Type: SymlinkType, Path: rel, Data: []byte(link)}}, nil
+	}
+	isDir, err := isDir(sfs, path, de)
+	if err != nil {
+		// Don't error out on broken symlinks
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	// Recurse directories
+	if isDir {
+		ops, err := diff(ctx, opt, sfs, path, tfs, tpath)
 		if err != nil {
 			return nil, err
 		}
-		// Skip if the source and target are the same
-		if sourceStamp == targetStamp {
-			continue
+		return prune(opt, tfs, tpath, ops)
+	}
+	// Skip files that haven't changed since the last sync
+	var indexed string
+	rel, err := opt.rel(path)
+	if err != nil {
+		return nil, err
+	}
+	if opt.index != nil {
+		info, err := fileInfo(sfs, path, de)
+		if err != nil {
+			// Don't error out on files that don't exist
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, nil
+			}
+			return nil, err
 		}
-		data, err := fs.ReadFile(sfs, path)
+		indexed = indexStamp(info)
+		if opt.index.unchanged(rel, indexed) {
+			opt.debug("dsync: unchanged", "path", rel, "reason", "index")
+			opt.index.record(rel, indexed)
+			return nil, nil
+		}
+	}
+	// Keep the edits made to the target when merging
+	if opt.bases != nil {
+		op, err := opt.fileOp(UpdateType, sfs, path, de)
 		if err != nil {
 			// Don't error out on files that don't exist
 			if errors.Is(err, fs.ErrNotExist) {
-				continue
+				return nil, nil
 			}
 			return nil, err
 		}
-		rel, err := opt.rel(path)
+		op, ok, err := opt.bases.merge(op, tfs, tpath)
 		if err != nil {
 			return nil, err
+		} else if !ok {
+			opt.debug("dsync: unchanged", "path", rel, "reason", "merge")
+			return nil, nil
+		} else if op.Type == ConflictType {
+			opt.debug("dsync: conflict", "path", rel, "reason", "changed in source and target")
+			// Keep surfacing the conflict until it's resolved
+			opt.index.forget(rel)
+			return []Op{op}, nil
 		}
-		ops = append(ops, Op{UpdateType, rel, data})
+		opt.debug("dsync: update", "path", rel, "reason", "changed in source")
+		return []Op{op}, nil
 	}
-	return ops, nil
+	// Compare the contents when hashing
+	if opt.digests != nil {
+		op, err := opt.fileOp(UpdateType, sfs, path, de)
+		if err != nil {
+			// Don't error out on files that don't exist
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		changed, err := opt.digests.changed(op, tfs, tpath)
+		if err != nil {
+			return nil, err
+		}
+		if !changed {
+			opt.debug("dsync: unchanged", "path", rel, "reason", "hash")
+			opt.index.record(rel, indexed)
+			return nil, nil
+		}
+		opt.debug("dsync: update", "path", rel, "reason", "hash")
+		return []Op{op}, nil
+	}
+	// Otherwise, check if the file has changed
+	sourceStamp, err := stamp(sfs, path)
+	if err != nil {
+		return nil, err
+	}
+	targetStamp, err := stamp(tfs, tpath)
+	if err != nil {
+		return nil, err
+	}
+	// Skip if the source and target are the same
+	if sourceStamp == targetStamp {
+		opt.debug("dsync: unchanged", "path", rel, "reason", "stamp")
+		opt.index.record(rel, indexed)
+		return nil, nil
+	}
+	if opt.log != nil {
+		opt.log.Debug("dsync: update", "path", rel, "reason", stampReason(sourceStamp, targetStamp), "source", sourceStamp, "target", targetStamp)
+	}
+	op, err := opt.fileOp(UpdateType, sfs, path, de)
+	if err != nil {
+		// Don't error out on files that don't exist
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return []Op{op}, nil
 }
 
-func apply(sfs fs.FS, tfs vfs.ReadWritable, ops []Op) error {
+// apply the operations, returning the operations that were applied
+func apply(ctx context.Context, opt *option, tfs vfs.ReadWritable, ops []Op) (applied []Op, err error) {
+	if opt.atomic {
+		if err := applyAtomic(ctx, opt, tfs, ops); err != nil {
+			return nil, err
+		}
+		return ops, nil
+	}
 	for _, op := range ops {
-		switch op.Type {
-		case CreateType:
-			dir := filepath.Dir(op.Path)
-			if err := tfs.MkdirAll(dir, 0755); err != nil {
-				return err
-			}
-			if err := tfs.WriteFile(op.Path, op.Data, 0644); err != nil {
-				return err
-			}
-		case UpdateType:
-			if err := tfs.WriteFile(op.Path, op.Data, 0644); err != nil {
-				return err
-			}
-		case DeleteType:
-			if err := tfs.RemoveAll(op.Path); err != nil {
-				return err
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := applyOp(opt, tfs, op); err != nil {
+			if err := opt.fail(op.Path, err); err != nil {
+				return nil, err
 			}
+			continue
+		}
+		applied = append(applied, op)
+	}
+	return applied, nil
+}
+
+func applyOp(opt *option, tfs vfs.ReadWritable, op Op) error {
+	switch op.Type {
+	case CreateType:
+		if op.Mode.IsDir() {
+			return tfs.MkdirAll(op.Path, op.Mode.Perm())
+		}
+		dir := filepath.Dir(op.Path)
+		if err := tfs.MkdirAll(dir, opt.dirMode); err != nil {
+			return err
+		}
+		if err := writeFile(tfs, op); err != nil {
+			return err
+		}
+		if err := chtimes(tfs, op.Path, op.modTime); err != nil {
+			return err
+		}
+	case UpdateType:
+		if err := writeFile(tfs, op); err != nil {
+			return err
+		}
+		// Writing doesn't change the permissions of existing files
+		if err := chmod(tfs, op.Path, op.Mode); err != nil {
+			return err
+		}
+		if err := chtimes(tfs, op.Path, op.modTime); err != nil {
+			return err
+		}
+	case DeleteType:
+		if err := tfs.RemoveAll(op.Path); err != nil {
+			return err
+		}
+	case SymlinkType:
+		if err := writeSymlink(tfs, string(op.Data), op.Path, opt.dirMode); err != nil {
+			return err
+		}
+	case RenameType:
+		if err := rename(tfs, string(op.Data), op.Path); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -272,9 +586,12 @@ func stamp(fsys fs.FS, path string) (stamp string, err error) {
 		}
 		return "", err
 	}
+	return stampOf(stat), nil
+}
+
+func stampOf(stat fs.FileInfo) string {
 	mtime := stat.ModTime().UnixNano()
 	mode := stat.Mode()
 	size := stat.Size()
-	stamp = strconv.Itoa(int(size)) + ":" + mode.String() + ":" + strconv.Itoa(int(mtime))
-	return stamp, nil
+	return strconv.Itoa(int(size)) + ":" + mode.String() + ":" + strconv.Itoa(int(mtime))
 }
@@ -1,14 +1,22 @@
 package dsync_test
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"errors"
+	"io"
 	"io/fs"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/livebud/bud/internal/dsync"
 	"github.com/livebud/bud/package/conjure"
+	"github.com/livebud/bud/package/log"
 	"github.com/livebud/bud/package/vfs"
 	"github.com/matryer/is"
 )
@@ -30,7 +38,7 @@ func TestFileSync(t *testing.T) {
 	}
 
 	// sync
-	err := dsync.Dir(sourceFS, ".", targetFS, ".")
+	_, err := dsync.Dir(sourceFS, ".", targetFS, ".")
 	is.NoErr(err)
 	is.Equal(len(targetFS), 2)
 
@@ -76,7 +84,7 @@ func TestDirSync(t *testing.T) {
 	}
 
 	// sync
-	err := dsync.Dir(sourceFS, "duo", targetFS, "duo")
+	_, err := dsync.Dir(sourceFS, "duo", targetFS, "duo")
 	is.NoErr(err)
 	is.Equal(len(targetFS), 5)
 
@@ -151,7 +159,7 @@ func TestNoDuo(t *testing.T) {
 	targetFS := vfs.Memory{}
 
 	// sync
-	err := dsync.Dir(sourceFS, "duo", targetFS, "duo")
+	_, err := dsync.Dir(sourceFS, "duo", targetFS, "duo")
 	is.NoErr(err)
 	is.Equal(len(targetFS), 2)
 
@@ -191,7 +199,7 @@ func TestSkipNotExist(t *testing.T) {
 	targetFS := vfs.Memory{}
 
 	// sync
-	err := dsync.Dir(sourceFS, ".", targetFS, ".")
+	_, err := dsync.Dir(sourceFS, ".", targetFS, ".")
 	is.NoErr(err)
 	is.Equal(len(targetFS), 0)
 }
@@ -210,7 +218,7 @@ func TestErrorGenerator(t *testing.T) {
 	targetFS := vfs.Memory{}
 
 	// sync
-	err := dsync.Dir(sourceFS, ".", targetFS, ".")
+	_, err := dsync.Dir(sourceFS, ".", targetFS, ".")
 	is.True(err != nil)
 	is.Equal(err.Error(), `conjure: generate "bud/generate/main.go" > uh oh`)
 	is.Equal(len(targetFS), 0)
@@ -225,7 +233,7 @@ func TestWithSkip(t *testing.T) {
 	targetFS := vfs.Memory{
 		"node_modules/svelte/svelte.js": &vfs.File{Data: []byte("svelte")},
 	}
-	err := dsync.Dir(sourceFS, ".", targetFS, ".")
+	_, err := dsync.Dir(sourceFS, ".", targetFS, ".")
 	is.NoErr(err)
 	is.Equal(len(targetFS), 1) // this should have deleted node_modules
 	// starting points
@@ -244,7 +252,7 @@ func TestWithSkip(t *testing.T) {
 	skip2 := func(name string, isDir bool) bool {
 		return !isDir && name == "bud/generate.go"
 	}
-	err = dsync.Dir(sourceFS, ".", targetFS, ".", dsync.WithSkip(skip1, skip2))
+	_, err = dsync.Dir(sourceFS, ".", targetFS, ".", dsync.WithSkip(skip1, skip2))
 	is.NoErr(err)
 	is.Equal(len(targetFS), 4) // this should have kept node_modules & generate
 }
@@ -256,7 +264,7 @@ func TestAvoidDotCreate(t *testing.T) {
 		".": &vfs.File{Mode: fs.ModeDir},
 	}
 	targetFS := vfs.Memory{}
-	err := dsync.Dir(sourceFS, ".", targetFS, ".")
+	_, err := dsync.Dir(sourceFS, ".", targetFS, ".")
 	is.NoErr(err)
 	is.Equal(len(targetFS), 0)
 }
@@ -270,7 +278,7 @@ func TestAvoidDotUpdate(t *testing.T) {
 	targetFS := vfs.Memory{
 		".": &vfs.File{Mode: fs.ModeDir | 0755},
 	}
-	err := dsync.Dir(sourceFS, ".", targetFS, ".")
+	_, err := dsync.Dir(sourceFS, ".", targetFS, ".")
 	is.NoErr(err)
 	is.Equal(len(targetFS), 1)
 }
@@ -283,7 +291,7 @@ func TestAvoidDotDelete(t *testing.T) {
 	targetFS := vfs.Memory{
 		".": &vfs.File{Mode: fs.ModeDir},
 	}
-	err := dsync.Dir(sourceFS, ".", targetFS, ".")
+	_, err := dsync.Dir(sourceFS, ".", targetFS, ".")
 	is.NoErr(err)
 	// . should be ignored
 	is.Equal(len(targetFS), 1)
@@ -300,7 +308,7 @@ func TestRelativeSource(t *testing.T) {
 	targetFS := vfs.Memory{
 		"a/a.go": &vfs.File{Data: []byte("package aa")},
 	}
-	err := dsync.Dir(sourceFS, "bud/.cli", targetFS, ".")
+	_, err := dsync.Dir(sourceFS, "bud/.cli", targetFS, ".")
 	is.NoErr(err)
 	_, ok := targetFS["main.go"]
 	is.True(ok) // missing main.go
@@ -324,3 +332,929 @@ func TestRel(t *testing.T) {
 	is.NoErr(err)
 	is.Equal(rel, "app/a/a.go")
 }
+
+func TestDiff(t *testing.T) {
+	is := is.New(t)
+	before := time.Date(2021, 8, 4, 14, 56, 0, 0, time.UTC)
+	after := time.Date(2021, 8, 4, 14, 57, 0, 0, time.UTC)
+	sourceFS := vfs.Memory{
+		"bud/.cli/main.go": &vfs.File{Data: []byte("package main"), ModTime: after},
+		"bud/.cli/a/a.go":  &vfs.File{Data: []byte("package a"), ModTime: after},
+	}
+	targetFS := vfs.Memory{
+		"a/a.go": &vfs.File{Data: []byte("package aa"), ModTime: before},
+		"b/b.go": &vfs.File{Data: []byte("package b"), ModTime: before},
+	}
+	ops, err := dsync.Diff(sourceFS, "bud/.cli", targetFS, ".")
+	is.NoErr(err)
+	is.Equal(len(ops), 3)
+	is.Equal(ops[0].String(), "create:main.go")
+	is.Equal(string(ops[0].Data), "package main")
+	is.Equal(ops[1].String(), "delete:b")
+	is.Equal(ops[2].String(), "update:a/a.go")
+	is.Equal(string(ops[2].Data), "package a")
+	// Target is unchanged
+	is.Equal(len(targetFS), 2)
+	data, err := fs.ReadFile(targetFS, "a/a.go")
+	is.NoErr(err)
+	is.Equal(string(data), "package aa")
+}
+
+func TestWithHash(t *testing.T) {
+	is := is.New(t)
+	before := time.Date(2021, 8, 4, 14, 56, 0, 0, time.UTC)
+	after := time.Date(2021, 8, 4, 14, 57, 0, 0, time.UTC)
+	sourceFS := vfs.Memory{
+		// Touched, but the same contents
+		"a.txt": &vfs.File{Data: []byte("a"), ModTime: after},
+		// Same size and modtime, but different contents
+		"b.txt": &vfs.File{Data: []byte("b"), ModTime: before},
+	}
+	targetFS := vfs.Memory{
+		"a.txt": &vfs.File{Data: []byte("a"), ModTime: before},
+		"b.txt": &vfs.File{Data: []byte("c"), ModTime: before},
+	}
+	ops, err := dsync.Diff(sourceFS, ".", targetFS, ".")
+	is.NoErr(err)
+	is.Equal(len(ops), 1)
+	is.Equal(ops[0].String(), "update:a.txt")
+	ops, err = dsync.Diff(sourceFS, ".", targetFS, ".", dsync.WithHash(sha256.New))
+	is.NoErr(err)
+	is.Equal(len(ops), 1)
+	is.Equal(ops[0].String(), "update:b.txt")
+	is.Equal(string(ops[0].Data), "b")
+}
+
+func TestWithHashCache(t *testing.T) {
+	is := is.New(t)
+	before := time.Date(2021, 8, 4, 14, 56, 0, 0, time.UTC)
+	sourceFS := vfs.Memory{
+		"a.txt": &vfs.File{Data: []byte("a")},
+	}
+	targetFS := vfs.Memory{
+		"a.txt": &vfs.File{Data: []byte("b"), ModTime: before},
+	}
+	hash := dsync.WithHash(sha256.New)
+	ops, err := dsync.Diff(sourceFS, ".", targetFS, ".", hash)
+	is.NoErr(err)
+	is.Equal(len(ops), 1)
+	// Change the contents without changing the stamp, the cached digest is used
+	targetFS["a.txt"].Data = []byte("a")
+	ops, err = dsync.Diff(sourceFS, ".", targetFS, ".", hash)
+	is.NoErr(err)
+	is.Equal(len(ops), 1)
+	// Changing the stamp rereads the file
+	targetFS["a.txt"].ModTime = before.Add(time.Second)
+	ops, err = dsync.Diff(sourceFS, ".", targetFS, ".", hash)
+	is.NoErr(err)
+	is.Equal(len(ops), 0)
+}
+
+func TestSymlink(t *testing.T) {
+	is := is.New(t)
+	sourceDir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(sourceDir, "view"), 0755)
+	is.NoErr(err)
+	err = os.WriteFile(filepath.Join(sourceDir, "view", "index.svelte"), []byte("<h1>index</h1>"), 0644)
+	is.NoErr(err)
+	err = os.Symlink("view/index.svelte", filepath.Join(sourceDir, "index.svelte"))
+	is.NoErr(err)
+	err = os.Symlink("view", filepath.Join(sourceDir, "views"))
+	is.NoErr(err)
+	targetDir := t.TempDir()
+	// Replicate symlinks
+	_, err = dsync.Dir(vfs.OS(sourceDir), ".", vfs.OS(targetDir), ".")
+	is.NoErr(err)
+	link, err := os.Readlink(filepath.Join(targetDir, "index.svelte"))
+	is.NoErr(err)
+	is.Equal(link, "view/index.svelte")
+	link, err = os.Readlink(filepath.Join(targetDir, "views"))
+	is.NoErr(err)
+	is.Equal(link, "view")
+	// Unchanged symlinks are skipped
+	ops, err := dsync.Diff(vfs.OS(sourceDir), ".", vfs.OS(targetDir), ".", dsync.WithHash(sha256.New))
+	is.NoErr(err)
+	is.Equal(len(ops), 0)
+	// Changed symlinks are replaced
+	err = os.Remove(filepath.Join(sourceDir, "views"))
+	is.NoErr(err)
+	err = os.Symlink("view/", filepath.Join(sourceDir, "views"))
+	is.NoErr(err)
+	ops, err = dsync.Diff(vfs.OS(sourceDir), ".", vfs.OS(targetDir), ".", dsync.WithHash(sha256.New))
+	is.NoErr(err)
+	is.Equal(len(ops), 1)
+	is.Equal(ops[0].String(), "symlink:views")
+	is.Equal(string(ops[0].Data), "view/")
+}
+
+func TestFollowSymlinks(t *testing.T) {
+	is := is.New(t)
+	sourceDir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(sourceDir, "view"), 0755)
+	is.NoErr(err)
+	err = os.WriteFile(filepath.Join(sourceDir, "view", "index.svelte"), []byte("<h1>index</h1>"), 0644)
+	is.NoErr(err)
+	err = os.Symlink("view/index.svelte", filepath.Join(sourceDir, "index.svelte"))
+	is.NoErr(err)
+	err = os.Symlink("view", filepath.Join(sourceDir, "views"))
+	is.NoErr(err)
+	err = os.Symlink("missing", filepath.Join(sourceDir, "broken"))
+	is.NoErr(err)
+	targetDir := t.TempDir()
+	_, err = dsync.Dir(vfs.OS(sourceDir), ".", vfs.OS(targetDir), ".", dsync.WithFollowSymlinks())
+	is.NoErr(err)
+	stat, err := os.Lstat(filepath.Join(targetDir, "index.svelte"))
+	is.NoErr(err)
+	is.True(stat.Mode().IsRegular())
+	data, err := os.ReadFile(filepath.Join(targetDir, "views", "index.svelte"))
+	is.NoErr(err)
+	is.Equal(string(data), "<h1>index</h1>")
+	_, err = os.Lstat(filepath.Join(targetDir, "broken"))
+	is.True(errors.Is(err, fs.ErrNotExist))
+	// Targets that don't support symlinks get the contents
+	targetFS := vfs.Memory{}
+	_, err = dsync.Dir(vfs.OS(sourceDir), ".", targetFS, ".")
+	is.NoErr(err)
+	data, err = fs.ReadFile(targetFS, "views/index.svelte")
+	is.NoErr(err)
+	is.Equal(string(data), "<h1>index</h1>")
+}
+
+func TestPreserveMode(t *testing.T) {
+	is := is.New(t)
+	sourceDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(sourceDir, "cli"), []byte("#!/bin/sh"), 0755)
+	is.NoErr(err)
+	err = os.WriteFile(filepath.Join(sourceDir, "main.go"), []byte("package main"), 0600)
+	is.NoErr(err)
+	targetDir := t.TempDir()
+	err = os.WriteFile(filepath.Join(targetDir, "main.go"), []byte("package mainn"), 0644)
+	is.NoErr(err)
+	_, err = dsync.Dir(vfs.OS(sourceDir), ".", vfs.OS(targetDir), ".")
+	is.NoErr(err)
+	stat, err := os.Stat(filepath.Join(targetDir, "cli"))
+	is.NoErr(err)
+	is.Equal(stat.Mode(), fs.FileMode(0755))
+	stat, err = os.Stat(filepath.Join(targetDir, "main.go"))
+	is.NoErr(err)
+	is.Equal(stat.Mode(), fs.FileMode(0600))
+}
+
+func TestWithMode(t *testing.T) {
+	is := is.New(t)
+	after := time.Date(2021, 8, 4, 14, 57, 0, 0, time.UTC)
+	vfs.Now = func() time.Time { return after }
+	sourceFS := vfs.Memory{
+		"bud/cli":     &vfs.File{Data: []byte("#!/bin/sh"), Mode: 0755},
+		"bud/main.go": &vfs.File{Data: []byte("package main"), Mode: 0600},
+	}
+	targetFS := vfs.Memory{}
+	_, err := dsync.Dir(sourceFS, ".", targetFS, ".", dsync.WithFileMode(0644), dsync.WithDirMode(0700))
+	is.NoErr(err)
+	stat, err := fs.Stat(targetFS, "bud")
+	is.NoErr(err)
+	is.Equal(stat.Mode(), fs.FileMode(0700|fs.ModeDir))
+	stat, err = fs.Stat(targetFS, "bud/cli")
+	is.NoErr(err)
+	is.Equal(stat.Mode(), fs.FileMode(0644))
+	stat, err = fs.Stat(targetFS, "bud/main.go")
+	is.NoErr(err)
+	is.Equal(stat.Mode(), fs.FileMode(0644))
+}
+
+func TestWithExclude(t *testing.T) {
+	is := is.New(t)
+	sourceFS := vfs.Memory{
+		"app/index.svelte":        &vfs.File{Data: []byte("<h1>index</h1>")},
+		"app/main.go":             &vfs.File{Data: []byte("package main")},
+		"app/main.test.go":        &vfs.File{Data: []byte("package main")},
+		"app/view/view.test.go":   &vfs.File{Data: []byte("package view")},
+		"app/node_modules/a/a.js": &vfs.File{Data: []byte("a")},
+	}
+	targetFS := vfs.Memory{
+		"node_modules/svelte/svelte.js": &vfs.File{Data: []byte("svelte")},
+	}
+	exclude := dsync.WithExclude("node_modules/**", "*.test.go")
+	_, err := dsync.Dir(sourceFS, "app", targetFS, ".", exclude)
+	is.NoErr(err)
+	is.Equal(len(targetFS), 3)
+	is.True(targetFS["index.svelte"] != nil)
+	is.True(targetFS["main.go"] != nil)
+	// Excluded directories aren't deleted
+	is.True(targetFS["node_modules/svelte/svelte.js"] != nil)
+}
+
+func TestWithInclude(t *testing.T) {
+	is := is.New(t)
+	sourceFS := vfs.Memory{
+		"index.svelte":      &vfs.File{Data: []byte("<h1>index</h1>")},
+		"main.go":           &vfs.File{Data: []byte("package main")},
+		"view/view.go":      &vfs.File{Data: []byte("package view")},
+		"view/view.test.go": &vfs.File{Data: []byte("package view")},
+	}
+	targetFS := vfs.Memory{}
+	_, err := dsync.Dir(sourceFS, ".", targetFS, ".", dsync.WithInclude("*.go"), dsync.WithExclude("*.test.go"))
+	is.NoErr(err)
+	is.True(targetFS["main.go"] != nil)
+	is.True(targetFS["view/view.go"] != nil)
+	is.True(targetFS["index.svelte"] == nil)
+	is.True(targetFS["view/view.test.go"] == nil)
+}
+
+func TestWithoutDelete(t *testing.T) {
+	is := is.New(t)
+	sourceFS := vfs.Memory{
+		"bud/main.go": &vfs.File{Data: []byte("package main")},
+	}
+	targetFS := vfs.Memory{
+		"bud/main.go":     &vfs.File{Data: []byte("package mainn")},
+		"bud/custom.go":   &vfs.File{Data: []byte("package main")},
+		"bud/user/doc.md": &vfs.File{Data: []byte("# docs")},
+	}
+	_, err := dsync.Dir(sourceFS, ".", targetFS, ".", dsync.WithoutDelete())
+	is.NoErr(err)
+	is.Equal(len(targetFS), 3)
+	data, err := fs.ReadFile(targetFS, "bud/main.go")
+	is.NoErr(err)
+	is.Equal(string(data), "package main")
+	is.True(targetFS["bud/custom.go"] != nil)
+	is.True(targetFS["bud/user/doc.md"] != nil)
+}
+
+// failFS fails to write a file
+type failFS struct {
+	vfs.Memory
+	fail string
+}
+
+func (f failFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if name == f.fail {
+		return errors.New("disk full")
+	}
+	return f.Memory.WriteFile(name, data, perm)
+}
+
+func TestWithAtomic(t *testing.T) {
+	is := is.New(t)
+	before := time.Date(2021, 8, 4, 14, 56, 0, 0, time.UTC)
+	after := time.Date(2021, 8, 4, 14, 57, 0, 0, time.UTC)
+	sourceFS := vfs.Memory{
+		"bud/a.go":       &vfs.File{Data: []byte("package a"), ModTime: after},
+		"bud/view/b.go":  &vfs.File{Data: []byte("package b"), ModTime: after},
+		"bud/view/c.go":  &vfs.File{Data: []byte("package c"), ModTime: after},
+		"bud/model/d.go": &vfs.File{Data: []byte("package d"), ModTime: after},
+	}
+	targetFS := vfs.Memory{
+		"bud/a.go":          &vfs.File{Data: []byte("package aa"), Mode: 0644, ModTime: before},
+		"bud/old/old.go":    &vfs.File{Data: []byte("package old"), Mode: 0644, ModTime: before},
+		"bud/view/index.go": &vfs.File{Data: []byte("package view"), Mode: 0644, ModTime: before},
+	}
+	// Fail after creating, updating and deleting files
+	tfs := failFS{targetFS, "bud/view/c.go"}
+	_, err := dsync.Dir(sourceFS, ".", tfs, ".", dsync.WithAtomic())
+	is.True(err != nil)
+	is.Equal(err.Error(), "disk full")
+	// Target is unchanged
+	// The deleted directory is restored as an entry in the memory filesystem
+	is.Equal(len(targetFS), 4)
+	is.True(targetFS["bud/model/d.go"] == nil)
+	is.True(targetFS["bud/view/b.go"] == nil)
+	data, err := fs.ReadFile(targetFS, "bud/a.go")
+	is.NoErr(err)
+	is.Equal(string(data), "package aa")
+	data, err = fs.ReadFile(targetFS, "bud/old/old.go")
+	is.NoErr(err)
+	is.Equal(string(data), "package old")
+	data, err = fs.ReadFile(targetFS, "bud/view/index.go")
+	is.NoErr(err)
+	is.Equal(string(data), "package view")
+	// Succeeds without failures
+	_, err = dsync.Dir(sourceFS, ".", targetFS, ".", dsync.WithAtomic())
+	is.NoErr(err)
+	data, err = fs.ReadFile(targetFS, "bud/view/c.go")
+	is.NoErr(err)
+	is.Equal(string(data), "package c")
+}
+
+func TestResult(t *testing.T) {
+	is := is.New(t)
+	before := time.Date(2021, 8, 4, 14, 56, 0, 0, time.UTC)
+	after := time.Date(2021, 8, 4, 14, 57, 0, 0, time.UTC)
+	sourceFS := vfs.Memory{
+		"a.txt":           &vfs.File{Data: []byte("a"), ModTime: after},
+		"b.txt":           &vfs.File{Data: []byte("bb"), ModTime: after},
+		"c.txt":           &vfs.File{Data: []byte("c"), ModTime: before},
+		"node_modules/xx": &vfs.File{Data: []byte("xx"), ModTime: after},
+	}
+	targetFS := vfs.Memory{
+		"b.txt": &vfs.File{Data: []byte("b"), ModTime: before},
+		"c.txt": &vfs.File{Data: []byte("c"), ModTime: before},
+		"d.txt": &vfs.File{Data: []byte("d"), ModTime: before},
+	}
+	result, err := dsync.Dir(sourceFS, ".", targetFS, ".", dsync.WithExclude("node_modules"))
+	is.NoErr(err)
+	is.Equal(result.Creates, 1)
+	is.Equal(result.Updates, 1)
+	is.Equal(result.Deletes, 1)
+	is.Equal(result.Bytes, int64(3))
+	is.Equal(result.Skipped, []string{"node_modules"})
+	is.True(result.Duration > 0)
+	is.True(result.Changed())
+	// Nothing left to sync
+	result, err = dsync.Dir(sourceFS, ".", targetFS, ".", dsync.WithHash(sha256.New), dsync.WithExclude("node_modules"))
+	is.NoErr(err)
+	is.True(!result.Changed())
+}
+
+func TestDirContextCanceled(t *testing.T) {
+	is := is.New(t)
+	sourceFS := vfs.Memory{
+		"a.txt": &vfs.File{Data: []byte("a")},
+	}
+	targetFS := vfs.Memory{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := dsync.DirContext(ctx, sourceFS, ".", targetFS, ".")
+	is.True(errors.Is(err, context.Canceled))
+	is.Equal(result, nil)
+	is.Equal(len(targetFS), 0)
+}
+
+// cancelFS cancels the context after writing a file
+type cancelFS struct {
+	vfs.Memory
+	cancel context.CancelFunc
+}
+
+func (c cancelFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	defer c.cancel()
+	return c.Memory.WriteFile(name, data, perm)
+}
+
+func TestDirContextCanceledAtomic(t *testing.T) {
+	is := is.New(t)
+	sourceFS := vfs.Memory{
+		"a.txt": &vfs.File{Data: []byte("a")},
+		"b.txt": &vfs.File{Data: []byte("b")},
+	}
+	targetFS := vfs.Memory{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tfs := cancelFS{targetFS, cancel}
+	_, err := dsync.DirContext(ctx, sourceFS, ".", tfs, ".", dsync.WithAtomic())
+	is.True(errors.Is(err, context.Canceled))
+	// The written file was rolled back
+	is.Equal(len(targetFS), 0)
+}
+
+func TestWithTransform(t *testing.T) {
+	is := is.New(t)
+	sourceFS := vfs.Memory{
+		"bud/main.go":      &vfs.File{Data: []byte("package main\n\nimport \"app.com/view\"\n")},
+		"bud/index.svelte": &vfs.File{Data: []byte("<h1>index</h1>")},
+	}
+	targetFS := vfs.Memory{}
+	rewrite := func(path string, data []byte) ([]byte, error) {
+		if filepath.Ext(path) != ".go" {
+			return data, nil
+		}
+		return bytes.ReplaceAll(data, []byte("app.com/"), []byte("app.com/bud/")), nil
+	}
+	stamp := func(path string, data []byte) ([]byte, error) {
+		if filepath.Ext(path) != ".go" {
+			return data, nil
+		}
+		return append([]byte("// Code generated by bud. DO NOT EDIT.\n\n"), data...), nil
+	}
+	_, err := dsync.Dir(sourceFS, ".", targetFS, ".", dsync.WithTransform(rewrite, stamp))
+	is.NoErr(err)
+	data, err := fs.ReadFile(targetFS, "bud/main.go")
+	is.NoErr(err)
+	is.Equal(string(data), "// Code generated by bud. DO NOT EDIT.\n\npackage main\n\nimport \"app.com/bud/view\"\n")
+	data, err = fs.ReadFile(targetFS, "bud/index.svelte")
+	is.NoErr(err)
+	is.Equal(string(data), "<h1>index</h1>")
+	// Transformed contents are compared when hashing
+	ops, err := dsync.Diff(sourceFS, ".", targetFS, ".", dsync.WithTransform(rewrite, stamp), dsync.WithHash(sha256.New))
+	is.NoErr(err)
+	is.Equal(len(ops), 0)
+}
+
+func TestWithTransformUnchanged(t *testing.T) {
+	is := is.New(t)
+	sourceDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(sourceDir, "main.go"), []byte("package main\n"), 0644)
+	is.NoErr(err)
+	targetDir := t.TempDir()
+	stamp := func(path string, data []byte) ([]byte, error) {
+		return append([]byte("// Code generated by bud. DO NOT EDIT.\n\n"), data...), nil
+	}
+	result, err := dsync.Dir(vfs.OS(sourceDir), ".", vfs.OS(targetDir), ".", dsync.WithTransform(stamp))
+	is.NoErr(err)
+	is.Equal(result.Creates, 1)
+	// Syncing an unchanged source again doesn't rewrite the transformed file
+	result, err = dsync.Dir(vfs.OS(sourceDir), ".", vfs.OS(targetDir), ".", dsync.WithTransform(stamp))
+	is.NoErr(err)
+	is.Equal(result.Creates, 0)
+	is.Equal(result.Updates, 0)
+}
+
+func TestWithTransformError(t *testing.T) {
+	is := is.New(t)
+	sourceFS := vfs.Memory{
+		"main.go": &vfs.File{Data: []byte("package main")},
+	}
+	targetFS := vfs.Memory{}
+	_, err := dsync.Dir(sourceFS, ".", targetFS, ".", dsync.WithTransform(func(path string, data []byte) ([]byte, error) {
+		return nil, errors.New("unable to format")
+	}))
+	is.True(err != nil)
+	is.Equal(err.Error(), `dsync: unable to transform "main.go". unable to format`)
+	is.Equal(len(targetFS), 0)
+}
+
+func TestStreamLargeFiles(t *testing.T) {
+	is := is.New(t)
+	sourceDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(sourceDir, "small.txt"), []byte("abc"), 0644)
+	is.NoErr(err)
+	err = os.WriteFile(filepath.Join(sourceDir, "large.mp4"), []byte("0123456789"), 0600)
+	is.NoErr(err)
+	targetDir := t.TempDir()
+	ops, err := dsync.Diff(vfs.OS(sourceDir), ".", vfs.OS(targetDir), ".", dsync.WithStreamSize(4))
+	is.NoErr(err)
+	is.Equal(len(ops), 2)
+	for _, op := range ops {
+		switch op.Path {
+		case "small.txt":
+			is.Equal(string(op.Data), "abc")
+		case "large.mp4":
+			is.Equal(op.Data, nil) // streamed when applied
+		}
+	}
+	result, err := dsync.Dir(vfs.OS(sourceDir), ".", vfs.OS(targetDir), ".", dsync.WithStreamSize(4))
+	is.NoErr(err)
+	is.Equal(result.Bytes, int64(13))
+	data, err := os.ReadFile(filepath.Join(targetDir, "large.mp4"))
+	is.NoErr(err)
+	is.Equal(string(data), "0123456789")
+	stat, err := os.Stat(filepath.Join(targetDir, "large.mp4"))
+	is.NoErr(err)
+	is.Equal(stat.Mode(), fs.FileMode(0600))
+	// Streamed files are hashed without reading them into memory
+	ops, err = dsync.Diff(vfs.OS(sourceDir), ".", vfs.OS(targetDir), ".", dsync.WithStreamSize(4), dsync.WithHash(sha256.New))
+	is.NoErr(err)
+	is.Equal(len(ops), 0)
+	// Targets that can't stream are written all at once
+	targetFS := vfs.Memory{}
+	_, err = dsync.Dir(vfs.OS(sourceDir), ".", targetFS, ".", dsync.WithStreamSize(4))
+	is.NoErr(err)
+	data, err = fs.ReadFile(targetFS, "large.mp4")
+	is.NoErr(err)
+	is.Equal(string(data), "0123456789")
+}
+
+func TestWithContinueOnError(t *testing.T) {
+	is := is.New(t)
+	sourceFS := conjure.New()
+	sourceFS.GenerateFile("bud/a.go", func(file *conjure.File) error {
+		return errors.New("uh oh")
+	})
+	sourceFS.GenerateFile("bud/b.go", func(file *conjure.File) error {
+		file.Data = []byte("package b")
+		return nil
+	})
+	sourceFS.GenerateFile("bud/view/c.go", func(file *conjure.File) error {
+		return errors.New("oh no")
+	})
+	sourceFS.GenerateFile("bud/view/d.go", func(file *conjure.File) error {
+		file.Data = []byte("package d")
+		return nil
+	})
+	targetFS := vfs.Memory{}
+	result, err := dsync.Dir(sourceFS, ".", targetFS, ".", dsync.WithContinueOnError())
+	is.True(err != nil)
+	is.Equal(err.Error(), `dsync: unable to sync 2 paths:
+  bud/a.go: conjure: generate "bud/a.go" > uh oh
+  bud/view/c.go: conjure: generate "bud/view/c.go" > oh no`)
+	var errs dsync.Errors
+	is.True(errors.As(err, &errs))
+	is.Equal(len(errs), 2)
+	is.Equal(result.Creates, 2)
+	data, err := fs.ReadFile(targetFS, "bud/b.go")
+	is.NoErr(err)
+	is.Equal(string(data), "package b")
+	data, err = fs.ReadFile(targetFS, "bud/view/d.go")
+	is.NoErr(err)
+	is.Equal(string(data), "package d")
+}
+
+func TestWithContinueOnErrorApply(t *testing.T) {
+	is := is.New(t)
+	sourceFS := vfs.Memory{
+		"a.txt": &vfs.File{Data: []byte("a")},
+		"b.txt": &vfs.File{Data: []byte("b")},
+	}
+	targetFS := vfs.Memory{}
+	tfs := failFS{targetFS, "a.txt"}
+	result, err := dsync.Dir(sourceFS, ".", tfs, ".", dsync.WithContinueOnError())
+	is.True(err != nil)
+	is.Equal(err.Error(), "dsync: unable to sync a.txt. disk full")
+	is.Equal(result.Creates, 1)
+	is.Equal(len(targetFS), 1)
+	is.True(targetFS["b.txt"] != nil)
+}
+
+func TestFile(t *testing.T) {
+	is := is.New(t)
+	before := time.Date(2021, 8, 4, 14, 56, 0, 0, time.UTC)
+	after := time.Date(2021, 8, 4, 14, 57, 0, 0, time.UTC)
+	vfs.Now = func() time.Time { return after }
+	sourceFS := vfs.Memory{
+		"bud/.cli/main.go":      &vfs.File{Data: []byte("package main"), ModTime: after},
+		"bud/.cli/view/view.go": &vfs.File{Data: []byte("package view"), ModTime: after},
+	}
+	targetFS := vfs.Memory{
+		"cli/main.go": &vfs.File{Data: []byte("package mainn"), ModTime: before},
+	}
+	// Create
+	result, err := dsync.File(sourceFS, "bud/.cli/view/view.go", targetFS, "cli/view/view.go")
+	is.NoErr(err)
+	is.Equal(result.Creates, 1)
+	data, err := fs.ReadFile(targetFS, "cli/view/view.go")
+	is.NoErr(err)
+	is.Equal(string(data), "package view")
+	// Update
+	result, err = dsync.File(sourceFS, "bud/.cli/main.go", targetFS, "cli/main.go")
+	is.NoErr(err)
+	is.Equal(result.Updates, 1)
+	data, err = fs.ReadFile(targetFS, "cli/main.go")
+	is.NoErr(err)
+	is.Equal(string(data), "package main")
+	// Unchanged
+	result, err = dsync.File(sourceFS, "bud/.cli/main.go", targetFS, "cli/main.go", dsync.WithHash(sha256.New))
+	is.NoErr(err)
+	is.True(!result.Changed())
+	// Skipped
+	result, err = dsync.File(sourceFS, "bud/.cli/main.go", targetFS, "cli/other.go", dsync.WithExclude("*.go"))
+	is.NoErr(err)
+	is.Equal(result.Skipped, []string{"bud/.cli/main.go"})
+	is.True(targetFS["cli/other.go"] == nil)
+	// Delete
+	delete(sourceFS, "bud/.cli/main.go")
+	result, err = dsync.File(sourceFS, "bud/.cli/main.go", targetFS, "cli/main.go")
+	is.NoErr(err)
+	is.Equal(result.Deletes, 1)
+	is.True(targetFS["cli/main.go"] == nil)
+	// Directories
+	_, err = dsync.File(sourceFS, "bud/.cli/view", targetFS, "cli/view")
+	is.True(err != nil)
+	is.Equal(err.Error(), `dsync: unable to sync file "bud/.cli/view" because it's a directory`)
+}
+
+func TestWithIgnoreFile(t *testing.T) {
+	is := is.New(t)
+	sourceFS := vfs.Memory{
+		"app/.budignore":             &vfs.File{Data: []byte("# scratch files\n*.tmp\nnotes/\n")},
+		"app/main.go":                &vfs.File{Data: []byte("package main")},
+		"app/main.tmp":               &vfs.File{Data: []byte("scratch")},
+		"app/notes/todo.md":          &vfs.File{Data: []byte("# todo")},
+		"app/view/.budignore":        &vfs.File{Data: []byte("draft.svelte\n")},
+		"app/view/index.svelte":      &vfs.File{Data: []byte("<h1>index</h1>")},
+		"app/view/draft.svelte":      &vfs.File{Data: []byte("<h1>draft</h1>")},
+		"app/view/user/draft.svelte": &vfs.File{Data: []byte("<h1>draft</h1>")},
+		"app/view/user/user.tmp":     &vfs.File{Data: []byte("scratch")},
+		"app/draft.svelte":           &vfs.File{Data: []byte("<h1>draft</h1>")},
+	}
+	targetFS := vfs.Memory{}
+	skipGo := func(name string, isDir bool) bool {
+		return filepath.Ext(name) == ".go"
+	}
+	_, err := dsync.Dir(sourceFS, "app", targetFS, ".", dsync.WithIgnoreFile(".budignore"), dsync.WithSkip(skipGo))
+	is.NoErr(err)
+	is.True(targetFS["main.go"] == nil)
+	is.True(targetFS["main.tmp"] == nil)
+	is.True(targetFS["notes/todo.md"] == nil)
+	is.True(targetFS["view/draft.svelte"] == nil)
+	is.True(targetFS["view/user/draft.svelte"] == nil)
+	is.True(targetFS["view/user/user.tmp"] == nil)
+	// Nested ignore files only apply to their directory
+	is.True(targetFS["draft.svelte"] != nil)
+	is.True(targetFS["view/index.svelte"] != nil)
+	is.True(targetFS[".budignore"] != nil)
+	is.True(targetFS["view/.budignore"] != nil)
+}
+
+func TestToArchive(t *testing.T) {
+	is := is.New(t)
+	sourceFS := vfs.Memory{
+		"bud/cli":              &vfs.File{Data: []byte("#!/bin/sh"), Mode: 0755},
+		"bud/view/index.js":    &vfs.File{Data: []byte("export default 1")},
+		"bud/view/about/ab.js": &vfs.File{Data: []byte("export default 2")},
+	}
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	result, err := dsync.Dir(sourceFS, "bud", dsync.ToArchive(zw), ".", dsync.WithStreamSize(10))
+	is.NoErr(err)
+	is.Equal(result.Creates, 3)
+	is.NoErr(zw.Close())
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	is.NoErr(err)
+	files := map[string]*zip.File{}
+	for _, file := range zr.File {
+		files[file.Name] = file
+	}
+	is.Equal(len(files), 5)
+	is.True(files["view/"] != nil)
+	is.True(files["view/about/"] != nil)
+	is.Equal(files["cli"].Mode(), fs.FileMode(0755))
+	data, err := fs.ReadFile(zr, "view/about/ab.js")
+	is.NoErr(err)
+	is.Equal(string(data), "export default 2")
+}
+
+func TestToTarArchive(t *testing.T) {
+	is := is.New(t)
+	sourceFS := vfs.Memory{
+		"bud/cli":           &vfs.File{Data: []byte("#!/bin/sh"), Mode: 0755},
+		"bud/view/index.js": &vfs.File{Data: []byte("export default 1")},
+	}
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	_, err := dsync.Dir(sourceFS, "bud", dsync.ToTarArchive(tw), ".")
+	is.NoErr(err)
+	is.NoErr(tw.Close())
+	tr := tar.NewReader(buf)
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		is.NoErr(err)
+		data, err := io.ReadAll(tr)
+		is.NoErr(err)
+		files[header.Name] = string(data)
+		if header.Name == "cli" {
+			is.Equal(header.Mode, int64(0755))
+		}
+	}
+	is.Equal(len(files), 3)
+	is.Equal(files["view/"], "")
+	is.Equal(files["view/index.js"], "export default 1")
+}
+
+func TestCaseRename(t *testing.T) {
+	is := is.New(t)
+	before := time.Date(2021, 8, 4, 14, 56, 0, 0, time.UTC)
+	after := time.Date(2021, 8, 4, 14, 57, 0, 0, time.UTC)
+	sourceFS := vfs.Memory{
+		"users.go":          &vfs.File{Data: []byte("package users"), ModTime: after},
+		"view/index.svelte": &vfs.File{Data: []byte("<h1>index</h1>"), ModTime: before},
+	}
+	targetFS := vfs.Memory{
+		"Users.go":          &vfs.File{Data: []byte("package Users"), ModTime: before},
+		"View/index.svelte": &vfs.File{Data: []byte("<h1>index</h1>"), ModTime: before},
+	}
+	ops, err := dsync.Diff(sourceFS, ".", targetFS, ".")
+	is.NoErr(err)
+	names := map[string]string{}
+	for _, op := range ops {
+		names[op.String()] = string(op.Data)
+	}
+	is.Equal(len(names), 3)
+	is.Equal(names["rename:users.go"], "Users.go")
+	is.Equal(names["update:users.go"], "package users")
+	is.Equal(names["rename:view"], "View")
+	result, err := dsync.Dir(sourceFS, ".", targetFS, ".")
+	is.NoErr(err)
+	is.Equal(result.Renames, 2)
+	is.Equal(result.Creates, 0)
+	is.Equal(result.Deletes, 0)
+	is.Equal(len(targetFS), 2)
+	data, err := fs.ReadFile(targetFS, "users.go")
+	is.NoErr(err)
+	is.Equal(string(data), "package users")
+	data, err = fs.ReadFile(targetFS, "view/index.svelte")
+	is.NoErr(err)
+	is.Equal(string(data), "<h1>index</h1>")
+}
+
+func TestCaseRenameOS(t *testing.T) {
+	is := is.New(t)
+	sourceDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(sourceDir, "users.go"), []byte("package users"), 0644)
+	is.NoErr(err)
+	targetDir := t.TempDir()
+	err = os.WriteFile(filepath.Join(targetDir, "Users.go"), []byte("package users"), 0644)
+	is.NoErr(err)
+	_, err = dsync.Dir(vfs.OS(sourceDir), ".", vfs.OS(targetDir), ".", dsync.WithHash(sha256.New))
+	is.NoErr(err)
+	des, err := os.ReadDir(targetDir)
+	is.NoErr(err)
+	is.Equal(len(des), 1)
+	is.Equal(des[0].Name(), "users.go")
+}
+
+func TestWithIndex(t *testing.T) {
+	is := is.New(t)
+	before := time.Date(2021, 8, 4, 14, 56, 0, 0, time.UTC)
+	after := time.Date(2021, 8, 4, 14, 57, 0, 0, time.UTC)
+	sourceFS := vfs.Memory{
+		"bud/view/a.txt": &vfs.File{Data: []byte("a"), ModTime: before},
+		"bud/view/b.txt": &vfs.File{Data: []byte("b"), ModTime: before},
+	}
+	targetFS := vfs.Memory{}
+	index := dsync.WithIndex("bud/.dsync")
+	result, err := dsync.Dir(sourceFS, "bud", targetFS, "bud", index)
+	is.NoErr(err)
+	is.Equal(result.Creates, 2)
+	_, err = fs.Stat(targetFS, "bud/.dsync")
+	is.NoErr(err)
+	// The target isn't read while the source is unchanged
+	targetFS["bud/view/a.txt"].Data = []byte("c")
+	ops, err := dsync.Diff(sourceFS, "bud", targetFS, "bud", index)
+	is.NoErr(err)
+	is.Equal(len(ops), 0)
+	// Changing the source updates the target
+	sourceFS["bud/view/b.txt"].ModTime = after
+	result, err = dsync.Dir(sourceFS, "bud", targetFS, "bud", index)
+	is.NoErr(err)
+	is.Equal(result.Updates, 1)
+	is.Equal(result.Deletes, 0)
+	result, err = dsync.Dir(sourceFS, "bud", targetFS, "bud", index)
+	is.NoErr(err)
+	is.Equal(result.Changed(), false)
+	// Deleting the index compares the target again
+	is.NoErr(targetFS.RemoveAll("bud/.dsync"))
+	result, err = dsync.Dir(sourceFS, "bud", targetFS, "bud", index)
+	is.NoErr(err)
+	is.True(result.Updates >= 1)
+	data, err := fs.ReadFile(targetFS, "bud/view/a.txt")
+	is.NoErr(err)
+	is.Equal(string(data), "a")
+}
+
+func TestWithMerge(t *testing.T) {
+	is := is.New(t)
+	sourceFS := vfs.Memory{
+		"a.txt": &vfs.File{Data: []byte("a")},
+		"b.txt": &vfs.File{Data: []byte("b")},
+		"c.txt": &vfs.File{Data: []byte("c")},
+	}
+	targetFS := vfs.Memory{}
+	merge := dsync.WithMerge(".dsync-base")
+	result, err := dsync.Dir(sourceFS, ".", targetFS, ".", merge)
+	is.NoErr(err)
+	is.Equal(result.Creates, 3)
+	// Change a.txt in the source, b.txt in the target and c.txt in both
+	sourceFS["a.txt"].Data = []byte("a2")
+	targetFS["b.txt"].Data = []byte("b2")
+	sourceFS["c.txt"].Data = []byte("c2")
+	targetFS["c.txt"].Data = []byte("c3")
+	result, err = dsync.Dir(sourceFS, ".", targetFS, ".", merge)
+	is.NoErr(err)
+	is.Equal(result.Updates, 1)
+	is.Equal(result.Deletes, 0)
+	is.Equal(result.Conflicts, []string{"c.txt"})
+	data, err := fs.ReadFile(targetFS, "a.txt")
+	is.NoErr(err)
+	is.Equal(string(data), "a2")
+	data, err = fs.ReadFile(targetFS, "b.txt")
+	is.NoErr(err)
+	is.Equal(string(data), "b2")
+	data, err = fs.ReadFile(targetFS, "c.txt")
+	is.NoErr(err)
+	is.Equal(string(data), "c3")
+	// The conflict is surfaced until it's resolved
+	ops, err := dsync.Diff(sourceFS, ".", targetFS, ".", merge)
+	is.NoErr(err)
+	is.Equal(len(ops), 1)
+	is.Equal(ops[0].String(), "conflict:c.txt")
+	is.Equal(string(ops[0].Data), "c2")
+	targetFS["c.txt"].Data = []byte("c2")
+	result, err = dsync.Dir(sourceFS, ".", targetFS, ".", merge)
+	is.NoErr(err)
+	is.Equal(result.Changed(), false)
+	is.Equal(len(result.Conflicts), 0)
+	// Source changes apply again once the conflict is resolved
+	sourceFS["c.txt"].Data = []byte("c4")
+	result, err = dsync.Dir(sourceFS, ".", targetFS, ".", merge)
+	is.NoErr(err)
+	is.Equal(result.Updates, 1)
+}
+
+func TestWithKeepEmptyDirs(t *testing.T) {
+	is := is.New(t)
+	sourceFS := vfs.Memory{
+		"view/index.svelte":   &vfs.File{Data: []byte("index")},
+		"view/about/.gitkeep": &vfs.File{Data: []byte("")},
+	}
+	skip := dsync.WithSkip(func(name string, isDir bool) bool {
+		return filepath.Base(name) == ".gitkeep"
+	})
+	targetFS := vfs.Memory{}
+	_, err := dsync.Dir(sourceFS, ".", targetFS, ".", skip)
+	is.NoErr(err)
+	_, err = fs.Stat(targetFS, "view/about")
+	is.True(errors.Is(err, fs.ErrNotExist))
+	result, err := dsync.Dir(sourceFS, ".", targetFS, ".", skip, dsync.WithKeepEmptyDirs())
+	is.NoErr(err)
+	is.Equal(result.Creates, 1)
+	stat, err := fs.Stat(targetFS, "view/about")
+	is.NoErr(err)
+	is.True(stat.IsDir())
+}
+
+func TestWithPruneEmptyDirs(t *testing.T) {
+	is := is.New(t)
+	sourceFS := vfs.Memory{
+		"view/index.svelte":   &vfs.File{Data: []byte("index")},
+		"view/about/.gitkeep": &vfs.File{Data: []byte("")},
+	}
+	skip := dsync.WithSkip(func(name string, isDir bool) bool {
+		return filepath.Base(name) == ".gitkeep"
+	})
+	targetFS := vfs.Memory{
+		"view/index.svelte":       &vfs.File{Data: []byte("index")},
+		"view/about/index.svelte": &vfs.File{Data: []byte("about")},
+	}
+	ops, err := dsync.Diff(sourceFS, ".", targetFS, ".", skip)
+	is.NoErr(err)
+	is.Equal(len(ops), 1)
+	is.Equal(ops[0].String(), "delete:view/about/index.svelte")
+	ops, err = dsync.Diff(sourceFS, ".", targetFS, ".", skip, dsync.WithPruneEmptyDirs())
+	is.NoErr(err)
+	is.Equal(len(ops), 1)
+	is.Equal(ops[0].String(), "delete:view/about")
+	_, err = dsync.Dir(sourceFS, ".", targetFS, ".", skip, dsync.WithPruneEmptyDirs())
+	is.NoErr(err)
+	_, err = fs.Stat(targetFS, "view/about")
+	is.True(errors.Is(err, fs.ErrNotExist))
+}
+
+type logEntries []log.Entry
+
+func (l *logEntries) Log(entry log.Entry) {
+	*l = append(*l, entry)
+}
+
+func TestWithLogger(t *testing.T) {
+	is := is.New(t)
+	before := time.Date(2021, 8, 4, 14, 56, 0, 0, time.UTC)
+	after := time.Date(2021, 8, 4, 14, 57, 0, 0, time.UTC)
+	sourceFS := vfs.Memory{
+		"a.txt": &vfs.File{Data: []byte("a"), ModTime: before},
+		"b.txt": &vfs.File{Data: []byte("bb"), ModTime: before},
+		"c.txt": &vfs.File{Data: []byte("c"), ModTime: after},
+		"d.txt": &vfs.File{Data: []byte("d"), ModTime: before},
+	}
+	targetFS := vfs.Memory{
+		"b.txt": &vfs.File{Data: []byte("b"), ModTime: before},
+		"c.txt": &vfs.File{Data: []byte("c"), ModTime: before},
+		"d.txt": &vfs.File{Data: []byte("d"), ModTime: before},
+		"e.txt": &vfs.File{Data: []byte("e"), ModTime: before},
+	}
+	entries := new(logEntries)
+	_, err := dsync.Diff(sourceFS, ".", targetFS, ".", dsync.WithLogger(log.New(entries)))
+	is.NoErr(err)
+	var lines []string
+	for _, entry := range *entries {
+		is.Equal(entry.Level, log.DebugLevel)
+		line := entry.Message
+		for _, field := range entry.Fields {
+			if field.Key == "path" || field.Key == "reason" {
+				line += " " + field.Key + "=" + field.Value
+			}
+		}
+		lines = append(lines, line)
+	}
+	is.Equal(lines, []string{
+		"dsync: create path=a.txt reason=missing",
+		"dsync: delete path=e.txt reason=not in source",
+		"dsync: update path=b.txt reason=size",
+		"dsync: update path=c.txt reason=mtime",
+		"dsync: unchanged path=d.txt reason=stamp",
+	})
+}
+
+func TestModTime(t *testing.T) {
+	is := is.New(t)
+	modTime := time.Date(2021, 8, 4, 14, 56, 0, 0, time.UTC)
+	sourceFS := vfs.Memory{
+		"bud/main.go": &vfs.File{Data: []byte("package main"), Mode: 0644, ModTime: modTime},
+		"bud/app":     &vfs.File{Data: []byte("#!/bin/sh"), Mode: 0755, ModTime: modTime},
+	}
+	targetFS := vfs.Memory{}
+	result, err := dsync.Dir(sourceFS, ".", targetFS, ".")
+	is.NoErr(err)
+	is.Equal(result.Creates, 2)
+	stat, err := fs.Stat(targetFS, "bud/app")
+	is.NoErr(err)
+	is.Equal(stat.Mode(), fs.FileMode(0755))
+	is.True(stat.ModTime().Equal(modTime))
+	// The stamps match, so nothing changes
+	ops, err := dsync.Diff(sourceFS, ".", targetFS, ".")
+	is.NoErr(err)
+	is.Equal(len(ops), 0)
+}
@@ -0,0 +1,63 @@
+package dsync
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// WithKeepEmptyDirs creates directories in the target that are empty or only
+// contain skipped files in the source. By default, these directories aren't
+// created.
+func WithKeepEmptyDirs() Option {
+	return func(o *option) {
+		o.keepEmptyDirs = true
+	}
+}
+
+// WithPruneEmptyDirs deletes directories from the target that would be left
+// empty after the sync, mirroring how empty source directories aren't
+// created. WithKeepEmptyDirs takes precedence.
+func WithPruneEmptyDirs() Option {
+	return func(o *option) {
+		o.pruneEmptyDirs = true
+	}
+}
+
+// emptyDir creates the directory when keeping empty directories
+func emptyDir(opt *option, path string) ([]Op, error) {
+	if !opt.keepEmptyDirs {
+		return nil, nil
+	}
+	rel, err := opt.rel(path)
+	if err != nil {
+		return nil, err
+	}
+	opt.debug("dsync: create", "path", rel, "reason", "empty directory")
+	return []Op{{Type: CreateType, Path: rel, Mode: fs.ModeDir | opt.dirMode}}, nil
+}
+
+// prune replaces the operations with deleting the target directory when every
+// entry in the directory would be deleted
+func prune(opt *option, tfs fs.FS, tdir string, ops []Op) ([]Op, error) {
+	if !opt.pruneEmptyDirs || opt.keepEmptyDirs {
+		return ops, nil
+	}
+	des, err := fs.ReadDir(tfs, tdir)
+	if err != nil {
+		return nil, err
+	}
+	deletes := 0
+	for _, op := range ops {
+		if op.Type != DeleteType {
+			return ops, nil
+		}
+		if filepath.Dir(op.Path) == tdir {
+			deletes++
+		}
+	}
+	if deletes < len(des) {
+		return ops, nil
+	}
+	opt.debug("dsync: delete", "path", tdir, "reason", "empty directory")
+	return []Op{{Type: DeleteType, Path: tdir}}, nil
+}
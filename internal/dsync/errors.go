@@ -0,0 +1,51 @@
+package dsync
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// WithContinueOnError keeps syncing when a path fails. The failures are
+// returned together as Errors after the rest of the paths have been synced.
+//
+// Note: WithAtomic still rolls back the sync when applying a change fails.
+func WithContinueOnError() Option {
+	return func(o *option) {
+		o.continueOnErr = true
+	}
+}
+
+// Errors are the paths that failed to sync
+type Errors []*fs.PathError
+
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return "dsync: unable to sync " + e[0].Path + ". " + e[0].Err.Error()
+	}
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = "\n  " + err.Path + ": " + err.Err.Error()
+	}
+	return "dsync: unable to sync " + strconv.Itoa(len(e)) + " paths:" + strings.Join(lines, "")
+}
+
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// fail records the error when continuing on errors. Otherwise, or if the sync
+// was canceled, the error is returned.
+func (o *option) fail(path string, err error) error {
+	if !o.continueOnErr || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	o.errors = append(o.errors, &fs.PathError{Op: "sync", Path: path, Err: err})
+	return nil
+}
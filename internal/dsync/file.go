@@ -0,0 +1,71 @@
+package dsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/livebud/bud/package/vfs"
+)
+
+// File syncs a single file from the source filesystem to the target
+// filesystem. It's cheaper than Dir when you know which file changed. If the
+// source file doesn't exist, the target file is deleted.
+func File(sfs fs.FS, spath string, tfs vfs.ReadWritable, tpath string, options ...Option) (*Result, error) {
+	ctx := context.Background()
+	start := time.Now()
+	opt := newOption(sfs, filepath.Dir(spath), filepath.Dir(tpath), options)
+	opt.loadIndex(tfs)
+	opt.loadBases(tfs)
+	// Keep the stamps of the other files in the index
+	opt.index.carry()
+	ops, err := diffFile(ctx, opt, sfs, spath, tfs, tpath)
+	if err != nil {
+		return nil, err
+	}
+	return finish(ctx, opt, tfs, ops, start)
+}
+
+func diffFile(ctx context.Context, opt *option, sfs fs.FS, spath string, tfs fs.FS, tpath string) (ops []Op, err error) {
+	if opt.skip(spath, false) {
+		return nil, nil
+	}
+	sourceInfo, err := fs.Stat(sfs, spath)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, opt.fail(spath, err)
+		}
+		// Delete the target when the source doesn't exist
+		if _, err := fs.Stat(tfs, tpath); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		if opt.noDelete {
+			return nil, nil
+		}
+		opt.index.forget(tpath)
+		opt.debug("dsync: delete", "path", tpath, "reason", "not in source")
+		return []Op{{Type: DeleteType, Path: tpath}}, nil
+	}
+	if sourceInfo.IsDir() {
+		return nil, fmt.Errorf("dsync: unable to sync file %q because it's a directory", spath)
+	}
+	de := fs.FileInfoToDirEntry(sourceInfo)
+	if _, err := fs.Stat(tfs, tpath); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		ops, err = createEntry(ctx, opt, sfs, tfs, spath, de)
+	} else {
+		ops, err = updateEntry(ctx, opt, sfs, spath, tfs, tpath, de)
+	}
+	if err != nil {
+		return nil, opt.fail(spath, err)
+	}
+	return ops, nil
+}
@@ -0,0 +1,108 @@
+package dsync
+
+import (
+	"bytes"
+	"errors"
+	"hash"
+	"io"
+	"io/fs"
+	"sync"
+)
+
+// WithHash compares the contents of files using the hash instead of their
+// modtime and size. Digests of target files are cached by their stamp, so
+// reusing the option across syncs avoids rereading unchanged files.
+func WithHash(h func() hash.Hash) Option {
+	digests := newDigestCache(h)
+	return func(o *option) {
+		o.digests = digests
+	}
+}
+
+func newDigestCache(h func() hash.Hash) *digestCache {
+	return &digestCache{
+		hash:    h,
+		digests: map[string]cachedDigest{},
+	}
+}
+
+type cachedDigest struct {
+	stamp string
+	sum   []byte
+}
+
+type digestCache struct {
+	hash    func() hash.Hash
+	mu      sync.Mutex
+	digests map[string]cachedDigest // path -> digest
+}
+
+func (c *digestCache) sum(data []byte) []byte {
+	h := c.hash()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// sumFile streams the file through the hash
+func (c *digestCache) sumFile(fsys fs.FS, path string) ([]byte, error) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	h := c.hash()
+	if _, err := io.Copy(h, file); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// changed compares the digest of the operation's contents with the target's
+// digest
+func (c *digestCache) changed(op Op, tfs fs.FS, tpath string) (bool, error) {
+	target, err := c.digest(tfs, tpath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return true, nil
+		}
+		return false, err
+	}
+	source := c.sum(op.Data)
+	if op.stream != nil {
+		source, err = c.sumFile(op.stream.fsys, op.stream.path)
+		if err != nil {
+			return false, err
+		}
+	}
+	return !bytes.Equal(source, target), nil
+}
+
+// digest of the file, reusing the cached digest if the file's stamp hasn't
+// changed. Files without a modtime are always read because their stamp can't
+// tell us if they've changed.
+func (c *digestCache) digest(fsys fs.FS, path string) ([]byte, error) {
+	stat, err := fs.Stat(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	stamp := stampOf(stat)
+	cacheable := !stat.ModTime().IsZero()
+	if cacheable {
+		c.mu.Lock()
+		cached, ok := c.digests[path]
+		c.mu.Unlock()
+		if ok && cached.stamp == stamp {
+			return cached.sum, nil
+		}
+	}
+	sum, err := c.sumFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	if cacheable {
+		c.mu.Lock()
+		c.digests[path] = cachedDigest{stamp, sum}
+		c.mu.Unlock()
+	}
+	return sum, nil
+}
@@ -0,0 +1,58 @@
+package dsync
+
+import (
+	"bytes"
+	"io/fs"
+	"path"
+	"path/filepath"
+
+	"github.com/monochromegane/go-gitignore"
+)
+
+// WithIgnoreFile skips paths that match the gitignore-style patterns in ignore
+// files with this name, like ".budignore". Ignore files are read from the
+// source directory and its subdirectories, and apply to the paths within the
+// directory they're in.
+func WithIgnoreFile(name string) Option {
+	return func(o *option) {
+		o.ignoreFiles = append(o.ignoreFiles, name)
+	}
+}
+
+// ignored checks the path against the ignore files in each directory between
+// the source directory and the path
+func (o *option) ignored(fpath string, isDir bool) bool {
+	if len(o.ignoreFiles) == 0 {
+		return false
+	}
+	for dir := filepath.Dir(fpath); ; dir = filepath.Dir(dir) {
+		for _, name := range o.ignoreFiles {
+			if matcher := o.ignoreFile(dir, name); matcher != nil && matcher.Match(fpath, isDir) {
+				return true
+			}
+		}
+		if dir == o.sdir || dir == "." || dir == "/" {
+			return false
+		}
+	}
+}
+
+// ignoreFile loads the ignore file in the directory, returning nil if there
+// isn't one. Ignore files are only read once per sync.
+func (o *option) ignoreFile(dir, name string) gitignore.IgnoreMatcher {
+	fpath := path.Join(filepath.ToSlash(dir), name)
+	if matcher, ok := o.ignores[fpath]; ok {
+		return matcher
+	}
+	if o.ignores == nil {
+		o.ignores = map[string]gitignore.IgnoreMatcher{}
+	}
+	data, err := fs.ReadFile(o.sfs, fpath)
+	if err != nil {
+		o.ignores[fpath] = nil
+		return nil
+	}
+	matcher := gitignore.NewGitIgnoreFromReader(dir, bytes.NewReader(data))
+	o.ignores[fpath] = matcher
+	return matcher
+}
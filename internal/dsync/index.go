@@ -0,0 +1,137 @@
+package dsync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/livebud/bud/package/vfs"
+)
+
+// WithIndex stores the stamps of the synced source files in an index file
+// within the target filesystem, e.g. bud/.dsync. Later syncs skip source files
+// that haven't changed since they were last synced without stat-ing or
+// reading the target. Changes made to the target outside of dsync aren't
+// noticed while the index is in place.
+func WithIndex(path string) Option {
+	return func(o *option) {
+		o.indexPath = path
+	}
+}
+
+// index of the source stamps, keyed by target path
+type index struct {
+	path   string
+	prev   map[string]string // stamps from the last sync
+	stamps map[string]string // stamps from this sync
+}
+
+// readIndex reads the index from the target. A missing or corrupt index is
+// treated as empty.
+func readIndex(tfs fs.FS, path string) *index {
+	idx := &index{path, map[string]string{}, map[string]string{}}
+	data, err := fs.ReadFile(tfs, path)
+	if err != nil {
+		return idx
+	}
+	if err := json.Unmarshal(data, &idx.prev); err != nil {
+		idx.prev = map[string]string{}
+	}
+	return idx
+}
+
+// indexStamp returns the stamp of the source file or "" if the file can't be
+// tracked because it doesn't have a modtime
+func indexStamp(info fs.FileInfo) string {
+	if info.ModTime().IsZero() {
+		return ""
+	}
+	return stampOf(info)
+}
+
+// unchanged returns true if the source stamp matches the last sync
+func (i *index) unchanged(tpath, stamp string) bool {
+	return stamp != "" && i.prev[tpath] == stamp
+}
+
+// record the source stamp of a synced file. It's a no-op without an index.
+func (i *index) record(tpath, stamp string) {
+	if i == nil || stamp == "" {
+		return
+	}
+	i.stamps[tpath] = stamp
+}
+
+// forget the stamp of a deleted file
+func (i *index) forget(tpath string) {
+	if i == nil {
+		return
+	}
+	delete(i.stamps, tpath)
+}
+
+// carry over the stamps from the last sync. This is used when syncing a single
+// file, so the stamps of the other files aren't lost.
+func (i *index) carry() {
+	if i == nil {
+		return
+	}
+	for tpath, stamp := range i.prev {
+		i.stamps[tpath] = stamp
+	}
+}
+
+func (i *index) save(tfs vfs.ReadWritable) error {
+	data, err := json.Marshal(i.stamps)
+	if err != nil {
+		return err
+	}
+	if err := tfs.MkdirAll(filepath.Dir(i.path), 0755); err != nil {
+		return err
+	}
+	if err := tfs.WriteFile(i.path, data, 0644); err != nil {
+		return fmt.Errorf("dsync: unable to write index %q. %w", i.path, err)
+	}
+	return nil
+}
+
+// remove the index after a failed sync, so the next sync compares every file
+func (i *index) remove(tfs vfs.ReadWritable) error {
+	if err := tfs.RemoveAll(i.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("dsync: unable to remove index %q. %w", i.path, err)
+	}
+	return nil
+}
+
+// loadIndex loads the index from the target when WithIndex is set
+func (o *option) loadIndex(tfs fs.FS) {
+	if o.indexPath == "" {
+		return
+	}
+	o.index = readIndex(tfs, o.indexPath)
+}
+
+// saveIndex writes the index after the sync. Syncs with errors remove the
+// index instead, so the next sync compares every file again.
+func (o *option) saveIndex(tfs vfs.ReadWritable) error {
+	if o.index == nil {
+		return nil
+	} else if len(o.errors) > 0 {
+		return o.index.remove(tfs)
+	}
+	return o.index.save(tfs)
+}
+
+func (o *option) removeIndex(tfs vfs.ReadWritable) {
+	if o.index == nil {
+		return
+	}
+	o.index.remove(tfs)
+}
+
+// isState returns true if dsync keeps its own state in the target path
+func (o *option) isState(tpath string) bool {
+	return (o.index != nil && tpath == o.index.path) || (o.bases != nil && tpath == o.bases.path)
+}
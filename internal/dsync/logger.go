@@ -0,0 +1,41 @@
+package dsync
+
+import (
+	"strings"
+
+	"github.com/livebud/bud/package/log"
+)
+
+// WithLogger logs each decision made while diffing at the debug level, along
+// with the reason why a path was considered changed. This is useful for
+// finding out why a file keeps getting rewritten.
+func WithLogger(log log.Logger) Option {
+	return func(o *option) {
+		o.log = log
+	}
+}
+
+// debug logs the message when there's a logger. Without one, the arguments
+// aren't formatted, since the diff logs every path it visits.
+func (o *option) debug(message string, args ...interface{}) {
+	if o.log == nil {
+		return
+	}
+	o.log.Debug(message, args...)
+}
+
+// stampReason explains why the source and target stamps differ
+func stampReason(source, target string) string {
+	sourceParts := strings.SplitN(source, ":", 3)
+	targetParts := strings.SplitN(target, ":", 3)
+	switch {
+	case len(targetParts) < 3:
+		return "missing"
+	case sourceParts[0] != targetParts[0]:
+		return "size"
+	case sourceParts[1] != targetParts[1]:
+		return "mode"
+	default:
+		return "mtime"
+	}
+}
@@ -0,0 +1,161 @@
+package dsync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/livebud/bud/package/vfs"
+)
+
+// WithMerge allows the target files to be edited by hand. The digest of the
+// content from the last sync is recorded in a file within the target
+// filesystem, e.g. bud/.dsync-base. When the source changes, files that were
+// only changed in the source are updated, files that were only changed in the
+// target are kept and files that were changed in both become conflicts.
+// Conflicts aren't applied, they're listed in the result so the caller can
+// decide what to do with them.
+//
+// Existing target files without a recorded base that differ from the source
+// are treated as conflicts, since it's unknown if they were edited.
+func WithMerge(path string) Option {
+	return func(o *option) {
+		o.basePath = path
+	}
+}
+
+// bases are the digests of the last synced contents, keyed by target path
+type bases struct {
+	path string
+	prev map[string]string // digests from the last sync
+	sums map[string]string // digests from this sync
+}
+
+// readBases reads the bases from the target. A missing or corrupt file is
+// treated as empty.
+func readBases(tfs fs.FS, path string) *bases {
+	b := &bases{path, map[string]string{}, map[string]string{}}
+	data, err := fs.ReadFile(tfs, path)
+	if err != nil {
+		return b
+	}
+	if err := json.Unmarshal(data, &b.prev); err != nil {
+		b.prev = map[string]string{}
+	}
+	// Bases that don't change are carried over to the next sync
+	for tpath, sum := range b.prev {
+		b.sums[tpath] = sum
+	}
+	return b
+}
+
+// merge compares the source, target and base of an updated file. The
+// operation is returned with ok set to false when the target should be kept.
+func (b *bases) merge(op Op, tfs fs.FS, tpath string) (_ Op, ok bool, err error) {
+	source, err := sumOp(op)
+	if err != nil {
+		return op, false, err
+	}
+	target, err := sumFile(tfs, tpath)
+	if err != nil {
+		return op, false, err
+	}
+	base, hasBase := b.prev[tpath]
+	switch {
+	case source == target:
+		b.sums[op.Path] = source
+		return op, false, nil
+	case hasBase && source == base:
+		// Only the target changed, so keep the edits
+		return op, false, nil
+	case hasBase && target == base:
+		// Only the source changed
+		return op, true, nil
+	default:
+		op.Type = ConflictType
+		return op, true, nil
+	}
+}
+
+// applied records the bases of the applied operations
+func (b *bases) applied(ops []Op) error {
+	for _, op := range ops {
+		switch op.Type {
+		case CreateType, UpdateType:
+			if op.Mode.IsDir() {
+				continue
+			}
+			sum, err := sumOp(op)
+			if err != nil {
+				return err
+			}
+			b.sums[op.Path] = sum
+		case DeleteType:
+			for tpath := range b.sums {
+				if tpath == op.Path || strings.HasPrefix(tpath, op.Path+"/") {
+					delete(b.sums, tpath)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (b *bases) save(tfs vfs.ReadWritable) error {
+	data, err := json.Marshal(b.sums)
+	if err != nil {
+		return err
+	}
+	if err := tfs.MkdirAll(filepath.Dir(b.path), 0755); err != nil {
+		return err
+	}
+	if err := tfs.WriteFile(b.path, data, 0644); err != nil {
+		return fmt.Errorf("dsync: unable to write merge bases %q. %w", b.path, err)
+	}
+	return nil
+}
+
+func sumOp(op Op) (string, error) {
+	if op.stream != nil {
+		return sumFile(op.stream.fsys, op.stream.path)
+	}
+	sum := sha256.Sum256(op.Data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func sumFile(fsys fs.FS, path string) (string, error) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// loadBases loads the merge bases from the target when WithMerge is set
+func (o *option) loadBases(tfs fs.FS) {
+	if o.basePath == "" {
+		return
+	}
+	o.bases = readBases(tfs, o.basePath)
+}
+
+// saveBases records the bases of the applied operations
+func (o *option) saveBases(tfs vfs.ReadWritable, applied []Op) error {
+	if o.bases == nil {
+		return nil
+	}
+	if err := o.bases.applied(applied); err != nil {
+		return err
+	}
+	return o.bases.save(tfs)
+}
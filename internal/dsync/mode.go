@@ -0,0 +1,49 @@
+package dsync
+
+import (
+	"io/fs"
+
+	"github.com/livebud/bud/package/vfs"
+)
+
+// WithDirMode sets the permissions of the directories created in the target.
+// Defaults to 0755.
+func WithDirMode(mode fs.FileMode) Option {
+	return func(o *option) {
+		o.dirMode = mode.Perm()
+	}
+}
+
+// WithFileMode sets the permissions of the files written to the target instead
+// of preserving the permissions of the source files
+func WithFileMode(mode fs.FileMode) Option {
+	return func(o *option) {
+		o.fileMode = mode.Perm()
+	}
+}
+
+// fileMode returns the permissions to write the source file with. Virtual
+// files without permissions are written with 0644.
+func fileMode(opt *option, info fs.FileInfo) fs.FileMode {
+	if opt.fileMode != 0 {
+		return opt.fileMode
+	}
+	if perm := info.Mode().Perm(); perm != 0 {
+		return perm
+	}
+	return 0644
+}
+
+// chmoder is implemented by filesystems that can change permissions
+type chmoder interface {
+	Chmod(name string, mode fs.FileMode) error
+}
+
+// chmod changes the permissions if the filesystem supports it
+func chmod(tfs vfs.ReadWritable, path string, mode fs.FileMode) error {
+	target, ok := tfs.(chmoder)
+	if !ok {
+		return nil
+	}
+	return target.Chmod(path, mode)
+}
@@ -0,0 +1,25 @@
+package dsync
+
+import (
+	"time"
+
+	"github.com/livebud/bud/package/vfs"
+)
+
+// chtimeser is implemented by filesystems that can change modification times
+type chtimeser interface {
+	Chtimes(name string, atime, mtime time.Time) error
+}
+
+// chtimes sets the target's modtime to the source's modtime, so the stamps
+// match on the next sync. Sources without a modtime are left alone.
+func chtimes(tfs vfs.ReadWritable, path string, modTime time.Time) error {
+	if modTime.IsZero() {
+		return nil
+	}
+	target, ok := tfs.(chtimeser)
+	if !ok {
+		return nil
+	}
+	return target.Chtimes(path, modTime, modTime)
+}
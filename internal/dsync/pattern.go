@@ -0,0 +1,78 @@
+package dsync
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/monochromegane/go-gitignore"
+)
+
+// WithExclude skips paths that match the gitignore-style patterns. Patterns
+// are matched against paths relative to the source directory.
+func WithExclude(patterns ...string) Option {
+	matcher := compilePatterns(patterns)
+	return func(o *option) {
+		o.excludes = append(o.excludes, matcher)
+	}
+}
+
+// WithInclude only syncs files that match the gitignore-style patterns.
+// Directories are always traversed, so "*.go" includes Go files at any depth.
+func WithInclude(patterns ...string) Option {
+	matcher := compilePatterns(patterns)
+	return func(o *option) {
+		o.includes = append(o.includes, matcher)
+	}
+}
+
+// compilePatterns compiles the patterns into a matcher. Patterns like
+// "node_modules/**" also match the directory itself, so the directory isn't
+// deleted from the target just because everything in it was skipped.
+func compilePatterns(patterns []string) gitignore.IgnoreMatcher {
+	lines := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		lines = append(lines, pattern)
+		if dir := strings.TrimSuffix(pattern, "/**"); dir != pattern && dir != "" {
+			lines = append(lines, dir+"/")
+		}
+	}
+	return gitignore.NewGitIgnoreFromReader("", strings.NewReader(strings.Join(lines, "\n")))
+}
+
+// skip returns true if the path shouldn't be synced, recording skipped paths
+func (o *option) skip(path string, isDir bool) bool {
+	if !o.skips(path, isDir) {
+		return false
+	}
+	o.skipped = append(o.skipped, path)
+	o.debug("dsync: skip", "path", path)
+	return true
+}
+
+func (o *option) skips(path string, isDir bool) bool {
+	if o.Skip(path, isDir) || o.ignored(path, isDir) {
+		return true
+	}
+	if len(o.excludes) == 0 && len(o.includes) == 0 {
+		return false
+	}
+	rel, err := filepath.Rel(o.sdir, path)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, exclude := range o.excludes {
+		if exclude.Match(rel, isDir) {
+			return true
+		}
+	}
+	if isDir || len(o.includes) == 0 {
+		return false
+	}
+	for _, include := range o.includes {
+		if include.Match(rel, isDir) {
+			return false
+		}
+	}
+	return true
+}
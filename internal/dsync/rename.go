@@ -0,0 +1,94 @@
+package dsync
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/livebud/bud/internal/dsync/set"
+	"github.com/livebud/bud/package/vfs"
+)
+
+// caseRename is a source entry whose name only differs by case from a target
+// entry
+type caseRename struct {
+	source fs.DirEntry
+	target fs.DirEntry
+}
+
+// caseRenames pairs up the creates and deletes whose names only differ by
+// case, removing them from the sets. On case-insensitive filesystems, creating
+// Users.go would overwrite users.go, then deleting users.go would remove it.
+func caseRenames(opt *option, sdir string, creates, deletes *set.Set) (renames []*caseRename) {
+	if creates.IsEmpty() || deletes.IsEmpty() {
+		return nil
+	}
+	folded := map[string][]fs.DirEntry{}
+	for _, de := range deletes.List() {
+		name := strings.ToLower(de.Name())
+		folded[name] = append(folded[name], de)
+	}
+	for _, de := range creates.List() {
+		name := strings.ToLower(de.Name())
+		// Only rename when there's exactly one match
+		if len(folded[name]) != 1 || opt.skips(filepath.Join(sdir, de.Name()), de.IsDir()) {
+			continue
+		}
+		renames = append(renames, &caseRename{de, folded[name][0]})
+		delete(folded, name)
+	}
+	for _, rename := range renames {
+		creates.Remove(rename.source)
+		deletes.Remove(rename.target)
+	}
+	return renames
+}
+
+// renameOps renames the target entries to match the source, then updates them
+func renameOps(ctx context.Context, opt *option, sfs fs.FS, sdir string, tfs fs.FS, tdir string, renames []*caseRename) (ops []Op, err error) {
+	for _, rename := range renames {
+		path := filepath.Join(sdir, rename.source.Name())
+		rel, err := opt.rel(path)
+		if err != nil {
+			return nil, err
+		}
+		old, err := opt.rel(filepath.Join(sdir, rename.target.Name()))
+		if err != nil {
+			return nil, err
+		}
+		opt.debug("dsync: rename", "path", rel, "reason", "case", "from", old)
+		ops = append(ops, Op{Type: RenameType, Path: rel, Data: []byte(old)})
+		// Compare the source with the target before it was renamed
+		tpath := filepath.Join(tdir, rename.target.Name())
+		entryOps, err := updateEntry(ctx, opt, sfs, path, tfs, tpath, rename.source)
+		if err != nil {
+			if err := opt.fail(path, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		ops = append(ops, entryOps...)
+	}
+	return ops, nil
+}
+
+// renamer is implemented by filesystems that can rename files
+type renamer interface {
+	Rename(oldname, newname string) error
+}
+
+// rename through a temporary name, since renaming a file to a name that only
+// differs by case doesn't do anything on some case-insensitive filesystems
+func rename(tfs vfs.ReadWritable, oldname, newname string) error {
+	target, ok := tfs.(renamer)
+	if !ok {
+		return fmt.Errorf("dsync: unable to rename %q to %q. target filesystem doesn't support renames", oldname, newname)
+	}
+	tmpname := newname + ".dsync-rename"
+	if err := target.Rename(oldname, tmpname); err != nil {
+		return err
+	}
+	return target.Rename(tmpname, newname)
+}
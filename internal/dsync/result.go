@@ -0,0 +1,44 @@
+package dsync
+
+import "time"
+
+// Result summarizes the changes that were applied to the target
+type Result struct {
+	Creates   int
+	Updates   int
+	Deletes   int
+	Symlinks  int
+	Renames   int
+	Bytes     int64         // Bytes written to the target
+	Duration  time.Duration // How long the sync took
+	Skipped   []string      // Source paths that were skipped
+	Conflicts []string      // Target paths that were changed in both the source and target
+}
+
+// Changed is true if the sync changed the target
+func (r *Result) Changed() bool {
+	return r.Creates+r.Updates+r.Deletes+r.Symlinks+r.Renames > 0
+}
+
+func summarize(ops []Op) *Result {
+	result := new(Result)
+	for _, op := range ops {
+		switch op.Type {
+		case CreateType:
+			result.Creates++
+			result.Bytes += op.size()
+		case UpdateType:
+			result.Updates++
+			result.Bytes += op.size()
+		case DeleteType:
+			result.Deletes++
+		case SymlinkType:
+			result.Symlinks++
+		case RenameType:
+			result.Renames++
+		case ConflictType:
+			result.Conflicts = append(result.Conflicts, op.Path)
+		}
+	}
+	return result
+}
@@ -0,0 +1,103 @@
+package dsync
+
+import (
+	"io"
+	"io/fs"
+
+	"github.com/livebud/bud/package/vfs"
+)
+
+// defaultStreamSize is the size above which files are streamed to the target
+// instead of being read into memory
+const defaultStreamSize = 4 << 20 // 4MiB
+
+// WithStreamSize sets the size in bytes above which files are copied from the
+// source to the target as they're applied, rather than read into memory
+// during the diff. Files are never streamed when transforms are used.
+func WithStreamSize(size int64) Option {
+	return func(o *option) {
+		o.streamSize = size
+	}
+}
+
+// stream copies a file from the source
+type stream struct {
+	fsys fs.FS
+	path string
+	size int64
+}
+
+// size of the data written by the operation
+func (o Op) size() int64 {
+	if o.stream != nil {
+		return o.stream.size
+	}
+	return int64(len(o.Data))
+}
+
+// fileOp creates an operation that writes the source file to the target
+func (o *option) fileOp(typ OpType, sfs fs.FS, path string, de fs.DirEntry) (op Op, err error) {
+	// Stat the file, since directory entries of virtual filesystems may not
+	// have the generated file's mode and modtime
+	info, err := fs.Stat(sfs, path)
+	if err != nil {
+		return op, err
+	}
+	rel, err := o.rel(path)
+	if err != nil {
+		return op, err
+	}
+	op = Op{Type: typ, Path: rel, Mode: fileMode(o, info), modTime: info.ModTime()}
+	o.index.record(rel, indexStamp(info))
+	if len(o.transforms) == 0 && info.Size() > o.streamSize {
+		op.stream = &stream{sfs, path, info.Size()}
+		return op, nil
+	}
+	op.Data, err = o.readFile(sfs, path)
+	if err != nil {
+		return op, err
+	}
+	return op, nil
+}
+
+// fileInfo follows symlinks to get the info of the entry
+func fileInfo(fsys fs.FS, path string, de fs.DirEntry) (fs.FileInfo, error) {
+	if de.Type()&fs.ModeSymlink != 0 {
+		return fs.Stat(fsys, path)
+	}
+	return de.Info()
+}
+
+// creator is implemented by filesystems that can stream writes to a file
+type creator interface {
+	Create(name string, perm fs.FileMode) (io.WriteCloser, error)
+}
+
+// writeFile writes the operation's contents to the target
+func writeFile(tfs vfs.ReadWritable, op Op) error {
+	if op.stream == nil {
+		return tfs.WriteFile(op.Path, op.Data, op.Mode)
+	}
+	target, ok := tfs.(creator)
+	if !ok {
+		data, err := fs.ReadFile(op.stream.fsys, op.stream.path)
+		if err != nil {
+			return err
+		}
+		return tfs.WriteFile(op.Path, data, op.Mode)
+	}
+	source, err := op.stream.fsys.Open(op.stream.path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	file, err := target.Create(op.Path, op.Mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, source); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
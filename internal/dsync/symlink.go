@@ -0,0 +1,90 @@
+package dsync
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/livebud/bud/package/vfs"
+)
+
+// WithFollowSymlinks copies the contents of symlinks into the target instead
+// of replicating the symlinks.
+//
+// Note: symlinks are also followed when the source filesystem can't read
+// symlinks or the target filesystem can't create them.
+func WithFollowSymlinks() Option {
+	return func(o *option) {
+		o.followSymlinks = true
+	}
+}
+
+// readlinker is implemented by filesystems that can read symlinks
+type readlinker interface {
+	Readlink(name string) (string, error)
+}
+
+// symlinker is implemented by filesystems that can create symlinks
+type symlinker interface {
+	Symlink(oldname, newname string) error
+}
+
+// symlink returns the symlink's destination if the entry is a symlink that
+// should be replicated in the target
+func symlink(opt *option, sfs, tfs fs.FS, path string, de fs.DirEntry) (link string, ok bool, err error) {
+	if de.Type()&fs.ModeSymlink == 0 || opt.followSymlinks {
+		return "", false, nil
+	}
+	source, ok := sfs.(readlinker)
+	if !ok {
+		return "", false, nil
+	}
+	if _, ok := tfs.(symlinker); !ok {
+		return "", false, nil
+	}
+	link, err = source.Readlink(path)
+	if err != nil {
+		return "", false, err
+	}
+	return link, true, nil
+}
+
+// readlink returns the symlink's destination or "" if the path isn't a symlink
+func readlink(fsys fs.FS, path string) string {
+	target, ok := fsys.(readlinker)
+	if !ok {
+		return ""
+	}
+	link, err := target.Readlink(path)
+	if err != nil {
+		return ""
+	}
+	return link
+}
+
+// isDir follows symlinks to check if the entry is a directory
+func isDir(fsys fs.FS, path string, de fs.DirEntry) (bool, error) {
+	if de.Type()&fs.ModeSymlink == 0 {
+		return de.IsDir(), nil
+	}
+	stat, err := fs.Stat(fsys, path)
+	if err != nil {
+		return false, err
+	}
+	return stat.IsDir(), nil
+}
+
+// writeSymlink replaces the path with a symlink
+func writeSymlink(tfs vfs.ReadWritable, link, path string, dirMode fs.FileMode) error {
+	target, ok := tfs.(symlinker)
+	if !ok {
+		return fmt.Errorf("dsync: unable to create symlink %q. target filesystem doesn't support symlinks", path)
+	}
+	if err := tfs.MkdirAll(filepath.Dir(path), dirMode); err != nil {
+		return err
+	}
+	if err := tfs.RemoveAll(path); err != nil {
+		return err
+	}
+	return target.Symlink(link, path)
+}
@@ -0,0 +1,38 @@
+package dsync
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+)
+
+type transformFunc = func(path string, data []byte) ([]byte, error)
+
+// WithTransform rewrites the contents of files before they're written to the
+// target. Transforms receive the source path and run in the order they were
+// provided. Transformed files never match the source's modtime and size, so
+// transforming implies WithHash(sha256.New) unless another hash is provided.
+func WithTransform(transforms ...transformFunc) Option {
+	digests := newDigestCache(sha256.New)
+	return func(o *option) {
+		o.transforms = append(o.transforms, transforms...)
+		if o.digests == nil {
+			o.digests = digests
+		}
+	}
+}
+
+// readFile reads the source file and transforms its contents
+func (o *option) readFile(sfs fs.FS, path string) (data []byte, err error) {
+	data, err = fs.ReadFile(sfs, path)
+	if err != nil {
+		return nil, err
+	}
+	for _, transform := range o.transforms {
+		data, err = transform(path, data)
+		if err != nil {
+			return nil, fmt.Errorf("dsync: unable to transform %q. %w", path, err)
+		}
+	}
+	return data, nil
+}
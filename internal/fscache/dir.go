@@ -20,6 +20,14 @@ func (d *Dir) open() fs.File {
 	return &dir{d, 0}
 }
 
+// size of the directory is the size of its entry names
+func (d *Dir) size() (size int64) {
+	for _, de := range d.Entries {
+		size += int64(len(de.Name()))
+	}
+	return size
+}
+
 type dir struct {
 	*Dir
 	offset int
@@ -6,4 +6,5 @@ import (
 
 type Entry interface {
 	open() fs.File
+	size() int64 // Bytes used by the entry
 }
@@ -21,6 +21,10 @@ func (f *File) open() fs.File {
 	return &file{f, 0}
 }
 
+func (f *File) size() int64 {
+	return int64(len(f.Data))
+}
+
 type file struct {
 	*File
 	offset int64
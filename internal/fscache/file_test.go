@@ -1,11 +1,16 @@
 package fscache_test
 
 import (
+	"fmt"
 	"io/fs"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/livebud/bud/internal/fscache"
 	"github.com/matryer/is"
+	"golang.org/x/sync/errgroup"
 )
 
 func TestFakeDir(t *testing.T) {
@@ -37,3 +42,114 @@ func TestFile(t *testing.T) {
 	is.NoErr(err)
 	is.Equal(string(code), `<h1>hello</h1>`)
 }
+
+func TestSnapshot(t *testing.T) {
+	is := is.New(t)
+	cache := fscache.New()
+	fsys := cache.Wrap("map", fstest.MapFS{
+		"view/index.svelte": &fstest.MapFile{Data: []byte(`<h1>index</h1>`), Mode: 0644},
+		"view/about.svelte": &fstest.MapFile{Data: []byte(`<h1>about</h1>`), Mode: 0644},
+	})
+	des, err := fs.ReadDir(fsys, "view")
+	is.NoErr(err)
+	is.Equal(len(des), 2)
+	code, err := fs.ReadFile(fsys, "view/index.svelte")
+	is.NoErr(err)
+	is.Equal(string(code), `<h1>index</h1>`)
+	snapshot, err := cache.Snapshot()
+	is.NoErr(err)
+	// Change the cache after the snapshot
+	cache.Set("view/index.svelte", &fscache.File{Name: "index.svelte", Data: []byte(`<h1>broken</h1>`)})
+	cache.Set("view/show.svelte", &fscache.File{Name: "show.svelte", Data: []byte(`<h1>show</h1>`)})
+	is.NoErr(cache.Restore(snapshot))
+	is.True(!cache.Has("view/show.svelte"))
+	code, err = fs.ReadFile(cache, "view/index.svelte")
+	is.NoErr(err)
+	is.Equal(string(code), `<h1>index</h1>`)
+	stat, err := fs.Stat(cache, "view/index.svelte")
+	is.NoErr(err)
+	is.Equal(stat.Mode(), fs.FileMode(0644))
+	des, err = fs.ReadDir(cache, "view")
+	is.NoErr(err)
+	is.Equal(len(des), 2)
+	is.Equal(des[0].Name(), "about.svelte")
+	is.Equal(des[1].Name(), "index.svelte")
+	is.True(cache.Restore([]byte("{")) != nil)
+}
+
+type slowFS struct {
+	fstest.MapFS
+	opens   int32
+	release chan struct{}
+}
+
+func (s *slowFS) Open(name string) (fs.File, error) {
+	atomic.AddInt32(&s.opens, 1)
+	<-s.release
+	return s.MapFS.Open(name)
+}
+
+func TestConcurrentOpen(t *testing.T) {
+	is := is.New(t)
+	cache := fscache.New()
+	slow := &slowFS{
+		MapFS: fstest.MapFS{
+			"bud/view/_index.svelte.js": &fstest.MapFile{Data: []byte(`export default {}`)},
+		},
+		release: make(chan struct{}),
+	}
+	fsys := cache.Wrap("slow", slow)
+	eg := new(errgroup.Group)
+	for i := 0; i < 10; i++ {
+		eg.Go(func() error {
+			code, err := fs.ReadFile(fsys, "bud/view/_index.svelte.js")
+			if err != nil {
+				return err
+			}
+			if string(code) != `export default {}` {
+				return fmt.Errorf("unexpected code %q", code)
+			}
+			return nil
+		})
+	}
+	// Give the readers time to miss the cache before releasing the open
+	time.Sleep(10 * time.Millisecond)
+	close(slow.release)
+	is.NoErr(eg.Wait())
+	is.Equal(atomic.LoadInt32(&slow.opens), int32(1))
+}
+
+func TestLimit(t *testing.T) {
+	is := is.New(t)
+	cache := fscache.New(fscache.WithLimit(10))
+	fsys := cache.Wrap("map", fstest.MapFS{
+		"a.txt":     &fstest.MapFile{Data: []byte("aaaa")},
+		"b.txt":     &fstest.MapFile{Data: []byte("bbbb")},
+		"c.txt":     &fstest.MapFile{Data: []byte("cccc")},
+		"large.txt": &fstest.MapFile{Data: []byte("a large file")},
+	})
+	_, err := fs.ReadFile(fsys, "a.txt")
+	is.NoErr(err)
+	_, err = fs.ReadFile(fsys, "b.txt")
+	is.NoErr(err)
+	// Use a.txt, so b.txt is the least recently used
+	_, err = fs.ReadFile(fsys, "a.txt")
+	is.NoErr(err)
+	_, err = fs.ReadFile(fsys, "c.txt")
+	is.NoErr(err)
+	is.True(cache.Has("a.txt"))
+	is.True(!cache.Has("b.txt"))
+	is.True(cache.Has("c.txt"))
+	stats := cache.Stats()
+	is.Equal(stats.Hits, int64(1))
+	is.Equal(stats.Misses, int64(3))
+	is.Equal(stats.Evictions, int64(1))
+	is.Equal(stats.Entries, 2)
+	is.Equal(stats.Size, int64(8))
+	// Files larger than the limit are read, but not cached
+	data, err := fs.ReadFile(fsys, "large.txt")
+	is.NoErr(err)
+	is.Equal(string(data), "a large file")
+	is.True(!cache.Has("large.txt"))
+	is.Equal(cache.Stats().Entries, 2)
+}
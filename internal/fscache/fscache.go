@@ -1,51 +1,161 @@
 package fscache
 
 import (
-	"fmt"
+	"container/list"
 	"io/fs"
 	"path"
 	"sync"
+
+	"golang.org/x/sync/singleflight"
 )
 
-func New() *Cache {
-	return &Cache{}
+type Option func(*option)
+
+type option struct {
+	limit int64
+}
+
+// WithLimit bounds the cache to limit bytes. When the limit is exceeded, the
+// least recently used entries are evicted. Entries larger than the limit
+// aren't cached. Defaults to 0, which doesn't bound the cache.
+func WithLimit(limit int64) Option {
+	return func(o *option) {
+		o.limit = limit
+	}
 }
 
+func New(options ...Option) *Cache {
+	opt := &option{}
+	for _, option := range options {
+		option(opt)
+	}
+	return &Cache{
+		limit:   opt.limit,
+		entries: map[string]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+// Cache of opened files. The cache is safe for concurrent use.
 type Cache struct {
-	sm sync.Map
+	mu      sync.Mutex
+	limit   int64
+	size    int64
+	entries map[string]*list.Element
+	lru     *list.List // most recently used at the front
+	stats   Stats
+	group   singleflight.Group
+}
+
+// Stats of the cache. Hits and misses are counted by wrapped filesystems.
+type Stats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+	Entries   int
+	Size      int64 // Bytes used by the cached entries
+}
+
+// cached entry in the LRU list
+type cached struct {
+	path  string
+	entry Entry
+	size  int64
 }
 
 func (c *Cache) Has(path string) (ok bool) {
-	_, ok = c.sm.Load(path)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok = c.entries[path]
 	return ok
 }
 
 func (c *Cache) Set(path string, entry Entry) {
-	c.sm.Store(path, entry)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(path)
+	size := entry.size()
+	if c.limit > 0 && size > c.limit {
+		return
+	}
+	c.entries[path] = c.lru.PushFront(&cached{path, entry, size})
+	c.size += size
+	// Evict the least recently used entries until the cache fits
+	for c.limit > 0 && c.size > c.limit {
+		c.remove(c.lru.Back().Value.(*cached).path)
+		c.stats.Evictions++
+	}
 }
 
 func (c *Cache) Open(path string) (fs.File, error) {
-	value, ok := c.sm.Load(path)
+	entry, ok := c.get(path)
 	if !ok {
 		return nil, fs.ErrNotExist
 	}
-	entry, ok := value.(Entry)
+	return entry.open(), nil
+}
+
+// get the entry, marking it as recently used
+func (c *Cache) get(path string) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[path]
 	if !ok {
-		return nil, fmt.Errorf("virtual: invalid map entry")
+		return nil, false
 	}
-	return entry.open(), nil
+	c.lru.MoveToFront(element)
+	return element.Value.(*cached).entry, true
+}
+
+// remove the entry. The caller must hold the lock.
+func (c *Cache) remove(path string) {
+	element, ok := c.entries[path]
+	if !ok {
+		return
+	}
+	c.lru.Remove(element)
+	delete(c.entries, path)
+	c.size -= element.Value.(*cached).size
 }
 
 func (c *Cache) Keys() (keys []string) {
-	c.sm.Range(func(key, value interface{}) bool {
-		keys = append(keys, key.(string))
-		return true
-	})
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		keys = append(keys, key)
+	}
 	return keys
 }
 
 func (c *Cache) Clear() {
-	c.sm = sync.Map{}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]*list.Element{}
+	c.lru.Init()
+	c.size = 0
+}
+
+// Stats returns the cache's metrics
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	stats.Size = c.size
+	return stats
+}
+
+// hit and miss record lookups from wrapped filesystems
+func (c *Cache) hit() {
+	c.mu.Lock()
+	c.stats.Hits++
+	c.mu.Unlock()
+}
+
+func (c *Cache) miss() {
+	c.mu.Lock()
+	c.stats.Misses++
+	c.mu.Unlock()
 }
 
 func (c *Cache) Wrap(name string, fsys fs.FS) fs.FS {
@@ -56,16 +166,22 @@ func (c *Cache) Wrap(name string, fsys fs.FS) fs.FS {
 
 // Update event
 func (c *Cache) Update(name string) {
-	c.sm.Delete(name)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(name)
 }
 
 // Delete event
 func (c *Cache) Delete(name string) {
-	c.sm.Delete(name)
-	c.sm.Delete(path.Dir(name))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(name)
+	c.remove(path.Dir(name))
 }
 
 // Create event
 func (c *Cache) Create(name string) {
-	c.sm.Delete(path.Dir(name))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(path.Dir(name))
 }
@@ -0,0 +1,115 @@
+package fscache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"sort"
+	"time"
+)
+
+// snapshot of a cached file or directory. Sys isn't included, since it can't
+// be serialized.
+type snapshot struct {
+	Path    string              `json:"path"`
+	Name    string              `json:"name"`
+	Data    []byte              `json:"data,omitempty"`
+	Mode    fs.FileMode         `json:"mode"`
+	ModTime time.Time           `json:"modtime"`
+	Dir     bool                `json:"dir,omitempty"`
+	Entries []*snapshotDirEntry `json:"entries,omitempty"`
+}
+
+type snapshotDirEntry struct {
+	Name    string      `json:"name"`
+	Mode    fs.FileMode `json:"mode"`
+	ModTime time.Time   `json:"modtime"`
+}
+
+// Snapshot serializes the cached entries, so they can be restored later
+func (c *Cache) Snapshot() ([]byte, error) {
+	c.mu.Lock()
+	snapshots := make([]*snapshot, 0, len(c.entries))
+	for path, element := range c.entries {
+		s, err := snapshotOf(path, element.Value.(*cached).entry)
+		if err != nil {
+			c.mu.Unlock()
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+	c.mu.Unlock()
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Path < snapshots[j].Path
+	})
+	return json.Marshal(snapshots)
+}
+
+func snapshotOf(path string, value Entry) (*snapshot, error) {
+	switch entry := value.(type) {
+	case *File:
+		return &snapshot{
+			Path:    path,
+			Name:    entry.Name,
+			Data:    entry.Data,
+			Mode:    entry.Mode,
+			ModTime: entry.ModTime,
+		}, nil
+	case *Dir:
+		s := &snapshot{
+			Path:    path,
+			Name:    entry.Name,
+			Mode:    entry.Mode,
+			ModTime: entry.ModTime,
+			Dir:     true,
+		}
+		for _, de := range entry.Entries {
+			info, err := de.Info()
+			if err != nil {
+				return nil, err
+			}
+			s.Entries = append(s.Entries, &snapshotDirEntry{
+				Name:    de.Name(),
+				Mode:    info.Mode(),
+				ModTime: info.ModTime(),
+			})
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("fscache: unable to snapshot %q with unknown entry %T", path, value)
+	}
+}
+
+// Restore replaces the cached entries with the entries from the snapshot
+func (c *Cache) Restore(data []byte) error {
+	var snapshots []*snapshot
+	if err := json.Unmarshal(data, &snapshots); err != nil {
+		return fmt.Errorf("fscache: unable to restore snapshot. %w", err)
+	}
+	c.Clear()
+	for _, s := range snapshots {
+		if !s.Dir {
+			c.Set(s.Path, &File{
+				Name:    s.Name,
+				Data:    s.Data,
+				Mode:    s.Mode,
+				ModTime: s.ModTime,
+			})
+			continue
+		}
+		dir := &Dir{
+			Name:    s.Name,
+			Mode:    s.Mode,
+			ModTime: s.ModTime,
+		}
+		for _, de := range s.Entries {
+			dir.Entries = append(dir.Entries, &DirEntry{
+				Base:    de.Name,
+				Mode:    de.Mode,
+				ModTime: de.ModTime,
+			})
+		}
+		c.Set(s.Path, dir)
+	}
+	return nil
+}
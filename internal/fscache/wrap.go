@@ -10,20 +10,35 @@ type Wrapped struct {
 	c    *Cache
 }
 
+// Open the file from the cache, falling back to the wrapped filesystem on a
+// miss. Concurrent misses for the same path share a single open, so the
+// wrapped filesystem opens each path at most once until it's evicted.
 func (w *Wrapped) Open(name string) (fs.File, error) {
-	if w.c.Has(name) {
-		// fmt.Println("  ", w.name, "cache hit", name)
-		return w.c.Open(name)
+	if entry, ok := w.c.get(name); ok {
+		w.c.hit()
+		return entry.open(), nil
 	}
-	// fmt.Println("  ", w.name, "cache miss", name)
-	file, err := w.fs.Open(name)
+	// Key by the wrapper too, since wrapped filesystems may open each other
+	value, err, _ := w.c.group.Do(w.name+":"+name, func() (interface{}, error) {
+		// The path may have been cached by a call that just finished
+		if entry, ok := w.c.get(name); ok {
+			return entry, nil
+		}
+		w.c.miss()
+		file, err := w.fs.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := From(file)
+		if err != nil {
+			return nil, err
+		}
+		w.c.Set(name, entry)
+		return entry, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	entry, err := From(file)
-	if err != nil {
-		return nil, err
-	}
-	w.c.Set(name, entry)
-	return w.c.Open(name)
+	// Open the entry directly, since it may have already been evicted
+	return value.(Entry).open(), nil
 }
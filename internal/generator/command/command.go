@@ -4,6 +4,7 @@ import (
 	"context"
 	"io/fs"
 
+	"github.com/livebud/bud/internal/bail"
 	"github.com/livebud/bud/internal/imports"
 	"github.com/livebud/bud/package/gomod"
 	"github.com/livebud/bud/package/overlay"
@@ -25,7 +26,7 @@ func (c *Command) Parse(ctx context.Context) (*State, error) {
 		fs:      c.fs,
 		module:  c.module,
 		parser:  c.parser,
-		imports: imports.New(),
+		imports: imports.New(imports.WithLocal(c.module.Import())),
 	}).Parse(ctx)
 }
 
@@ -44,7 +45,7 @@ func (c *Command) Compile(ctx context.Context) ([]byte, error) {
 func (c *Command) GenerateFile(ctx context.Context, fsys overlay.F, file *overlay.File) error {
 	code, err := c.Compile(ctx)
 	if err != nil {
-		return err
+		return bail.Generating(err, "command", file.Path())
 	}
 	file.Data = code
 	return nil
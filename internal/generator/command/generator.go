@@ -9,7 +9,7 @@ import (
 //go:embed command.gotext
 var template string
 
-var generator = gotemplate.MustParse("command.gotext", template)
+var generator = gotemplate.MustParse("command.gotext", template, gotemplate.WithFormat())
 
 func Generate(state *State) ([]byte, error) {
 	// if state.Command == nil {
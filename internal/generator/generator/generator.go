@@ -5,6 +5,7 @@ import (
 	_ "embed"
 	"io/fs"
 
+	"github.com/livebud/bud/internal/bail"
 	"github.com/livebud/bud/internal/gotemplate"
 	"github.com/livebud/bud/internal/imports"
 	"github.com/livebud/bud/package/gomod"
@@ -15,7 +16,7 @@ import (
 //go:embed generator.gotext
 var template string
 
-var generator = gotemplate.MustParse("generator.gotext", template)
+var generator = gotemplate.MustParse("generator.gotext", template, gotemplate.WithFormat())
 
 func Generate(state *State) ([]byte, error) {
 	return generator.Generate(state)
@@ -36,7 +37,7 @@ func (c *Generator) Parse(ctx context.Context) (*State, error) {
 		fs:      c.fs,
 		module:  c.module,
 		parser:  c.parser,
-		imports: imports.New(),
+		imports: imports.New(imports.WithLocal(c.module.Import())),
 	}).Parse(ctx)
 }
 
@@ -55,7 +56,7 @@ func (c *Generator) Compile(ctx context.Context) ([]byte, error) {
 func (c *Generator) GenerateFile(ctx context.Context, fsys overlay.F, file *overlay.File) error {
 	code, err := c.Compile(ctx)
 	if err != nil {
-		return err
+		return bail.Generating(err, "generator", file.Path())
 	}
 	file.Data = code
 	return nil
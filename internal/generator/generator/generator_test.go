@@ -0,0 +1,63 @@
+package generator_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/livebud/bud/internal/budtest"
+	"github.com/matryer/is"
+)
+
+func TestProjectGenerators(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	bud := budtest.New(dir)
+	bud.Files["generator/hello/hello.go"] = `
+		package hello
+
+		import (
+			"context"
+
+			"github.com/livebud/bud/package/overlay"
+		)
+
+		type Generator struct{}
+
+		func (g *Generator) GenerateFile(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+			file.Data = []byte("package hello\n\nconst Greeting = \"hello\"\n")
+			return nil
+		}
+	`
+	bud.Files["generator/tables/tables.go"] = `
+		package tables
+
+		import (
+			"context"
+
+			"github.com/livebud/bud/package/overlay"
+		)
+
+		type Generator struct{}
+
+		func (g *Generator) GenerateDir(ctx context.Context, fsys overlay.F, dir *overlay.Dir) error {
+			dir.GenerateFile("users.go", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+				file.Data = []byte("package tables\n\ntype User struct{}\n")
+				return nil
+			})
+			return nil
+		}
+	`
+	project, err := bud.Compile(ctx)
+	is.NoErr(err)
+	_, err = project.Build(ctx)
+	is.NoErr(err)
+	data, err := os.ReadFile(filepath.Join(dir, "bud/.app/generator/hello/hello.go"))
+	is.NoErr(err)
+	is.Equal(string(data), "package hello\n\nconst Greeting = \"hello\"\n")
+	data, err = os.ReadFile(filepath.Join(dir, "bud/.app/generator/tables/users.go"))
+	is.NoErr(err)
+	is.Equal(string(data), "package tables\n\ntype User struct{}\n")
+}
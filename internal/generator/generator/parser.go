@@ -2,10 +2,14 @@ package generator
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io/fs"
+	"path"
 
 	"github.com/livebud/bud/internal/bail"
 	"github.com/livebud/bud/internal/imports"
+	"github.com/livebud/bud/internal/valid"
 	"github.com/livebud/bud/package/gomod"
 	goparse "github.com/livebud/bud/package/parser"
 )
@@ -29,6 +33,76 @@ func (p *parser) Parse(ctx context.Context) (state *State, err error) {
 	p.imports.AddNamed("controller", "github.com/livebud/bud/runtime/generator/controller")
 	p.imports.AddNamed("view", "github.com/livebud/bud/runtime/generator/view")
 	state = new(State)
+	state.Generators = p.loadGenerators("generator")
 	state.Imports = p.imports.List()
 	return state, nil
 }
+
+// Load the project's generators. Each generator lives in generator/<name> and
+// has a Generator struct with either a GenerateFile or a GenerateDir method.
+func (p *parser) loadGenerators(base string) (generators []*Gen) {
+	des, err := fs.ReadDir(p.fs, base)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		p.Bail(err)
+	}
+	for _, de := range des {
+		if !de.IsDir() || !valid.Dir(de.Name()) {
+			continue
+		}
+		gen := p.loadGenerator(path.Join(base, de.Name()))
+		if gen == nil {
+			continue
+		}
+		generators = append(generators, gen)
+	}
+	return generators
+}
+
+func (p *parser) loadGenerator(dir string) *Gen {
+	des, err := fs.ReadDir(p.fs, dir)
+	if err != nil {
+		p.Bail(err)
+	}
+	shouldParse := false
+	for _, de := range des {
+		if !de.IsDir() && valid.GeneratorFile(de.Name()) {
+			shouldParse = true
+			break
+		}
+	}
+	if !shouldParse {
+		return nil
+	}
+	pkg, err := p.parser.Parse(dir)
+	if err != nil {
+		p.Bail(err)
+	}
+	stct := pkg.Struct("Generator")
+	if stct == nil {
+		p.Bail(fmt.Errorf("generator: %q is missing a Generator struct", dir))
+	}
+	gen := new(Gen)
+	gen.Name = path.Base(dir)
+	hasFile := stct.Method("GenerateFile") != nil
+	hasDir := stct.Method("GenerateDir") != nil
+	switch {
+	case hasFile && hasDir:
+		p.Bail(fmt.Errorf("generator: %q can't have both a GenerateFile and a GenerateDir method", dir))
+	case hasDir:
+		gen.Dir = true
+		gen.Path = path.Join("bud/.app", dir)
+	case hasFile:
+		gen.Path = path.Join("bud/.app", dir, gen.Name+".go")
+	default:
+		p.Bail(fmt.Errorf("generator: %q must have a GenerateFile or a GenerateDir method", dir))
+	}
+	importPath := p.module.Import(dir)
+	gen.Import = &imports.Import{
+		Name: p.imports.Add(importPath),
+		Path: importPath,
+	}
+	return gen
+}
@@ -1,7 +1,23 @@
 package generator
 
-import "github.com/livebud/bud/internal/imports"
+import (
+	"github.com/livebud/bud/internal/imports"
+	"github.com/matthewmueller/gotext"
+)
 
 type State struct {
-	Imports []*imports.Import
+	Imports    []*imports.Import
+	Generators []*Gen
+}
+
+// Gen is a generator within the project
+type Gen struct {
+	Import *imports.Import
+	Name   string
+	Path   string // Path the generator is registered at
+	Dir    bool   // Generates a directory instead of a file
+}
+
+func (g *Gen) Camel() string {
+	return gotext.Camel(g.Name) + "Generator"
 }
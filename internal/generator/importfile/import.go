@@ -15,7 +15,7 @@ import (
 //go:embed import.gotext
 var template string
 
-var generator = gotemplate.MustParse("import.gotext", template)
+var generator = gotemplate.MustParse("import.gotext", template, gotemplate.WithFormat())
 
 // State for the generator
 type State struct {
@@ -13,7 +13,7 @@ import (
 //go:embed main.gotext
 var template string
 
-var generator = gotemplate.MustParse("main.gotext", template)
+var generator = gotemplate.MustParse("main.gotext", template, gotemplate.WithFormat())
 
 // State for the generator
 type State struct {
@@ -29,7 +29,7 @@ type Main struct {
 }
 
 func (m *Main) Parse(ctx context.Context) (*State, error) {
-	imports := imports.New()
+	imports := imports.New(imports.WithLocal(m.module.Import()))
 	imports.AddStd("os", "context")
 	imports.AddNamed("program", m.module.Import("bud/.cli/program"))
 	return &State{
@@ -17,7 +17,7 @@ import (
 //go:embed program.gotext
 var template string
 
-var generator = gotemplate.MustParse("program.gotext", template)
+var generator = gotemplate.MustParse("program.gotext", template, gotemplate.WithFormat())
 
 var ErrCantWire = errors.New(`program: unable to wire`)
 
@@ -45,7 +45,7 @@ type Program struct {
 
 func (p *Program) Parse(ctx context.Context) (*State, error) {
 	// Default  imports
-	imports := imports.New()
+	imports := imports.New(imports.WithLocal(p.module.Import()))
 	imports.AddStd("errors", "context")
 	imports.AddNamed("console", "github.com/livebud/bud/package/log/console")
 	imports.AddNamed("command", p.module.Import("bud/.cli/command"))
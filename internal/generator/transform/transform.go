@@ -14,7 +14,7 @@ import (
 //go:embed transform.gotext
 var template string
 
-var generator = gotemplate.MustParse("transform.gotext", template)
+var generator = gotemplate.MustParse("transform.gotext", template, gotemplate.WithFormat())
 
 func New(module *gomod.Module) *Generator {
 	return &Generator{module}
@@ -39,7 +39,7 @@ type Transform struct {
 }
 
 func (g *Generator) GenerateFile(ctx context.Context, fsys overlay.F, file *overlay.File) error {
-	imports := imports.New()
+	imports := imports.New(imports.WithLocal(g.Module.Import()))
 	imports.AddNamed("transform", "github.com/livebud/bud/runtime/transform")
 	imports.AddNamed("svelte", "github.com/livebud/bud/package/svelte")
 	code, err := generator.Generate(State{
@@ -0,0 +1,92 @@
+package gotemplate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Error is a template that failed to parse, execute or format, along with the
+// lines around the failure. Lines of format errors refer to the generated code
+// rather than the template.
+type Error struct {
+	Name    string // Name of the template
+	Line    int    // Line of the failure
+	Column  int    // Column of the failure, 0 if unknown
+	Message string // Message without the location
+	Context string // Lines around the failure
+	Err     error  // Underlying text/template error
+}
+
+func (e *Error) Error() string {
+	out := new(strings.Builder)
+	out.WriteString("gotemplate: ")
+	out.WriteString(e.Name)
+	out.WriteString(":" + strconv.Itoa(e.Line))
+	if e.Column > 0 {
+		out.WriteString(":" + strconv.Itoa(e.Column))
+	}
+	out.WriteString(": " + e.Message)
+	if e.Context != "" {
+		out.WriteString("\n\n" + e.Context)
+	}
+	return out.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Matches the location text/template prefixes its errors with, e.g.
+// "template: command.gotext:12:5: executing ..." or "template: command.gotext:3:
+// unclosed action"
+var errorLocation = regexp.MustCompile(`^template: (.+?):(\d+)(?::(\d+))?: (.*)$`)
+
+// contextLines is the number of lines shown before and after the failing line
+const contextLines = 2
+
+// wrapError adds the template name, line and surrounding lines of code to the
+// error. Errors without a location are returned as-is.
+func wrapError(name, code string, err error) error {
+	match := errorLocation.FindStringSubmatch(err.Error())
+	if match == nil || match[1] != name {
+		return err
+	}
+	line, _ := strconv.Atoi(match[2])
+	column, _ := strconv.Atoi(match[3])
+	return &Error{
+		Name:    name,
+		Line:    line,
+		Column:  column,
+		Message: match[4],
+		Context: context(code, line),
+		Err:     err,
+	}
+}
+
+// context renders the lines around line, marking the line itself
+func context(code string, line int) string {
+	lines := strings.Split(code, "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	start, end := line-contextLines, line+contextLines
+	if start < 1 {
+		start = 1
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+	width := len(strconv.Itoa(end))
+	out := new(strings.Builder)
+	for i := start; i <= end; i++ {
+		marker := " "
+		if i == line {
+			marker = ">"
+		}
+		out.WriteString(strings.TrimRight(fmt.Sprintf("%s %*d | %s", marker, width, i, lines[i-1]), " "))
+		out.WriteString("\n")
+	}
+	return strings.TrimRight(out.String(), "\n")
+}
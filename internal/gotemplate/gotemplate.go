@@ -2,16 +2,56 @@ package gotemplate
 
 import (
 	"bytes"
+	"errors"
+	"go/format"
+	"go/scanner"
 	"text/template"
+
+	"golang.org/x/tools/imports"
 )
 
 type Template interface {
 	Generate(state interface{}) ([]byte, error)
 }
 
+type Option func(*option)
+
+type option struct {
+	funcs      template.FuncMap
+	format     bool
+	fixImports bool
+}
+
+// WithFuncs adds helper functions to the template. Functions with the same
+// name as an earlier function replace it.
+func WithFuncs(funcs map[string]interface{}) Option {
+	return func(o *option) {
+		for name, fn := range funcs {
+			o.funcs[name] = fn
+		}
+	}
+}
+
+// WithFormat formats the generated code with gofmt, so templates don't need to
+// get the whitespace right. The generated code must be valid Go.
+func WithFormat() Option {
+	return func(o *option) {
+		o.format = true
+	}
+}
+
+// WithFixImports formats the generated code like goimports, which also adds
+// missing imports and removes unused ones
+func WithFixImports() Option {
+	return func(o *option) {
+		o.format = true
+		o.fixImports = true
+	}
+}
+
 // MustParse panics if unable to parse
-func MustParse(name, code string) Template {
-	template, err := Parse(name, code)
+func MustParse(name, code string, options ...Option) Template {
+	template, err := Parse(name, code, options...)
 	if err != nil {
 		panic(err)
 	}
@@ -19,24 +59,65 @@ func MustParse(name, code string) Template {
 }
 
 // Parse parses Go code
-func Parse(name, code string) (Template, error) {
-	tpl, err := template.New(name).Parse(code)
+func Parse(name, code string, options ...Option) (Template, error) {
+	opt := &option{
+		funcs: template.FuncMap{},
+	}
+	for _, option := range options {
+		option(opt)
+	}
+	// Funcs must be added before parsing, so the parser knows about them
+	tpl, err := template.New(name).Funcs(opt.funcs).Parse(code)
 	if err != nil {
-		return nil, err
+		return nil, wrapError(name, code, err)
 	}
-	return &gotemplate{tpl}, nil
+	return &gotemplate{name, code, tpl, opt}, nil
 }
 
 // Template struct
 type gotemplate struct {
-	tpl *template.Template
+	name string
+	code string
+	tpl  *template.Template
+	opt  *option
 }
 
 // Generate the code
 func (t *gotemplate) Generate(state interface{}) ([]byte, error) {
 	buf := new(bytes.Buffer)
 	if err := t.tpl.Execute(buf, state); err != nil {
-		return nil, err
+		return nil, wrapError(t.name, t.code, err)
+	}
+	if !t.opt.format {
+		return buf.Bytes(), nil
+	}
+	return t.format(buf.Bytes())
+}
+
+// format the generated code, pointing to the invalid generated code on error
+func (t *gotemplate) format(code []byte) (formatted []byte, err error) {
+	if t.opt.fixImports {
+		formatted, err = imports.Process(t.name, code, &imports.Options{
+			Comments:  true,
+			TabIndent: true,
+			TabWidth:  8,
+		})
+	} else {
+		formatted, err = format.Source(code)
+	}
+	if err != nil {
+		var list scanner.ErrorList
+		if !errors.As(err, &list) || len(list) == 0 {
+			return nil, err
+		}
+		return nil, &Error{
+			Name:    t.name,
+			Line:    list[0].Pos.Line,
+			Column:  list[0].Pos.Column,
+			Message: "unable to format the generated code. " + list[0].Msg,
+			Context: context(string(code), list[0].Pos.Line),
+			Err:     err,
+		}
 	}
-	return buf.Bytes(), nil
+	return formatted, nil
 }
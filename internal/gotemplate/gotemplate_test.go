@@ -0,0 +1,134 @@
+package gotemplate_test
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/livebud/bud/internal/gotemplate"
+	"github.com/matryer/is"
+	"github.com/matthewmueller/gotext"
+)
+
+func TestGenerate(t *testing.T) {
+	is := is.New(t)
+	tpl, err := gotemplate.Parse("test.gotext", `package {{ $.Name }}`)
+	is.NoErr(err)
+	code, err := tpl.Generate(map[string]string{"Name": "web"})
+	is.NoErr(err)
+	is.Equal(string(code), "package web")
+}
+
+func TestWithFuncs(t *testing.T) {
+	is := is.New(t)
+	tpl, err := gotemplate.Parse("test.gotext", `{{ plural $.Name }} {{ camel $.Name }} {{ join "bud" $.Name }}`,
+		gotemplate.WithFuncs(map[string]interface{}{
+			"plural": gotext.Plural,
+			"camel":  gotext.Camel,
+			"join":   path.Join,
+		}),
+	)
+	is.NoErr(err)
+	code, err := tpl.Generate(map[string]string{"Name": "blog_post"})
+	is.NoErr(err)
+	is.Equal(string(code), "blog_posts blogPost bud/blog_post")
+}
+
+func TestWithFuncsOverride(t *testing.T) {
+	is := is.New(t)
+	tpl, err := gotemplate.Parse("test.gotext", `{{ shout "hi" }}`,
+		gotemplate.WithFuncs(map[string]interface{}{"shout": strings.ToLower}),
+		gotemplate.WithFuncs(map[string]interface{}{"shout": strings.ToUpper}),
+	)
+	is.NoErr(err)
+	code, err := tpl.Generate(nil)
+	is.NoErr(err)
+	is.Equal(string(code), "HI")
+}
+
+func TestUnknownFunc(t *testing.T) {
+	is := is.New(t)
+	_, err := gotemplate.Parse("test.gotext", `{{ plural "post" }}`)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), `function "plural" not defined`))
+}
+
+func TestExecuteError(t *testing.T) {
+	is := is.New(t)
+	tpl, err := gotemplate.Parse("web.gotext", strings.Join([]string{
+		"package web",
+		"",
+		"import (",
+		"\t{{ $.Import }}",
+		")",
+		"",
+		"func Load() {}",
+	}, "\n"))
+	is.NoErr(err)
+	_, err = tpl.Generate(struct{ Name string }{"web"})
+	is.True(err != nil)
+	lines := strings.Split(err.Error(), "\n")
+	is.Equal(lines[0], `gotemplate: web.gotext:4:5: executing "web.gotext" at <$.Import>: can't evaluate field Import in type struct { Name string }`)
+	var tplErr *gotemplate.Error
+	is.True(errors.As(err, &tplErr))
+	is.Equal(tplErr.Name, "web.gotext")
+	is.Equal(tplErr.Line, 4)
+	is.Equal(tplErr.Column, 5)
+	is.Equal(tplErr.Context, strings.Join([]string{
+		"  2 |",
+		"  3 | import (",
+		"> 4 | \t{{ $.Import }}",
+		"  5 | )",
+		"  6 |",
+	}, "\n"))
+}
+
+func TestParseError(t *testing.T) {
+	is := is.New(t)
+	_, err := gotemplate.Parse("web.gotext", "package web\n\n{{ if }}\n")
+	is.True(err != nil)
+	is.Equal(err.Error(), strings.Join([]string{
+		"gotemplate: web.gotext:3: missing value for if",
+		"",
+		"  1 | package web",
+		"  2 |",
+		"> 3 | {{ if }}",
+		"  4 |",
+	}, "\n"))
+}
+
+func TestWithFormat(t *testing.T) {
+	is := is.New(t)
+	tpl, err := gotemplate.Parse("web.gotext", "package web\nimport (\n{{- range $.Imports }}\n\"{{ . }}\"\n{{- end }}\n)\nvar _ = fmt.Sprintf\nvar  _  =  http.StatusOK\n", gotemplate.WithFormat())
+	is.NoErr(err)
+	code, err := tpl.Generate(map[string][]string{"Imports": {"fmt", "net/http"}})
+	is.NoErr(err)
+	is.Equal(string(code), "package web\n\nimport (\n\t\"fmt\"\n\t\"net/http\"\n)\n\nvar _ = fmt.Sprintf\nvar _ = http.StatusOK\n")
+}
+
+func TestWithFormatError(t *testing.T) {
+	is := is.New(t)
+	tpl, err := gotemplate.Parse("web.gotext", "package web\n\nfunc {{ $.Name }}() {\n}\n", gotemplate.WithFormat())
+	is.NoErr(err)
+	_, err = tpl.Generate(map[string]string{"Name": "Load Web"})
+	is.True(err != nil)
+	is.Equal(err.Error(), strings.Join([]string{
+		"gotemplate: web.gotext:3:11: unable to format the generated code. expected '(', found Web",
+		"",
+		"  1 | package web",
+		"  2 |",
+		"> 3 | func Load Web() {",
+		"  4 | }",
+		"  5 |",
+	}, "\n"))
+}
+
+func TestWithFixImports(t *testing.T) {
+	is := is.New(t)
+	tpl, err := gotemplate.Parse("web.gotext", "package web\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\nvar _ = {{ $.Call }}\n", gotemplate.WithFixImports())
+	is.NoErr(err)
+	code, err := tpl.Generate(map[string]string{"Call": `strings.TrimSpace(fmt.Sprint(" hi "))`})
+	is.NoErr(err)
+	is.Equal(string(code), "package web\n\nimport (\n\t\"fmt\"\n\t\"strings\"\n)\n\nvar _ = strings.TrimSpace(fmt.Sprint(\" hi \"))\n")
+}
@@ -1,9 +1,13 @@
 // Package imports is a small package for dealing with import paths. imports
 // picks unique names if needed and is able to determine the assumed name of the
-// of an import path. It also orders the imports properly for `gofmt`.
+// of an import path. It also orders the imports properly for `gofmt`, grouping
+// them like `goimports`.
 package imports
 
 import (
+	"fmt"
+	"go/token"
+	"go/types"
 	"path"
 	"sort"
 	"strconv"
@@ -12,20 +16,35 @@ import (
 	"unicode/utf8"
 )
 
+type Option func(*Set)
+
+// WithLocal groups the imports within the module at modulePath after the
+// standard library and third-party imports
+func WithLocal(modulePath string) Option {
+	return func(s *Set) {
+		s.local = modulePath
+	}
+}
+
 // New import set
-func New() *Set {
-	return &Set{
-		names:    map[string]int{},
+func New(options ...Option) *Set {
+	set := &Set{
+		names:    map[string]string{},
 		paths:    map[string]string{},
 		reserved: map[string]string{},
 	}
+	for _, option := range options {
+		option(set)
+	}
+	return set
 }
 
 // Set of imports
 type Set struct {
-	names    map[string]int
-	paths    map[string]string
-	reserved map[string]string
+	names    map[string]string // name -> path
+	paths    map[string]string // path -> name
+	reserved map[string]string // path -> name
+	local    string
 }
 
 // AddStd is a convenience function for adding standard library packages
@@ -37,8 +56,17 @@ func (s *Set) AddStd(pkgs ...string) {
 	}
 }
 
-// Add to the set
+// Add to the set. If the assumed name of the path is taken, the name is
+// prefixed with the parent directories of the path until it's unique. For
+// example, "app.com/admin/controller" becomes "admincontroller" when
+// "app.com/controller" was added first.
 func (s *Set) Add(path string) string {
+	return s.AddNamed(AssumedName(path), path)
+}
+
+// AddNamed brings a preferred name. Like Add, the name is prefixed with the
+// parent directories of the path if it's taken.
+func (s *Set) AddNamed(name, path string) string {
 	if name, ok := s.paths[path]; ok {
 		return name
 	}
@@ -47,35 +75,37 @@ func (s *Set) Add(path string) string {
 		s.paths[path] = reserved
 		return reserved
 	}
-	assumed := AssumedName(path)
-	ith := s.names[assumed]
-	uniqueName := assumed
-	if ith > 0 {
-		uniqueName += strconv.Itoa(ith)
-	}
+	uniqueName := s.unique(name, path)
 	s.paths[path] = uniqueName
-	s.names[assumed]++
+	s.names[uniqueName] = path
 	return uniqueName
 }
 
-// AddNamed brings a preferred name
-func (s *Set) AddNamed(name, path string) string {
-	if name, ok := s.paths[path]; ok {
-		return name
+// Alias adds the path with exactly the given name. Unlike AddNamed, it returns
+// an error instead of picking another name when the name is taken.
+func (s *Set) Alias(name, path string) error {
+	if existing, ok := s.paths[path]; ok {
+		if existing == name {
+			return nil
+		}
+		return fmt.Errorf("imports: unable to alias %q as %q because it's already imported as %q", path, name, existing)
 	}
-	if reserved, ok := s.reserved[path]; ok {
-		delete(s.reserved, path)
-		s.paths[path] = reserved
-		return reserved
+	if reserved, ok := s.reserved[path]; ok && reserved != name {
+		return fmt.Errorf("imports: unable to alias %q as %q because %q is reserved for it", path, name, reserved)
 	}
-	ith := s.names[name]
-	uniqueName := name
-	if ith > 0 {
-		uniqueName += strconv.Itoa(ith)
+	if !isIdentifier(name) {
+		return fmt.Errorf("imports: unable to alias %q as %q because it's not a valid identifier", path, name)
 	}
-	s.paths[path] = uniqueName
-	s.names[name]++
-	return uniqueName
+	if isPredeclared(name) {
+		return fmt.Errorf("imports: unable to alias %q as %q because it's a Go keyword or predeclared identifier", path, name)
+	}
+	if other, ok := s.names[name]; ok && other != path {
+		return fmt.Errorf("imports: unable to alias %q as %q because it's already the name of %q", path, name, other)
+	}
+	delete(s.reserved, path)
+	s.paths[path] = name
+	s.names[name] = path
+	return nil
 }
 
 // Reserve a name, but don't take import it. If Add or AddNamed come later,
@@ -84,40 +114,150 @@ func (s *Set) Reserve(path string) string {
 	if name, ok := s.paths[path]; ok {
 		return name
 	}
-	name := AssumedName(path)
-	ith := s.names[name]
-	uniqueName := name
-	if ith > 0 {
-		uniqueName += strconv.Itoa(ith)
+	if name, ok := s.reserved[path]; ok {
+		return name
 	}
+	uniqueName := s.unique(AssumedName(path), path)
 	s.reserved[path] = uniqueName
-	s.names[name]++
+	s.names[uniqueName] = path
 	return uniqueName
 }
 
-// List imports by path first, then by name
+// unique picks a name for the path starting from the preferred name. The name
+// is derived from the path, so it doesn't depend on the other imports unless
+// every parent directory is exhausted. Then it falls back to a number suffix.
+func (s *Set) unique(name, importPath string) string {
+	if s.available(name) {
+		return name
+	}
+	candidate := name
+	parents := parentDirs(importPath)
+	for i := len(parents) - 1; i >= 0; i-- {
+		prefix := identifier(parents[i])
+		if prefix == "" {
+			continue
+		}
+		candidate = prefix + candidate
+		if isIdentifier(candidate) && s.available(candidate) {
+			return candidate
+		}
+	}
+	for ith := 1; ; ith++ {
+		candidate := name + strconv.Itoa(ith)
+		if s.available(candidate) {
+			return candidate
+		}
+	}
+}
+
+func (s *Set) available(name string) bool {
+	_, taken := s.names[name]
+	return !taken && !isPredeclared(name)
+}
+
+// parentDirs returns the directories above the directory the assumed name
+// comes from
+func parentDirs(importPath string) []string {
+	dirs := strings.Split(path.Dir(importPath), "/")
+	if isMajorVersion(path.Base(importPath)) && len(dirs) > 0 {
+		dirs = dirs[:len(dirs)-1]
+	}
+	if len(dirs) == 1 && dirs[0] == "." {
+		return nil
+	}
+	return dirs
+}
+
+// identifier turns a directory into a lowercase identifier. For example,
+// "github.com" becomes "githubcom".
+func identifier(dir string) string {
+	return strings.ToLower(strings.Map(func(r rune) rune {
+		if notIdentifier(r) {
+			return -1
+		}
+		return r
+	}, dir))
+}
+
+func isIdentifier(name string) bool {
+	if name == "" || name == "_" || strings.IndexFunc(name, notIdentifier) >= 0 {
+		return false
+	}
+	first, _ := utf8.DecodeRuneInString(name)
+	return !unicode.IsDigit(first)
+}
+
+// isPredeclared returns true for names that would shadow builtins or fail to
+// compile as package names
+func isPredeclared(name string) bool {
+	return token.IsKeyword(name) || types.Universe.Lookup(name) != nil
+}
+
+func isMajorVersion(element string) bool {
+	if !strings.HasPrefix(element, "v") {
+		return false
+	}
+	_, err := strconv.Atoi(element[1:])
+	return err == nil
+}
+
+// List imports by group first, then by path, then by name. The first import
+// of each group after the first is marked, so templates can separate the
+// groups with a blank line.
 func (s *Set) List() (imports []*Import) {
-	imports = make([]*Import, len(s.paths))
-	i := 0
+	imports = make([]*Import, 0, len(s.paths))
 	for path, name := range s.paths {
-		imports[i] = &Import{name, path}
-		i++
+		imports = append(imports, &Import{Name: name, Path: path})
 	}
 	sort.Slice(imports, func(i int, j int) bool {
+		gi, gj := s.group(imports[i].Path), s.group(imports[j].Path)
+		if gi != gj {
+			return gi < gj
+		}
 		if imports[i].Path == imports[j].Path {
 			return imports[i].Name < imports[j].Name
 		}
 		return imports[i].Path < imports[j].Path
 	})
+	for i := 1; i < len(imports); i++ {
+		imports[i].NewGroup = s.group(imports[i].Path) != s.group(imports[i-1].Path)
+	}
 	return imports
 }
 
+// Groups of imports in the order they're listed
+const (
+	groupStd = iota
+	groupExternal
+	groupLocal
+)
+
+func (s *Set) group(importPath string) int {
+	if s.local != "" && (importPath == s.local || strings.HasPrefix(importPath, s.local+"/")) {
+		return groupLocal
+	}
+	if IsStd(importPath) {
+		return groupStd
+	}
+	return groupExternal
+}
+
+// IsStd returns true if the import path looks like it's from the standard
+// library. Like `goimports`, it assumes that paths without a dot in their first
+// element are standard.
+func IsStd(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".")
+}
+
 // Import struct returned by list
 type Import struct {
 	// Package identifier name
 	Name string `json:"name,omitempty"`
 	// Path to the import
 	Path string `json:"path,omitempty"`
+	// NewGroup is true when the import starts a new group of imports
+	NewGroup bool `json:"new_group,omitempty"`
 }
 
 // AssumedName returns the assumed package name of an import path.
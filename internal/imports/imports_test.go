@@ -12,7 +12,7 @@ func TestAdd(t *testing.T) {
 	im := imports.New()
 	is.Equal(im.Add("net/http"), "http")
 	is.Equal(im.Add("net/http"), "http")
-	is.Equal(im.Add("hop/http"), "http1")
+	is.Equal(im.Add("hop/http"), "hophttp")
 }
 
 func TestAddNamed(t *testing.T) {
@@ -20,7 +20,7 @@ func TestAddNamed(t *testing.T) {
 	im := imports.New()
 	is.Equal(im.AddNamed("www", "net/http"), "www")
 	is.Equal(im.AddNamed("www", "net/http"), "www")
-	is.Equal(im.AddNamed("www", "hop/http"), "www1")
+	is.Equal(im.AddNamed("www", "hop/http"), "hopwww")
 	is.Equal(im.AddNamed("v8", "app.com/js/v8"), "v8")
 }
 
@@ -39,9 +39,9 @@ func TestReserveAfter(t *testing.T) {
 	is.Equal(len(im.List()), 1)
 	is.Equal(im.Reserve("web"), "web")
 	is.Equal(len(im.List()), 1)
-	is.Equal(im.Reserve("duo/web"), "web1")
+	is.Equal(im.Reserve("duo/web"), "duoweb")
 	is.Equal(len(im.List()), 1)
-	is.Equal(im.Add("duo/web"), "web1")
+	is.Equal(im.Add("duo/web"), "duoweb")
 	is.Equal(len(im.List()), 2)
 }
 
@@ -57,3 +57,149 @@ func TestAddStd(t *testing.T) {
 	is.Equal(im.List()[2].Name, "os")
 	is.Equal(im.List()[2].Path, "os")
 }
+
+func TestGroups(t *testing.T) {
+	is := is.New(t)
+	im := imports.New(imports.WithLocal("app.com"))
+	im.Add("app.com/bud/.app/web")
+	im.Add("github.com/livebud/bud/package/router")
+	im.AddStd("net/http", "context")
+	im.Add("app.com")
+	im.Add("golang.org/x/sync/errgroup")
+	list := im.List()
+	paths := []string{}
+	groups := []bool{}
+	for _, im := range list {
+		paths = append(paths, im.Path)
+		groups = append(groups, im.NewGroup)
+	}
+	is.Equal(paths, []string{
+		"context",
+		"net/http",
+		"github.com/livebud/bud/package/router",
+		"golang.org/x/sync/errgroup",
+		"app.com",
+		"app.com/bud/.app/web",
+	})
+	is.Equal(groups, []bool{false, false, true, false, true, false})
+}
+
+func TestGroupsWithoutLocal(t *testing.T) {
+	is := is.New(t)
+	im := imports.New()
+	im.Add("app.com/web")
+	im.Add("github.com/livebud/bud/package/router")
+	list := im.List()
+	is.Equal(len(list), 2)
+	is.Equal(list[0].Path, "app.com/web")
+	is.Equal(list[0].NewGroup, false)
+	is.Equal(list[1].Path, "github.com/livebud/bud/package/router")
+	is.Equal(list[1].NewGroup, false)
+}
+
+func TestGroupsLocalPrefix(t *testing.T) {
+	is := is.New(t)
+	im := imports.New(imports.WithLocal("app.com/web"))
+	im.Add("app.com/web/controller")
+	im.Add("app.com/website")
+	list := im.List()
+	is.Equal(len(list), 2)
+	is.Equal(list[0].Path, "app.com/website")
+	is.Equal(list[1].Path, "app.com/web/controller")
+	is.Equal(list[1].NewGroup, true)
+}
+
+func TestIsStd(t *testing.T) {
+	is := is.New(t)
+	is.True(imports.IsStd("context"))
+	is.True(imports.IsStd("net/http"))
+	is.True(!imports.IsStd("github.com/livebud/bud"))
+	is.True(!imports.IsStd("app.com"))
+}
+
+func TestAddParentPrefix(t *testing.T) {
+	is := is.New(t)
+	im := imports.New()
+	is.Equal(im.Add("app.com/controller"), "controller")
+	is.Equal(im.Add("app.com/admin/controller"), "admincontroller")
+	is.Equal(im.Add("app.com/api/admin/controller"), "apiadmincontroller")
+	is.Equal(im.Add("app.com/controller/v2"), "appcomcontroller")
+	is.Equal(im.Add("github.com/livebud/controller/v2"), "livebudcontroller")
+	is.Equal(im.Add("controller"), "controller1")
+}
+
+func TestAddStable(t *testing.T) {
+	is := is.New(t)
+	a := imports.New()
+	a.Add("app.com/controller")
+	a.Add("app.com/view")
+	is.Equal(a.Add("app.com/admin/controller"), "admincontroller")
+	b := imports.New()
+	b.Add("app.com/view")
+	b.Add("app.com/posts/view")
+	b.Add("app.com/controller")
+	is.Equal(b.Add("app.com/admin/controller"), "admincontroller")
+}
+
+func TestAddPredeclared(t *testing.T) {
+	is := is.New(t)
+	im := imports.New()
+	is.Equal(im.Add("app.com/command/migrate/new"), "migratenew")
+	is.Equal(im.Add("app.com/types/string"), "typesstring")
+	is.Equal(im.Add("app.com/go"), "appcomgo")
+	is.Equal(im.Reserve("app.com/internal/error"), "internalerror")
+}
+
+func TestAddDigitPrefix(t *testing.T) {
+	is := is.New(t)
+	im := imports.New()
+	is.Equal(im.Add("app.com/auth"), "auth")
+	is.Equal(im.Add("app.com/2fa/auth"), "appcom2faauth")
+}
+
+func TestReserveTwice(t *testing.T) {
+	is := is.New(t)
+	im := imports.New()
+	is.Equal(im.Reserve("app.com/web"), "web")
+	is.Equal(im.Reserve("app.com/web"), "web")
+	is.Equal(im.Add("app.com/web"), "web")
+}
+
+func TestAlias(t *testing.T) {
+	is := is.New(t)
+	im := imports.New()
+	is.NoErr(im.Alias("router", "github.com/livebud/bud/package/router"))
+	is.NoErr(im.Alias("router", "github.com/livebud/bud/package/router"))
+	is.Equal(im.Add("github.com/livebud/bud/package/router"), "router")
+	is.Equal(im.Add("app.com/router"), "appcomrouter")
+	list := im.List()
+	is.Equal(len(list), 2)
+	is.Equal(list[0].Name, "appcomrouter")
+	is.Equal(list[1].Name, "router")
+}
+
+func TestAliasErrors(t *testing.T) {
+	is := is.New(t)
+	im := imports.New()
+	im.Add("app.com/web")
+	im.Reserve("app.com/view")
+	tests := []struct {
+		name, path, err string
+	}{
+		{"web", "app.com/admin/web", `imports: unable to alias "app.com/admin/web" as "web" because it's already the name of "app.com/web"`},
+		{"www", "app.com/web", `imports: unable to alias "app.com/web" as "www" because it's already imported as "web"`},
+		{"v", "app.com/view", `imports: unable to alias "app.com/view" as "v" because "view" is reserved for it`},
+		{"view", "app.com/admin/view", `imports: unable to alias "app.com/admin/view" as "view" because it's already the name of "app.com/view"`},
+		{"new", "app.com/new", `imports: unable to alias "app.com/new" as "new" because it's a Go keyword or predeclared identifier`},
+		{"func", "app.com/func", `imports: unable to alias "app.com/func" as "func" because it's a Go keyword or predeclared identifier`},
+		{"my-web", "app.com/my-web", `imports: unable to alias "app.com/my-web" as "my-web" because it's not a valid identifier`},
+		{"2fa", "app.com/2fa", `imports: unable to alias "app.com/2fa" as "2fa" because it's not a valid identifier`},
+	}
+	for _, test := range tests {
+		err := im.Alias(test.name, test.path)
+		is.True(err != nil)
+		is.Equal(err.Error(), test.err)
+	}
+	is.Equal(len(im.List()), 1)
+	is.Equal(im.Add("app.com/view"), "view")
+}
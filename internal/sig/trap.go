@@ -6,15 +6,22 @@ import (
 	"os/signal"
 )
 
-// Trap cancels the context based on a signal
+// Trap cancels the context based on a signal. Trapping no signals returns a
+// context that's only cancelled by the cancel function.
 func Trap(ctx context.Context, signals ...os.Signal) (context.Context, context.CancelFunc) {
 	ret, cancel := context.WithCancel(ctx)
+	if len(signals) == 0 {
+		return ret, cancel
+	}
 	ch := make(chan os.Signal, len(signals))
+	signal.Notify(ch, signals...)
 	go func() {
-		<-ch
-		cancel()
-		signal.Stop(ch)
+		defer signal.Stop(ch)
+		select {
+		case <-ch:
+			cancel()
+		case <-ret.Done():
+		}
 	}()
-	signal.Notify(ch, signals...)
 	return ret, cancel
 }
@@ -3,6 +3,7 @@ package sig_test
 import (
 	"context"
 	"os"
+	"os/signal"
 	"testing"
 	"time"
 
@@ -56,3 +57,21 @@ func TestCancel(t *testing.T) {
 		is.Fail() // context should have been cancelled
 	}
 }
+
+func TestNoSignals(t *testing.T) {
+	is := is.New(t)
+	ctx, cancel := sig.Trap(context.Background())
+	defer cancel()
+	// Catch the interrupt so it doesn't kill the test process
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt)
+	defer signal.Stop(ch)
+	is.NoErr(raise(os.Interrupt))
+	<-ch
+	// Should not have been cancelled by the signal
+	select {
+	case <-ctx.Done():
+		is.Fail() // context shouldn't be cancelled
+	case <-time.After(50 * time.Millisecond):
+	}
+}
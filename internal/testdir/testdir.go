@@ -243,12 +243,13 @@ func (d *Dir) Write(dir string, options ...Option) error {
 	if opt.backup {
 		cachedFS, err := snapshot.Restore(hash)
 		if nil == err {
-			return dsync.Dir(cachedFS, ".", vfs.OS(dir), ".", dsync.WithSkip(opt.skips...))
+			_, err := dsync.Dir(cachedFS, ".", vfs.OS(dir), ".", dsync.WithSkip(opt.skips...))
+			return err
 		} else if !errors.Is(err, fs.ErrNotExist) {
 			return err
 		}
 	}
-	if err := dsync.Dir(fsys, ".", vfs.OS(dir), ".", dsync.WithSkip(opt.skips...)); err != nil {
+	if _, err := dsync.Dir(fsys, ".", vfs.OS(dir), ".", dsync.WithSkip(opt.skips...)); err != nil {
 		return err
 	}
 	// Load the module cache
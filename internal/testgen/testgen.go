@@ -0,0 +1,165 @@
+// Package testgen runs generators against fixture projects and compares the
+// generated files to golden files. Fixtures and golden files are both txtar
+// archives. Run the tests with -update to rewrite the golden files.
+package testgen
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"go/format"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/livebud/bud/internal/txtar"
+	"github.com/livebud/bud/package/gomod"
+	"github.com/livebud/bud/package/overlay"
+	"github.com/livebud/bud/package/parser"
+	"github.com/livebud/bud/package/vfs"
+	"github.com/matthewmueller/diff"
+	xtxtar "golang.org/x/tools/txtar"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+const goMod = `module app.com
+
+go 1.18
+
+require github.com/livebud/bud v0.0.0
+
+replace github.com/livebud/bud => %s
+`
+
+// Load the txtar fixture into a temporary project. The project depends on
+// this checkout of bud unless the fixture has its own go.mod.
+func Load(t testing.TB, fixture string) *Project {
+	t.Helper()
+	fsys, err := txtar.ParseFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fsys["go.mod"]; !ok {
+		budDir, err := budDirectory()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fsys["go.mod"] = &vfs.File{Data: []byte(strings.Replace(goMod, "%s", budDir, 1))}
+		// Reuse bud's go.sum, so the project's dependencies are verified
+		sum, err := os.ReadFile(filepath.Join(budDir, "go.sum"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fsys["go.sum"] = &vfs.File{Data: sum}
+	}
+	dir := t.TempDir()
+	if err := vfs.Write(dir, fsys); err != nil {
+		t.Fatal(err)
+	}
+	module, err := gomod.Find(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ofs, err := overlay.Load(module)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Project{
+		t:       t,
+		fixture: fixture,
+		Module:  module,
+		FS:      ofs,
+		Parser:  parser.New(ofs, module),
+	}
+}
+
+// Project is a fixture project to register generators with
+type Project struct {
+	t       testing.TB
+	fixture string
+	Module  *gomod.Module
+	FS      *overlay.FileSystem
+	Parser  *parser.Parser
+}
+
+// Golden compares the files generated at paths to the golden file next to the
+// fixture, e.g. testdata/nested.golden for testdata/nested.txt. Generated Go
+// files are formatted first, so they must parse.
+func (p *Project) Golden(paths ...string) {
+	p.t.Helper()
+	archive := new(xtxtar.Archive)
+	for _, root := range paths {
+		err := fs.WalkDir(p.FS, root, func(fpath string, de fs.DirEntry, err error) error {
+			if err != nil || de.IsDir() {
+				return err
+			}
+			data, err := fs.ReadFile(p.FS, fpath)
+			if err != nil {
+				return err
+			}
+			if path.Ext(fpath) == ".go" {
+				if data, err = format.Source(data); err != nil {
+					return &fs.PathError{Op: "format", Path: fpath, Err: err}
+				}
+			}
+			archive.Files = append(archive.Files, xtxtar.File{Name: fpath, Data: data})
+			return nil
+		})
+		if err != nil {
+			p.t.Fatal(err)
+		}
+	}
+	actual := xtxtar.Format(archive)
+	golden := strings.TrimSuffix(p.fixture, filepath.Ext(p.fixture)) + ".golden"
+	if *update {
+		if err := os.WriteFile(golden, actual, 0644); err != nil {
+			p.t.Fatal(err)
+		}
+		return
+	}
+	expect, err := os.ReadFile(golden)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			p.t.Fatalf("testgen: missing golden file %q. Run the test with -update to create it", golden)
+		}
+		p.t.Fatal(err)
+	}
+	if !bytes.Equal(expect, actual) {
+		diff.TestString(p.t, string(expect), string(actual))
+	}
+}
+
+// Vet writes the generated dirs to the project and runs go vet on them, which
+// also checks that they compile
+func (p *Project) Vet(dirs ...string) {
+	p.t.Helper()
+	args := []string{"vet"}
+	for _, dir := range dirs {
+		if err := p.FS.Sync(dir); err != nil {
+			p.t.Fatal(err)
+		}
+		args = append(args, "./"+dir)
+	}
+	cmd := exec.Command("go", args...)
+	cmd.Dir = p.Module.Directory()
+	// Allow go.mod to be updated with the requirements of the fixture
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		p.t.Fatalf("testgen: go vet failed. %s\n%s", err, out)
+	}
+}
+
+// budDirectory returns the root of this checkout of bud
+func budDirectory() (string, error) {
+	_, filename, _, ok := runtime.Caller(0)
+	if !ok {
+		return "", errors.New("testgen: unable to get the current filename")
+	}
+	return gomod.Absolute(filepath.Dir(filename))
+}
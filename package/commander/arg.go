@@ -1,8 +1,64 @@
 package commander
 
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+)
+
 type Arg struct {
-	Name  string
-	value value
+	Name       string
+	usage      string
+	value      value
+	validators []func(value string) error
+	secret     bool
+}
+
+// Usage describes the argument in the help output
+func (a *Arg) Usage(usage string) *Arg {
+	a.usage = usage
+	return a
+}
+
+// Secret masks the input when prompting for the argument
+func (a *Arg) Secret() *Arg {
+	a.secret = true
+	return a
+}
+
+// Validate the argument's value before it's set. Validators run in the order
+// they're added.
+func (a *Arg) Validate(validate func(value string) error) *Arg {
+	a.validators = append(a.validators, validate)
+	return a
+}
+
+// set validates and sets the value
+func (a *Arg) set(val string) error {
+	if err := validate(a.validators, a.Name, val); err != nil {
+		return err
+	}
+	if err := a.value.Set(val); err != nil {
+		return fmt.Errorf("invalid value %q for %s: %w", val, a.Name, err)
+	}
+	return nil
+}
+
+// validate the value against each of the validators
+func validate(validators []func(value string) error, name, val string) error {
+	for _, validate := range validators {
+		if err := validate(val); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %w", val, name, err)
+		}
+	}
+	return nil
+}
+
+func (a *Arg) Bool(target *bool) *Bool {
+	value := &Bool{target: target}
+	a.value = &boolValue{inner: value}
+	return value
 }
 
 func (a *Arg) Int(target *int) *Int {
@@ -11,12 +67,66 @@ func (a *Arg) Int(target *int) *Int {
 	return value
 }
 
+func (a *Arg) Float64(target *float64) *Float64 {
+	value := &Float64{target: target}
+	a.value = &float64Value{inner: value}
+	return value
+}
+
+func (a *Arg) Duration(target *time.Duration) *Duration {
+	value := &Duration{target: target}
+	a.value = &durationValue{inner: value}
+	return value
+}
+
 func (a *Arg) String(target *string) *String {
 	value := &String{target: target}
 	a.value = &stringValue{inner: value}
 	return value
 }
 
+func (a *Arg) Enum(target *string, choices ...string) *Enum {
+	value := &Enum{target: target, choices: choices}
+	a.value = &enumValue{inner: value}
+	return value
+}
+
+func (a *Arg) URL(target *url.URL) *URL {
+	value := &URL{target: target}
+	a.value = &urlValue{inner: value}
+	return value
+}
+
+func (a *Arg) Path(target *string) *Path {
+	value := &Path{target: target}
+	a.value = &pathValue{inner: value}
+	return value
+}
+
+func (a *Arg) Time(target *time.Time) *Time {
+	value := &Time{target: target, layout: time.RFC3339}
+	a.value = &timeValue{inner: value}
+	return value
+}
+
+func (a *Arg) Bytes(target *int64) *Bytes {
+	value := &Bytes{target: target}
+	a.value = &bytesValue{inner: value}
+	return value
+}
+
+func (a *Arg) IP(target *net.IP) *IP {
+	value := &IP{target: target}
+	a.value = &ipValue{inner: value}
+	return value
+}
+
+func (a *Arg) CIDR(target *net.IPNet) *CIDR {
+	value := &CIDR{target: target}
+	a.value = &cidrValue{inner: value}
+	return value
+}
+
 func (a *Arg) Strings(target *[]string) *Strings {
 	value := &Strings{target: target}
 	a.value = &stringsValue{inner: value}
@@ -29,6 +139,24 @@ func (a *Arg) StringMap(target *map[string]string) *StringMap {
 	return value
 }
 
+func (a *Arg) JSON(target interface{}) *JSON {
+	value := &JSON{target: target}
+	a.value = &jsonValue{inner: value}
+	return value
+}
+
+func (a *Arg) Custom(target Value) *Custom {
+	value := &Custom{target: target}
+	a.value = &customValue{inner: value}
+	return value
+}
+
+// optional is true when the argument can be omitted
+func (a *Arg) optional() bool {
+	o, ok := a.value.(optioner)
+	return ok && o.optional()
+}
+
 func (a *Arg) verify(name string) error {
 	return a.value.verify(name)
 }
@@ -41,36 +169,3 @@ func verifyArgs(args []*Arg) error {
 	}
 	return nil
 }
-
-// func (a *Arg) Optional() *optionalArg {
-
-// }
-
-// type optionalArg struct {
-// 	Name  string
-// 	value value
-// }
-
-// func (a *optionalArg) Int(target **int) *Int {
-// 	value := &Int{target: target}
-// 	a.value = &intValue{inner: value}
-// 	return value
-// }
-
-// func (a *optionalArg) String(target **string) *String {
-// 	value := &String{target: target}
-// 	a.value = &stringValue{inner: value}
-// 	return value
-// }
-
-// func (a *optionalArg) Strings(target **[]string) *Strings {
-// 	value := &Strings{target: target}
-// 	a.value = &stringsValue{inner: value}
-// 	return value
-// }
-
-// func (a *optionalArg) StringMap(target **map[string]string) *StringMap {
-// 	value := &StringMap{target: target}
-// 	a.value = &stringMapValue{inner: value}
-// 	return value
-// }
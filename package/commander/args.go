@@ -1,8 +1,67 @@
 package commander
 
+import "fmt"
+
 type Args struct {
-	Name  string
-	value value
+	Name       string
+	usage      string
+	value      value
+	validators []func(value string) error
+	min        int
+	max        int // zero means there's no maximum
+	count      int // number of values set
+}
+
+// Usage describes the arguments in the help output
+func (a *Args) Usage(usage string) *Args {
+	a.usage = usage
+	return a
+}
+
+// Min requires at least n values
+func (a *Args) Min(n int) *Args {
+	a.min = n
+	return a
+}
+
+// Max allows at most n values
+func (a *Args) Max(n int) *Args {
+	a.max = n
+	return a
+}
+
+// Validate each of the argument values before they're set. Validators run in
+// the order they're added.
+func (a *Args) Validate(validate func(value string) error) *Args {
+	a.validators = append(a.validators, validate)
+	return a
+}
+
+// set validates and appends the value
+func (a *Args) set(val string) error {
+	if err := validate(a.validators, a.Name, val); err != nil {
+		return err
+	}
+	if err := a.value.Set(val); err != nil {
+		return fmt.Errorf("invalid value %q for %s: %w", val, a.Name, err)
+	}
+	a.count++
+	return nil
+}
+
+// verify the number of values is within the arity. Defaults are applied when
+// no values were set.
+func (a *Args) verify() error {
+	if o, ok := a.value.(optioner); ok && a.count == 0 && o.optional() {
+		return a.value.verify(a.Name)
+	} else if a.count == 0 && a.min > 0 {
+		return fmt.Errorf("missing %s", a.Name)
+	} else if a.count < a.min {
+		return fmt.Errorf("expected at least %d %s but got %d", a.min, a.Name, a.count)
+	} else if a.max > 0 && a.count > a.max {
+		return fmt.Errorf("expected at most %d %s but got %d", a.max, a.Name, a.count)
+	}
+	return nil
 }
 
 func (a *Args) Strings(target *[]string) *Strings {
@@ -10,3 +69,15 @@ func (a *Args) Strings(target *[]string) *Strings {
 	a.value = &stringsValue{inner: value}
 	return value
 }
+
+func (a *Args) Ints(target *[]int) *Ints {
+	value := &Ints{target: target}
+	a.value = &intsValue{inner: value}
+	return value
+}
+
+func (a *Args) Float64s(target *[]float64) *Float64s {
+	value := &Float64s{target: target}
+	a.value = &float64sValue{inner: value}
+	return value
+}
@@ -0,0 +1,172 @@
+package commander
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Commander declares flags, arguments and the runner for a command. It's
+// implemented by *CLI and *Command.
+type Commander interface {
+	Flag(name, usage string) *Flag
+	Arg(name string) *Arg
+	Args(name string) *Args
+	Run(runner interface{})
+}
+
+// Bind declares the flags and arguments from the tagged fields of the struct
+// that v points to. If the struct has a Run(ctx) method, it becomes the
+// command's runner.
+//
+//	type Build struct {
+//		Minify bool   `flag:"minify" help:"minify output" default:"true"`
+//		Dir    string `arg:"dir"`
+//	}
+//
+// Fields are tagged with flag, arg or args, along with the optional help,
+// short, env and default tags. Fields without a default are required, except
+// for boolean flags, which default to false.
+func Bind(cmd Commander, v interface{}) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		// Panic is okay here because settings commands should be done during
+		// initialization. We want to fail fast for invalid usage.
+		panic(fmt.Sprintf("commander: Bind expects a pointer to a struct, not %T", v))
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		if err := bindField(cmd, field.Tag, rv.Field(i).Addr().Interface()); err != nil {
+			panic(fmt.Sprintf("commander: unable to bind %s.%s. %s", rt.Name(), field.Name, err))
+		}
+	}
+	if runner, ok := v.(runner); ok {
+		cmd.Run(runner.Run)
+	}
+}
+
+// runner is implemented by structs that can be run as a command
+type runner interface {
+	Run(ctx context.Context) error
+}
+
+// typer is implemented by *Flag and *Arg
+type typer interface {
+	String(target *string) *String
+	Bool(target *bool) *Bool
+	Int(target *int) *Int
+	Float64(target *float64) *Float64
+	Duration(target *time.Duration) *Duration
+	Strings(target *[]string) *Strings
+	StringMap(target *map[string]string) *StringMap
+	Custom(target Value) *Custom
+}
+
+func bindField(cmd Commander, tag reflect.StructTag, ptr interface{}) error {
+	defval, hasDefault := tag.Lookup("default")
+	if name, ok := tag.Lookup("flag"); ok {
+		flag := cmd.Flag(name, tag.Get("help"))
+		if short := tag.Get("short"); short != "" {
+			flag.Short(short[0])
+		}
+		if env := tag.Get("env"); env != "" {
+			flag.Env(env)
+		}
+		// Boolean flags are off unless they're passed in
+		if _, ok := ptr.(*bool); ok && !hasDefault {
+			defval, hasDefault = "false", true
+		}
+		return bindValue(flag, ptr, defval, hasDefault)
+	} else if name, ok := tag.Lookup("arg"); ok {
+		return bindValue(cmd.Arg(name), ptr, defval, hasDefault)
+	} else if name, ok := tag.Lookup("args"); ok {
+		return bindArgs(cmd.Args(name), ptr)
+	}
+	return nil
+}
+
+func bindValue(t typer, ptr interface{}, defval string, hasDefault bool) error {
+	switch target := ptr.(type) {
+	case Value:
+		value := t.Custom(target)
+		if hasDefault {
+			value.Default(defval)
+		}
+	case *string:
+		value := t.String(target)
+		if hasDefault {
+			value.Default(defval)
+		}
+	case *bool:
+		value := t.Bool(target)
+		if hasDefault {
+			b, err := strconv.ParseBool(defval)
+			if err != nil {
+				return fmt.Errorf("invalid default %q. %w", defval, err)
+			}
+			value.Default(b)
+		}
+	case *int:
+		value := t.Int(target)
+		if hasDefault {
+			n, err := strconv.Atoi(defval)
+			if err != nil {
+				return fmt.Errorf("invalid default %q. %w", defval, err)
+			}
+			value.Default(n)
+		}
+	case *float64:
+		value := t.Float64(target)
+		if hasDefault {
+			f, err := strconv.ParseFloat(defval, 64)
+			if err != nil {
+				return fmt.Errorf("invalid default %q. %w", defval, err)
+			}
+			value.Default(f)
+		}
+	case *time.Duration:
+		value := t.Duration(target)
+		if hasDefault {
+			d, err := time.ParseDuration(defval)
+			if err != nil {
+				return fmt.Errorf("invalid default %q. %w", defval, err)
+			}
+			value.Default(d)
+		}
+	case *[]string:
+		value := t.Strings(target)
+		if hasDefault {
+			value.Default(strings.Split(defval, ",")...)
+		}
+	case *map[string]string:
+		if hasDefault {
+			return fmt.Errorf("defaults aren't supported for maps")
+		}
+		t.StringMap(target)
+	default:
+		return fmt.Errorf("unsupported type %T", ptr)
+	}
+	return nil
+}
+
+func bindArgs(args *Args, ptr interface{}) error {
+	switch target := ptr.(type) {
+	case *[]string:
+		args.Strings(target)
+	case *[]int:
+		args.Ints(target)
+	case *[]float64:
+		args.Float64s(target)
+	default:
+		return fmt.Errorf("unsupported type %T for args", ptr)
+	}
+	return nil
+}
@@ -33,6 +33,10 @@ func (v *boolValue) verify(displayName string) error {
 	return fmt.Errorf("missing %s", displayName)
 }
 
+func (v *boolValue) optional() bool {
+	return v.inner.defval != nil
+}
+
 func (v *boolValue) Get() interface{} {
 	if v.set {
 		return *v.inner.target
@@ -66,3 +70,34 @@ func (v *boolValue) String() string {
 func (v *boolValue) IsBoolFlag() bool {
 	return true
 }
+
+// negatable returns true if the flag defaults to true, allowing it to be turned
+// off with --no-<name>
+func (v *boolValue) negatable() bool {
+	return v.inner.defval != nil && *v.inner.defval
+}
+
+// negatedValue sets the inverse of the boolean value for --no-<name>
+type negatedValue struct {
+	inner *boolValue
+}
+
+func (v *negatedValue) Get() interface{} {
+	return nil
+}
+
+func (v *negatedValue) Set(val string) error {
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		return err
+	}
+	return v.inner.Set(strconv.FormatBool(!b))
+}
+
+func (v *negatedValue) String() string {
+	return ""
+}
+
+func (v *negatedValue) IsBoolFlag() bool {
+	return true
+}
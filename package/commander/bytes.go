@@ -0,0 +1,160 @@
+package commander
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Bytes parses human-readable byte sizes like 512K, 10MB or 1GiB. Single
+// letter and IEC units (K, KiB) are powers of 1024, while SI units (KB) are
+// powers of 1000.
+type Bytes struct {
+	target *int64
+	defval *int64 // default value
+}
+
+func (v *Bytes) Default(value int64) {
+	v.defval = &value
+}
+
+func (v *Bytes) Optional() {
+	v.defval = new(int64)
+}
+
+type bytesValue struct {
+	inner *Bytes
+	set   bool
+}
+
+func (v *bytesValue) verify(displayName string) error {
+	if v.set {
+		return nil
+	} else if v.inner.defval != nil {
+		*v.inner.target = *v.inner.defval
+		return nil
+	}
+	return fmt.Errorf("missing %s", displayName)
+}
+
+func (v *bytesValue) optional() bool {
+	return v.inner.defval != nil
+}
+
+func (v *bytesValue) Get() interface{} {
+	if v.set {
+		return *v.inner.target
+	} else if v.inner.defval != nil {
+		return *v.inner.defval
+	}
+	return nil
+}
+
+func (v *bytesValue) Set(val string) error {
+	n, err := parseBytes(val)
+	if err != nil {
+		return err
+	}
+	*v.inner.target = n
+	v.set = true
+	return nil
+}
+
+func (v *bytesValue) String() string {
+	if v.inner == nil {
+		return ""
+	} else if v.set {
+		return formatBytes(*v.inner.target)
+	} else if v.inner.defval != nil {
+		return formatBytes(*v.inner.defval)
+	}
+	return ""
+}
+
+// Default value shown in the help output
+func (v *bytesValue) defaultString() (string, bool) {
+	if v.inner == nil || v.inner.defval == nil || *v.inner.defval == 0 {
+		return "", false
+	}
+	return formatBytes(*v.inner.defval), true
+}
+
+type byteUnit struct {
+	name string
+	size int64
+}
+
+// Units from largest to smallest for formatting
+var iecUnits = []byteUnit{
+	{"TiB", 1 << 40},
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+}
+
+var siUnits = []byteUnit{
+	{"TB", 1e12},
+	{"GB", 1e9},
+	{"MB", 1e6},
+	{"KB", 1e3},
+}
+
+var byteSizes = map[string]int64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"ki":  1 << 10,
+	"kib": 1 << 10,
+	"kb":  1e3,
+	"m":   1 << 20,
+	"mi":  1 << 20,
+	"mib": 1 << 20,
+	"mb":  1e6,
+	"g":   1 << 30,
+	"gi":  1 << 30,
+	"gib": 1 << 30,
+	"gb":  1e9,
+	"t":   1 << 40,
+	"ti":  1 << 40,
+	"tib": 1 << 40,
+	"tb":  1e12,
+}
+
+func parseBytes(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	i := strings.IndexFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(value)
+	}
+	number, unit := value[:i], strings.ToLower(strings.TrimSpace(value[i:]))
+	size, ok := byteSizes[unit]
+	if !ok || number == "" {
+		return 0, fmt.Errorf("expected a size like 512K, 10MB or 1GiB")
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("expected a size like 512K, 10MB or 1GiB")
+	}
+	bytes := n * float64(size)
+	if bytes > math.MaxInt64 {
+		return 0, fmt.Errorf("%s is too large", value)
+	}
+	return int64(bytes), nil
+}
+
+// formatBytes formats the size with the largest unit that divides evenly
+func formatBytes(n int64) string {
+	if n != 0 {
+		for _, units := range [][]byteUnit{iecUnits, siUnits} {
+			for _, unit := range units {
+				if n%unit.size == 0 {
+					return strconv.FormatInt(n/unit.size, 10) + unit.name
+				}
+			}
+		}
+	}
+	return strconv.FormatInt(n, 10) + "B"
+}
@@ -0,0 +1,74 @@
+package commander
+
+import (
+	"fmt"
+	"net"
+)
+
+type CIDR struct {
+	target *net.IPNet
+	defval *net.IPNet // default value
+}
+
+func (v *CIDR) Default(value string) {
+	_, ipnet, err := net.ParseCIDR(value)
+	if err != nil {
+		// Panic is okay here because defaults are set during initialization. We
+		// want to fail fast for invalid usage.
+		panic(fmt.Sprintf("commander: invalid default CIDR %q", value))
+	}
+	v.defval = ipnet
+}
+
+func (v *CIDR) Optional() {
+	v.defval = new(net.IPNet)
+}
+
+type cidrValue struct {
+	inner *CIDR
+	set   bool
+}
+
+func (v *cidrValue) verify(displayName string) error {
+	if v.set {
+		return nil
+	} else if v.inner.defval != nil {
+		*v.inner.target = *v.inner.defval
+		return nil
+	}
+	return fmt.Errorf("missing %s", displayName)
+}
+
+func (v *cidrValue) optional() bool {
+	return v.inner.defval != nil
+}
+
+func (v *cidrValue) Get() interface{} {
+	if v.set {
+		return v.inner.target
+	} else if v.inner.defval != nil {
+		return v.inner.defval
+	}
+	return nil
+}
+
+func (v *cidrValue) Set(val string) error {
+	_, ipnet, err := net.ParseCIDR(val)
+	if err != nil {
+		return fmt.Errorf("expected a CIDR like 10.0.0.0/8 or fd00::/8")
+	}
+	*v.inner.target = *ipnet
+	v.set = true
+	return nil
+}
+
+func (v *cidrValue) String() string {
+	if v.inner == nil {
+		return ""
+	} else if v.set {
+		return v.inner.target.String()
+	} else if v.inner.defval != nil && v.inner.defval.IP != nil {
+		return v.inner.defval.String()
+	}
+	return ""
+}
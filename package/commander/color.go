@@ -1,32 +1,91 @@
 package commander
 
 import (
+	"io"
 	"os"
 	"text/template"
+
+	"github.com/mattn/go-isatty"
+)
+
+const (
+	resetCode = "\033[0m"
+	dimCode   = "\033[37m"
 )
 
-var reset = color("\033[0m")
-var dim = color("\033[37m")
-
-var colors = template.FuncMap{
-	"reset":     reset,
-	"bold":      color("\033[1m"),
-	"dim":       dim,
-	"underline": color("\033[4m"),
-	"teal":      color("\033[36m"),
-	"blue":      color("\033[34m"),
-	"yellow":    color("\033[33m"),
-	"red":       color("\033[31m"),
-	"green":     color("\033[32m"),
+var colorCodes = map[string]string{
+	"reset":     resetCode,
+	"bold":      "\033[1m",
+	"dim":       dimCode,
+	"underline": "\033[4m",
+	"teal":      "\033[36m",
+	"blue":      "\033[34m",
+	"yellow":    "\033[33m",
+	"red":       "\033[31m",
+	"green":     "\033[32m",
 }
 
-var nocolor = os.Getenv("NO_COLOR") != ""
+// colors are used to parse templates. They're replaced with colorFuncs when
+// the template is executed.
+var colors = colorFuncs(true)
 
-func color(code string) func() string {
-	return func() string {
-		if nocolor {
-			return ""
+// colorFuncs returns the template functions for the colors
+func colorFuncs(enabled bool) template.FuncMap {
+	funcs := template.FuncMap{}
+	for name, code := range colorCodes {
+		code := code
+		funcs[name] = func() string {
+			if !enabled {
+				return ""
+			}
+			return code
 		}
-		return code
 	}
+	return funcs
+}
+
+// useColor decides whether to color the output written to w. An explicit
+// setting wins, then NO_COLOR disables color. Files are only colored when
+// they're a terminal.
+func (c *config) useColor(w io.Writer) bool {
+	if c.color != nil {
+		return *c.color
+	} else if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if f, ok := w.(*os.File); ok {
+		return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+	}
+	return true
+}
+
+func (c *config) paint(code string) string {
+	if !c.colored {
+		return ""
+	}
+	return code
+}
+
+func (c *config) dim() string {
+	return c.paint(dimCode)
+}
+
+func (c *config) reset() string {
+	return c.paint(resetCode)
+}
+
+// usageTemplate returns the help template for the current output. The template
+// without colors is cloned the first time it's needed.
+func (c *config) usageTemplate() (*template.Template, error) {
+	if c.colored {
+		return c.template, nil
+	} else if c.plain != nil {
+		return c.plain, nil
+	}
+	plain, err := c.template.Clone()
+	if err != nil {
+		return nil, err
+	}
+	c.plain = plain.Funcs(colorFuncs(false))
+	return c.plain, nil
 }
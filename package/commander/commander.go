@@ -1,6 +1,7 @@
 package commander
 
 import (
+	"bufio"
 	"context"
 	_ "embed"
 	"errors"
@@ -9,7 +10,11 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"reflect"
+	"sort"
+	"strings"
 	"text/template"
+	"time"
 
 	"github.com/livebud/bud/internal/sig"
 )
@@ -24,7 +29,12 @@ func Usage() error {
 }
 
 func New(name string) *CLI {
-	config := &config{"", os.Stdout, defaultUsage, []os.Signal{os.Interrupt}}
+	config := &config{
+		writer:    os.Stdout,
+		errWriter: os.Stderr,
+		template:  defaultUsage,
+		signals:   []os.Signal{os.Interrupt},
+	}
 	return &CLI{newCommand(config, name, ""), config}
 }
 
@@ -32,25 +42,40 @@ type Command struct {
 	config *config
 	fset   *flag.FlagSet
 	run    func(ctx context.Context) error
+	parent *Command
 
 	// state for the template
-	name     string
-	usage    string
-	commands map[string]*Command
-	flags    []*Flag
-	args     []*Arg
-	restArgs *Args // optional, collects the rest of the args
+	name         string
+	usage        string
+	commands     map[string]*Command
+	flags        []*Flag
+	args         []*Arg
+	restArgs     *Args // optional, collects the rest of the args
+	interspersed bool  // allow flags after positional arguments
+	group        string
+	description  string
+	examples     []*Example
+	together     [][]string // groups of flags that must be used together
+	deprecated   string
+	fallback     *Command // default subcommand
+}
+
+// Example usage of a command that's shown in the help output
+type Example struct {
+	Command string
+	Usage   string
 }
 
 func newCommand(config *config, name, usage string) *Command {
 	fset := flag.NewFlagSet(name, flag.ContinueOnError)
 	fset.SetOutput(ioutil.Discard)
 	return &Command{
-		config:   config,
-		fset:     fset,
-		name:     name,
-		usage:    usage,
-		commands: map[string]*Command{},
+		config:       config,
+		fset:         fset,
+		name:         name,
+		usage:        usage,
+		commands:     map[string]*Command{},
+		interspersed: true,
 	}
 }
 
@@ -60,10 +85,26 @@ type CLI struct {
 }
 
 type config struct {
-	version  string
-	writer   io.Writer
-	template *template.Template
-	signals  []os.Signal
+	version   string
+	commit    string
+	built     string
+	writer    io.Writer
+	errWriter io.Writer // warnings and errors
+	template  *template.Template
+	plain     *template.Template // template without colors, cloned on demand
+	signals   []os.Signal
+	grace     time.Duration // shutdown grace period
+	groups    []string      // command groups in the order they were declared
+	help      func(w io.Writer, cmd *Command) error
+	prompt    func(question string, secret bool) (string, error)
+	external  bool  // resolve unknown subcommands to executables
+	color     *bool // nil detects whether to use color
+	colored   bool  // whether the current help output is colored
+	width     int   // zero detects the terminal width
+	columns   int   // width of the current help output, zero doesn't wrap
+	loader    Loader
+	values    map[string]interface{} // values loaded from the loader
+	providers map[reflect.Type]reflect.Value
 }
 
 func (c *CLI) Writer(writer io.Writer) *CLI {
@@ -71,23 +112,136 @@ func (c *CLI) Writer(writer io.Writer) *CLI {
 	return c
 }
 
+// ErrWriter sets where warnings and errors are written. Defaults to stderr.
+func (c *CLI) ErrWriter(writer io.Writer) *CLI {
+	c.config.errWriter = writer
+	return c
+}
+
+// Version adds --version and -V flags that print the version
 func (c *CLI) Version(version string) *CLI {
 	c.config.version = version
 	return c
 }
 
+// Commit is shown alongside the version
+func (c *CLI) Commit(commit string) *CLI {
+	c.config.commit = commit
+	return c
+}
+
+// Built is the build date shown alongside the version
+func (c *CLI) Built(date string) *CLI {
+	c.config.built = date
+	return c
+}
+
 func (c *CLI) Template(template *template.Template) {
 	c.config.template = template
+	c.config.plain = nil
+}
+
+// HelpTemplate parses the text as the help template. The template has access
+// to the same color functions as the default template.
+func (c *CLI) HelpTemplate(text string) *CLI {
+	c.config.template = template.Must(template.New("usage").Funcs(colors).Parse(text))
+	c.config.plain = nil
+	return c
+}
+
+// External resolves unknown subcommands to executables in $PATH named after
+// the command, like git and kubectl plugins. For example, "bud deploy" runs
+// "bud-deploy" when there's no deploy subcommand.
+func (c *CLI) External(enable bool) *CLI {
+	c.config.external = enable
+	return c
+}
+
+// Color forces colored help output on or off. By default, color is disabled
+// when $NO_COLOR is set or when writing to a file that's not a terminal.
+func (c *CLI) Color(enable bool) *CLI {
+	c.config.color = &enable
+	return c
+}
+
+// Width wraps the help output to the given number of columns instead of the
+// width of the terminal
+func (c *CLI) Width(columns int) *CLI {
+	c.config.width = columns
+	return c
+}
+
+// Prompt asks for required flags and args that are missing instead of failing
+// when stdin is a terminal
+func (c *CLI) Prompt(enable bool) *CLI {
+	c.config.prompt = nil
+	if enable && isTerminal() {
+		c.config.prompt = promptTerminal
+	}
+	return c
+}
+
+// PromptFunc overrides how missing flags and args are asked for. Secret values
+// should mask their input.
+func (c *CLI) PromptFunc(prompt func(question string, secret bool) (string, error)) *CLI {
+	c.config.prompt = prompt
+	return c
+}
+
+// HelpFunc overrides how help is rendered for every command
+func (c *CLI) HelpFunc(help func(w io.Writer, cmd *Command) error) *CLI {
+	c.config.help = help
+	return c
+}
+
+// ConfigFile loads flag values from a JSON file before falling back to
+// defaults. A missing file is ignored.
+func (c *CLI) ConfigFile(path string) *CLI {
+	return c.Loader(configFile(path))
 }
 
-func (c *CLI) Trap(signals ...os.Signal) {
+// Loader loads flag values before falling back to defaults. Flags passed in
+// take precedence over the environment, then the loader, then the default.
+func (c *CLI) Loader(loader Loader) *CLI {
+	c.config.loader = loader
+	return c
+}
+
+// Signals that cancel the context passed to Run. Defaults to os.Interrupt.
+// Passing no signals disables signal handling.
+func (c *CLI) Signals(signals ...os.Signal) *CLI {
 	c.config.signals = signals
+	return c
+}
+
+// Trap is the same as Signals
+func (c *CLI) Trap(signals ...os.Signal) *CLI {
+	return c.Signals(signals...)
+}
+
+// Grace enables a two-stage shutdown. The first signal cancels the context,
+// then the command has the grace period to return. If it takes longer or a
+// second signal is received, Parse returns ErrForceQuit without waiting.
+func (c *CLI) Grace(period time.Duration) *CLI {
+	c.config.grace = period
+	return c
 }
 
 func (c *CLI) Parse(ctx context.Context, args []string) error {
+	if c.config.loader != nil {
+		values, err := c.config.loader.Load()
+		if err != nil {
+			return err
+		}
+		c.config.values = values
+	}
+	c.root.addVersionFlag()
+	if c.config.grace > 0 && len(c.config.signals) > 0 {
+		return c.parseGracefully(ctx, args)
+	}
 	ctx, cancel := sig.Trap(ctx, c.config.signals...)
 	defer cancel()
-	if err := c.root.parse(ctx, args); err != nil {
+	if err := c.root.execute(ctx, args); err != nil {
 		return err
 	}
 	// Give the caller a chance to handle context cancellations and therefore
@@ -99,6 +253,16 @@ func (c *CLI) Command(name, usage string) *Command {
 	return c.root.Command(name, usage)
 }
 
+// Commands returns the top-level commands sorted by name
+func (c *CLI) Commands() []*Command {
+	return c.root.Commands()
+}
+
+// Flags returns the top-level flags in the order they were declared
+func (c *CLI) Flags() []*Flag {
+	return c.root.Flags()
+}
+
 func (c *CLI) Flag(name, usage string) *Flag {
 	return c.root.Flag(name, usage)
 }
@@ -111,17 +275,74 @@ func (c *CLI) Args(name string) *Args {
 	return c.root.Args(name)
 }
 
-func (c *CLI) Run(runner func(ctx context.Context) error) {
+func (c *CLI) Run(runner interface{}) {
 	c.root.Run(runner)
 }
 
+func (c *CLI) RequireTogether(names ...string) *CLI {
+	c.root.RequireTogether(names...)
+	return c
+}
+
+func (c *CLI) Description(description string) *CLI {
+	c.root.Description(description)
+	return c
+}
+
+func (c *CLI) Example(command, usage string) *CLI {
+	c.root.Example(command, usage)
+	return c
+}
+
+func (c *CLI) Interspersed(interspersed bool) *CLI {
+	c.root.Interspersed(interspersed)
+	return c
+}
+
 func (c *Command) printUsage() error {
-	usage, err := generateUsage(c.config.template, c)
-	if err != nil {
+	if c.config.help != nil {
+		return c.config.help(c.config.writer, c)
+	}
+	return RenderHelp(c.config.writer, c)
+}
+
+// RenderHelp renders the command's help using the help template. This is
+// useful within a HelpFunc to extend the existing help output.
+func RenderHelp(w io.Writer, cmd *Command) error {
+	cmd.config.colored = cmd.config.useColor(w)
+	cmd.config.columns = cmd.config.useWidth(w)
+	bw := bufio.NewWriter(w)
+	if err := generateUsage(bw, cmd); err != nil {
 		return err
 	}
-	fmt.Fprint(c.config.writer, usage)
-	return nil
+	return bw.Flush()
+}
+
+// Name of the command
+func (c *Command) Name() string {
+	return c.name
+}
+
+// Usage describes the command
+func (c *Command) Usage() string {
+	return c.usage
+}
+
+// Commands returns the subcommands sorted by name
+func (c *Command) Commands() []*Command {
+	commands := make([]*Command, 0, len(c.commands))
+	for _, cmd := range c.commands {
+		commands = append(commands, cmd)
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].name < commands[j].name
+	})
+	return commands
+}
+
+// Flags returns the command's flags in the order they were declared
+func (c *Command) Flags() []*Flag {
+	return append([]*Flag(nil), c.flags...)
 }
 
 type value interface {
@@ -129,33 +350,112 @@ type value interface {
 	verify(displayName string) error
 }
 
+// defaulter is an optional interface for values that show their default value
+// in the help output
+type defaulter interface {
+	defaultString() (string, bool)
+}
+
+// chooser is an optional interface for values that only accept a fixed set of
+// choices
+type chooser interface {
+	choices() []string
+}
+
+// optioner is an optional interface for values that may be omitted, either
+// because they have a default or because they're explicitly optional
+type optioner interface {
+	optional() bool
+}
+
 func (c *Command) parse(ctx context.Context, args []string) error {
+	if c.deprecated != "" {
+		fmt.Fprintf(c.config.errWriter, "warning: %s is deprecated, %s\n", c.name, c.deprecated)
+	}
 	// Set flags
 	for _, flag := range c.flags {
-		c.fset.Var(flag.value, flag.name, flag.usage)
+		value := flag.flagValue()
+		c.fset.Var(value, flag.name, flag.usage)
 		if flag.short != 0 {
-			c.fset.Var(flag.value, string(flag.short), flag.usage)
+			c.fset.Var(value, string(flag.short), flag.usage)
 		}
 	}
+	// Allow boolean flags that default to true to be turned off
+	for _, flag := range c.flags {
+		negated, ok := flag.negation()
+		if !ok || c.fset.Lookup("no-"+flag.name) != nil {
+			continue
+		}
+		c.fset.Var(negated, "no-"+flag.name, flag.usage)
+	}
 	// Parse the arguments
-	if err := c.fset.Parse(args); err != nil {
+	if err := c.parseFlags(args); err != nil {
 		// Print usage if the developer used -h or --help
 		if errors.Is(err, flag.ErrHelp) {
 			return c.printUsage()
 		}
 		return err
 	}
+	// Check if the first argument is a subcommand. Arguments after a "--"
+	// terminator are always positional.
+	restArgs := c.fset.Args()
+	sub, isSub := c.commands[c.fset.Arg(0)]
+	isSub = isSub && !terminated(args, restArgs)
+	// Otherwise check if it's an external subcommand
+	external := ""
+	if !isSub && c.config.external && !terminated(args, restArgs) {
+		external = c.lookupExternal(c.fset.Arg(0))
+	}
+	// Fall back to the default subcommand when this command doesn't take the
+	// positional arguments itself
+	fallback := !isSub && external == "" && c.fallback != nil &&
+		(len(restArgs) == 0 || (len(c.args) == 0 && c.restArgs == nil && !terminated(args, restArgs)))
+	// Continue parsing flags that come after positional arguments
+	if !isSub && external == "" && !fallback && c.interspersed && !terminated(args, restArgs) {
+		var err error
+		restArgs, err = c.parseInterspersed(restArgs)
+		if err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return c.printUsage()
+			}
+			return err
+		}
+	}
+	// Print the version if the developer used -V or --version
+	if c.showVersion() {
+		return printVersion(c.config.writer, c.name, c.config)
+	}
+	// Load the remaining flags from the environment
+	supplied := suppliedFlags(c.fset, c.flags)
+	if err := loadEnv(c.flags, supplied); err != nil {
+		return err
+	}
+	// Load the remaining flags from the config
+	if err := loadConfig(c.flags, c.section(), supplied); err != nil {
+		return err
+	}
+	// Prompt for the required flags that are still missing
+	if err := promptFlags(c.config.prompt, c.flags, supplied); err != nil {
+		return err
+	}
+	warnDeprecated(c.config.errWriter, c.flags, supplied)
+	// Verify that flags that must be used together were supplied together
+	if err := verifyTogether(c.together, c.flags, supplied); err != nil {
+		return err
+	}
 	// Verify that all the flags have been set or have default values
 	if err := verifyFlags(c.flags); err != nil {
 		return err
 	}
-	// Check if the first argument is a subcommand
-	if sub, ok := c.commands[c.fset.Arg(0)]; ok {
-		return sub.parse(ctx, c.fset.Args()[1:])
+	if isSub {
+		return sub.parse(ctx, restArgs[1:])
+	} else if fallback {
+		return c.fallback.parse(ctx, restArgs)
+	} else if external != "" {
+		return c.runExternal(ctx, external, restArgs[1:])
 	}
 	// Handle the remaining arguments
 	numArgs := len(c.args)
-	restArgs := c.fset.Args()
 loop:
 	for i, arg := range restArgs {
 		if i >= numArgs {
@@ -164,11 +464,19 @@ loop:
 			}
 			// Loop over the remaining unset args, appending them to restArgs
 			for _, arg := range restArgs[i:] {
-				c.restArgs.value.Set(arg)
+				if err := c.restArgs.set(arg); err != nil {
+					return err
+				}
 			}
 			break loop
 		}
-		if err := c.args[i].value.Set(arg); err != nil {
+		if err := c.args[i].set(arg); err != nil {
+			return err
+		}
+	}
+	// Prompt for the required args that are still missing
+	if len(restArgs) < numArgs {
+		if err := promptArgs(c.config.prompt, c.args[len(restArgs):]); err != nil {
 			return err
 		}
 	}
@@ -176,25 +484,138 @@ loop:
 	if err := verifyArgs(c.args); err != nil {
 		return err
 	}
+	if c.restArgs != nil {
+		if err := c.restArgs.verify(); err != nil {
+			return err
+		}
+	}
 	// Print usage if there's no run function defined
 	if c.run == nil {
 		if len(restArgs) == 0 {
 			return c.printUsage()
 		}
-		return fmt.Errorf("unexpected %s", c.fset.Arg(0))
+		return fmt.Errorf("unexpected %s", restArgs[0])
 	}
 	if err := c.run(ctx); err != nil {
 		// Support explicitly printing usage
 		if errors.Is(err, flag.ErrHelp) {
 			return c.printUsage()
 		}
-		return err
+		return &runError{err}
+	}
+	return nil
+}
+
+func (c *Command) parseFlags(args []string) error {
+	if err := c.fset.Parse(expandShorts(c.flags, args)); err != nil {
+		return c.unknownFlag(err)
+	}
+	return nil
+}
+
+// parseInterspersed parses the flags that are mixed in with the positional
+// arguments, returning the positional arguments. Parsing stops at a "--"
+// terminator.
+func (c *Command) parseInterspersed(args []string) (positional []string, err error) {
+	for len(args) > 0 {
+		positional = append(positional, args[0])
+		if err := c.parseFlags(args[1:]); err != nil {
+			return nil, err
+		}
+		rest := c.fset.Args()
+		if terminated(args[1:], rest) {
+			return append(positional, rest...), nil
+		}
+		args = rest
+	}
+	return positional, nil
+}
+
+// RequireTogether requires that if any of the named flags are supplied, all of
+// them are supplied
+func (c *Command) RequireTogether(names ...string) *Command {
+	for _, name := range names {
+		if c.lookupFlag(name) == nil {
+			// Panic is okay here because settings commands should be done during
+			// initialization. We want to fail fast for invalid usage.
+			panic(fmt.Sprintf("commander: unknown flag %q in RequireTogether", name))
+		}
+	}
+	c.together = append(c.together, names)
+	return c
+}
+
+func (c *Command) lookupFlag(name string) *Flag {
+	for _, flag := range c.flags {
+		if flag.name == name {
+			return flag
+		}
 	}
 	return nil
 }
 
-func (c *Command) Run(runner func(ctx context.Context) error) {
-	c.run = runner
+// Deprecated warns when the command is used and annotates the help output
+func (c *Command) Deprecated(message string) *Command {
+	c.deprecated = message
+	return c
+}
+
+// Default runs this command when the parent command is called without a
+// subcommand. Positional arguments are passed through unless the parent takes
+// arguments itself.
+func (c *Command) Default() *Command {
+	if c.parent == nil {
+		// Panic is okay here because settings commands should be done during
+		// initialization. We want to fail fast for invalid usage.
+		panic("commander: the root command can't be a default subcommand")
+	}
+	c.parent.fallback = c
+	return c
+}
+
+// Description adds a longer explanation of the command to its help output.
+// Paragraphs are separated by blank lines.
+func (c *Command) Description(description string) *Command {
+	c.description = strings.TrimSpace(description)
+	return c
+}
+
+// Example adds an example to the command's help output
+func (c *Command) Example(command, usage string) *Command {
+	c.examples = append(c.examples, &Example{command, usage})
+	return c
+}
+
+// Group the command under a heading in the parent's help output
+func (c *Command) Group(name string) *Command {
+	c.group = name
+	for _, group := range c.config.groups {
+		if group == name {
+			return c
+		}
+	}
+	c.config.groups = append(c.config.groups, name)
+	return c
+}
+
+// Interspersed allows flags to come after positional arguments. This is enabled
+// by default. Disable it for commands that pass their arguments through
+// verbatim.
+func (c *Command) Interspersed(interspersed bool) *Command {
+	c.interspersed = interspersed
+	return c
+}
+
+// terminated returns true if the flag parser stopped at a "--" terminator
+func terminated(args, rest []string) bool {
+	i := len(args) - len(rest) - 1
+	return i >= 0 && args[i] == "--"
+}
+
+// Run the command. The runner is either a func(ctx context.Context) error or a
+// function that returns an error and takes dependencies passed to Provide.
+func (c *Command) Run(runner interface{}) {
+	c.run = c.config.runner(runner)
 }
 
 func (c *Command) Command(name, usage string) *Command {
@@ -202,6 +623,7 @@ func (c *Command) Command(name, usage string) *Command {
 		return c.commands[name]
 	}
 	cmd := newCommand(c.config, name, usage)
+	cmd.parent = c
 	c.commands[name] = cmd
 	return cmd
 }
@@ -5,52 +5,28 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/livebud/bud/package/commander"
+	"github.com/livebud/bud/package/commander/commandertest"
 	"github.com/matryer/is"
-	"github.com/matthewmueller/diff"
 )
 
 func isEqual(t testing.TB, actual, expected string) {
 	t.Helper()
-	equal(t, expected, replaceEscapeCodes(actual))
-}
-
-func replaceEscapeCodes(str string) string {
-	// TODO: this is needlessly slow
-	str = strings.ReplaceAll(str, "\033[0m", `{reset}`)
-	str = strings.ReplaceAll(str, "\033[1m", `{bold}`)
-	str = strings.ReplaceAll(str, "\033[37m", `{dim}`)
-	str = strings.ReplaceAll(str, "\033[4m", `{underline}`)
-	str = strings.ReplaceAll(str, "\033[36m", `{teal}`)
-	str = strings.ReplaceAll(str, "\033[34m", `{blue}`)
-	str = strings.ReplaceAll(str, "\033[33m", `{yellow}`)
-	str = strings.ReplaceAll(str, "\033[31m", `{red}`)
-	str = strings.ReplaceAll(str, "\033[32m", `{green}`)
-	return str
-}
-
-// is checks if expect and actual are equal
-func equal(t testing.TB, expect, actual string) {
-	t.Helper()
-	if expect == actual {
-		return
-	}
-	var b bytes.Buffer
-	b.WriteString("\n\x1b[4mExpect\x1b[0m:\n")
-	b.WriteString(expect)
-	b.WriteString("\n\n")
-	b.WriteString("\x1b[4mActual\x1b[0m: \n")
-	b.WriteString(actual)
-	b.WriteString("\n\n")
-	b.WriteString("\x1b[4mDifference\x1b[0m: \n")
-	b.WriteString(diff.String(expect, actual))
-	b.WriteString("\n")
-	t.Fatal(b.String())
+	commandertest.Equal(t, actual, expected)
 }
 
 func TestHelp(t *testing.T) {
@@ -78,11 +54,161 @@ func TestHelpArgs(t *testing.T) {
 	is.NoErr(err)
 	isEqual(t, actual.String(), `
   {bold}Usage:{reset}
-    cp {dim}<src>{reset} {dim}<dst>{reset}
+    cp {dim}<src>{reset} {dim}[dst]{reset}
+
+`)
+}
+
+func TestHelpArgsUsage(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cmd := commander.New("cp").Writer(actual)
+	cmd.Arg("dst").Usage("destination directory").String(nil)
+	cmd.Args("src").Usage("files to copy").Strings(nil)
+	ctx := context.Background()
+	err := cmd.Parse(ctx, []string{"-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    cp {dim}<dst>{reset} {dim}[src...]{reset}
+
+  {bold}Args:{reset}
+    dst     {dim}destination directory{reset}
+    src...  {dim}files to copy{reset}
+
+`)
+}
+
+func TestHelpArgsOptional(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cmd := commander.New("bud").Writer(actual)
+	cmd.Arg("dir").String(nil).Optional()
+	cmd.Command("run", "run your application")
+	ctx := context.Background()
+	err := cmd.Parse(ctx, []string{"-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    bud {dim}[command|dir]{reset}
+
+  {bold}Commands:{reset}
+    run  {dim}run your application{reset}
+
+`)
+}
+
+func TestArgOptional(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var src, dst string
+	cli.Arg("src").String(&src)
+	cli.Arg("dst").String(&dst).Optional()
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"a.txt"})
+	is.NoErr(err)
+	is.Equal(called, 1)
+	is.Equal(src, "a.txt")
+	is.Equal(dst, "")
+}
+
+func TestHelpArgsArity(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cmd := commander.New("cp").Writer(actual)
+	cmd.Args("src").Min(1).Strings(nil)
+	cmd.Arg("dst").String(nil)
+	ctx := context.Background()
+	err := cmd.Parse(ctx, []string{"-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    cp {dim}<dst>{reset} {dim}<src...>{reset}
+
+`)
+}
+
+func TestHelpArgsVariadic(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cmd := commander.New("rm").Writer(actual)
+	cmd.Args("files").Strings(nil)
+	ctx := context.Background()
+	err := cmd.Parse(ctx, []string{"-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    rm {dim}[files...]{reset}
 
 `)
 }
 
+func TestArgsMin(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var files []string
+	cli.Args("files").Min(2).Strings(&files)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.Equal(err.Error(), "missing files")
+}
+
+func TestArgsMinNotMet(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var files []string
+	cli.Args("files").Min(2).Strings(&files)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"a.txt"})
+	is.Equal(err.Error(), "expected at least 2 files but got 1")
+}
+
+func TestArgsMax(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var files []string
+	cli.Args("files").Min(1).Max(2).Strings(&files)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"a.txt", "b.txt", "c.txt"})
+	is.Equal(err.Error(), "expected at most 2 files but got 3")
+}
+
+func TestArgsWithinArity(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var files []string
+	cli.Args("files").Min(1).Max(2).Strings(&files)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"a.txt", "b.txt"})
+	is.NoErr(err)
+	is.Equal(called, 1)
+	is.Equal(files, []string{"a.txt", "b.txt"})
+}
+
 func TestInvalid(t *testing.T) {
 	is := is.New(t)
 	actual := new(bytes.Buffer)
@@ -211,7 +337,7 @@ func TestFlagIntRequired(t *testing.T) {
 	err := cli.Parse(ctx, []string{})
 	is.Equal(err.Error(), "missing --flag")
 }
-func TestFlagBool(t *testing.T) {
+func TestFlagFloat64(t *testing.T) {
 	is := is.New(t)
 	actual := new(bytes.Buffer)
 	called := 0
@@ -220,16 +346,17 @@ func TestFlagBool(t *testing.T) {
 		called++
 		return nil
 	})
-	var flag bool
-	cli.Flag("flag", "cli flag").Bool(&flag)
+	var flag float64
+	cli.Flag("flag", "cli flag").Float64(&flag)
 	ctx := context.Background()
-	err := cli.Parse(ctx, []string{"--flag"})
+	err := cli.Parse(ctx, []string{"--flag", "0.25"})
 	is.NoErr(err)
 	is.Equal(1, called)
-	is.Equal(flag, true)
+	is.Equal(flag, 0.25)
 	isEqual(t, actual.String(), ``)
 }
-func TestFlagBoolDefault(t *testing.T) {
+
+func TestFlagFloat64Default(t *testing.T) {
 	is := is.New(t)
 	actual := new(bytes.Buffer)
 	called := 0
@@ -238,17 +365,17 @@ func TestFlagBoolDefault(t *testing.T) {
 		called++
 		return nil
 	})
-	var flag bool
-	cli.Flag("flag", "cli flag").Bool(&flag).Default(true)
+	var flag float64
+	cli.Flag("flag", "cli flag").Float64(&flag).Default(1.5)
 	ctx := context.Background()
 	err := cli.Parse(ctx, []string{})
 	is.NoErr(err)
 	is.Equal(1, called)
-	is.Equal(flag, true)
+	is.Equal(flag, 1.5)
 	isEqual(t, actual.String(), ``)
 }
 
-func TestFlagBoolRequired(t *testing.T) {
+func TestFlagFloat64Required(t *testing.T) {
 	is := is.New(t)
 	actual := new(bytes.Buffer)
 	called := 0
@@ -257,14 +384,14 @@ func TestFlagBoolRequired(t *testing.T) {
 		called++
 		return nil
 	})
-	var flag bool
-	cli.Flag("flag", "cli flag").Bool(&flag)
+	var flag float64
+	cli.Flag("flag", "cli flag").Float64(&flag)
 	ctx := context.Background()
 	err := cli.Parse(ctx, []string{})
 	is.Equal(err.Error(), "missing --flag")
 }
 
-func TestFlagStrings(t *testing.T) {
+func TestArgFloat64(t *testing.T) {
 	is := is.New(t)
 	actual := new(bytes.Buffer)
 	called := 0
@@ -273,17 +400,16 @@ func TestFlagStrings(t *testing.T) {
 		called++
 		return nil
 	})
-	var flags []string
-	cli.Flag("flag", "cli flag").Strings(&flags)
+	var arg float64
+	cli.Arg("arg").Float64(&arg)
 	ctx := context.Background()
-	err := cli.Parse(ctx, []string{"--flag", "1", "--flag", "2"})
+	err := cli.Parse(ctx, []string{"3.5"})
 	is.NoErr(err)
-	is.Equal(len(flags), 2)
-	is.Equal(flags[0], "1")
-	is.Equal(flags[1], "2")
+	is.Equal(1, called)
+	is.Equal(arg, 3.5)
 }
 
-func TestFlagStringsRequired(t *testing.T) {
+func TestArgInt(t *testing.T) {
 	is := is.New(t)
 	actual := new(bytes.Buffer)
 	called := 0
@@ -292,33 +418,31 @@ func TestFlagStringsRequired(t *testing.T) {
 		called++
 		return nil
 	})
-	var flags []string
-	cli.Flag("flag", "cli flag").Strings(&flags)
+	var arg int
+	cli.Arg("arg").Int(&arg)
 	ctx := context.Background()
-	err := cli.Parse(ctx, []string{})
-	is.Equal(err.Error(), "missing --flag")
+	err := cli.Parse(ctx, []string{"10"})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(arg, 10)
 }
 
-func TestFlagStringsDefault(t *testing.T) {
+func TestArgIntInvalid(t *testing.T) {
 	is := is.New(t)
 	actual := new(bytes.Buffer)
-	called := 0
 	cli := commander.New("cli").Writer(actual)
 	cli.Run(func(ctx context.Context) error {
-		called++
 		return nil
 	})
-	var flags []string
-	cli.Flag("flag", "cli flag").Strings(&flags).Default("a", "b")
+	var arg int
+	cli.Arg("count").Int(&arg)
 	ctx := context.Background()
-	err := cli.Parse(ctx, []string{})
-	is.NoErr(err)
-	is.Equal(len(flags), 2)
-	is.Equal(flags[0], "a")
-	is.Equal(flags[1], "b")
+	err := cli.Parse(ctx, []string{"ten"})
+	is.True(err != nil)
+	is.True(strings.HasPrefix(err.Error(), `invalid value "ten" for count:`))
 }
 
-func TestFlagStringMap(t *testing.T) {
+func TestArgBool(t *testing.T) {
 	is := is.New(t)
 	actual := new(bytes.Buffer)
 	called := 0
@@ -327,55 +451,45 @@ func TestFlagStringMap(t *testing.T) {
 		called++
 		return nil
 	})
-	var flags map[string]string
-	cli.Flag("flag", "cli flag").StringMap(&flags)
+	var arg bool
+	cli.Arg("arg").Bool(&arg)
 	ctx := context.Background()
-	err := cli.Parse(ctx, []string{"--flag", "a:1 + 1", "--flag", "b:2"})
+	err := cli.Parse(ctx, []string{"true"})
 	is.NoErr(err)
-	is.Equal(len(flags), 2)
-	is.Equal(flags["a"], "1 + 1")
-	is.Equal(flags["b"], "2")
+	is.Equal(1, called)
+	is.Equal(arg, true)
 }
 
-func TestFlagStringMapRequired(t *testing.T) {
+func TestArgBoolDefault(t *testing.T) {
 	is := is.New(t)
 	actual := new(bytes.Buffer)
-	called := 0
 	cli := commander.New("cli").Writer(actual)
 	cli.Run(func(ctx context.Context) error {
-		called++
 		return nil
 	})
-	var flags map[string]string
-	cli.Flag("flag", "cli flag").StringMap(&flags)
+	var arg bool
+	cli.Arg("arg").Bool(&arg).Default(true)
 	ctx := context.Background()
 	err := cli.Parse(ctx, []string{})
-	is.Equal(err.Error(), "missing --flag")
+	is.NoErr(err)
+	is.Equal(arg, true)
 }
 
-func TestFlagStringMapDefault(t *testing.T) {
+func TestArgBoolRequired(t *testing.T) {
 	is := is.New(t)
 	actual := new(bytes.Buffer)
-	called := 0
 	cli := commander.New("cli").Writer(actual)
 	cli.Run(func(ctx context.Context) error {
-		called++
 		return nil
 	})
-	var flags map[string]string
-	cli.Flag("flag", "cli flag").StringMap(&flags).Default(map[string]string{
-		"a": "1",
-		"b": "2",
-	})
+	var arg bool
+	cli.Arg("arg").Bool(&arg)
 	ctx := context.Background()
 	err := cli.Parse(ctx, []string{})
-	is.NoErr(err)
-	is.Equal(len(flags), 2)
-	is.Equal(flags["a"], "1")
-	is.Equal(flags["b"], "2")
+	is.Equal(err.Error(), "missing arg")
 }
 
-func TestArgStringMap(t *testing.T) {
+func TestFlagDuration(t *testing.T) {
 	is := is.New(t)
 	actual := new(bytes.Buffer)
 	called := 0
@@ -384,17 +498,17 @@ func TestArgStringMap(t *testing.T) {
 		called++
 		return nil
 	})
-	var args map[string]string
-	cli.Arg("arg").StringMap(&args)
-	// Can have only one arg
+	var flag time.Duration
+	cli.Flag("flag", "cli flag").Duration(&flag)
 	ctx := context.Background()
-	err := cli.Parse(ctx, []string{"a:1 + 1"})
+	err := cli.Parse(ctx, []string{"--flag", "5m"})
 	is.NoErr(err)
-	is.Equal(len(args), 1)
-	is.Equal(args["a"], "1 + 1")
+	is.Equal(1, called)
+	is.Equal(flag, 5*time.Minute)
+	isEqual(t, actual.String(), ``)
 }
 
-func TestArgStringMapRequired(t *testing.T) {
+func TestFlagDurationDefault(t *testing.T) {
 	is := is.New(t)
 	actual := new(bytes.Buffer)
 	called := 0
@@ -403,14 +517,17 @@ func TestArgStringMapRequired(t *testing.T) {
 		called++
 		return nil
 	})
-	var args map[string]string
-	cli.Arg("arg").StringMap(&args)
+	var flag time.Duration
+	cli.Flag("flag", "cli flag").Duration(&flag).Default(30 * time.Second)
 	ctx := context.Background()
 	err := cli.Parse(ctx, []string{})
-	is.Equal(err.Error(), "missing arg")
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(flag, 30*time.Second)
+	isEqual(t, actual.String(), ``)
 }
 
-func TestArgStringMapDefault(t *testing.T) {
+func TestFlagDurationRequired(t *testing.T) {
 	is := is.New(t)
 	actual := new(bytes.Buffer)
 	called := 0
@@ -419,141 +536,203 @@ func TestArgStringMapDefault(t *testing.T) {
 		called++
 		return nil
 	})
-	var args map[string]string
-	cli.Arg("arg").StringMap(&args).Default(map[string]string{
-		"a": "1",
-		"b": "2",
-	})
+	var flag time.Duration
+	cli.Flag("flag", "cli flag").Duration(&flag)
 	ctx := context.Background()
 	err := cli.Parse(ctx, []string{})
-	is.NoErr(err)
-	is.Equal(len(args), 2)
-	is.Equal(args["a"], "1")
-	is.Equal(args["b"], "2")
+	is.Equal(err.Error(), "missing --flag")
 }
 
-func TestSub(t *testing.T) {
+func TestFlagDurationInvalid(t *testing.T) {
 	is := is.New(t)
 	actual := new(bytes.Buffer)
-	cli := commander.New("bud").Writer(actual)
-	var trace []string
+	cli := commander.New("cli").Writer(actual)
+	var flag time.Duration
+	cli.Flag("flag", "cli flag").Duration(&flag)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--flag", "5"})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "missing unit in duration"))
+}
+
+func TestArgDuration(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
 	cli.Run(func(ctx context.Context) error {
-		trace = append(trace, "bud")
 		return nil
 	})
-	{
-		sub := cli.Command("run", "run your application")
-		sub.Run(func(ctx context.Context) error {
-			trace = append(trace, "run")
-			return nil
-		})
-	}
-	{
-		sub := cli.Command("build", "build your application")
-		sub.Run(func(ctx context.Context) error {
-			trace = append(trace, "build")
-			return nil
-		})
-	}
+	var arg time.Duration
+	cli.Arg("arg").Duration(&arg).Default(time.Second)
 	ctx := context.Background()
-	err := cli.Parse(ctx, []string{"build"})
+	err := cli.Parse(ctx, []string{"1m"})
 	is.NoErr(err)
-	is.Equal(len(trace), 1)
-	is.Equal(trace[0], "build")
-	isEqual(t, actual.String(), ``)
+	is.Equal(arg, time.Minute)
 }
 
-func TestSubHelp(t *testing.T) {
+func TestHelpFlagDuration(t *testing.T) {
 	is := is.New(t)
 	actual := new(bytes.Buffer)
-	cli := commander.New("bud").Writer(actual)
-	cli.Flag("log", "specify the logger").Bool(nil)
-	cli.Command("run", "run your application")
-	cli.Command("build", "build your application")
+	cli := commander.New("cli").Writer(actual)
+	cli.Flag("timeout", "request timeout").Duration(nil).Default(5 * time.Minute)
+	cli.Flag("delay", "").Duration(nil).Default(90 * time.Minute)
 	ctx := context.Background()
 	err := cli.Parse(ctx, []string{"-h"})
 	is.NoErr(err)
 	isEqual(t, actual.String(), `
   {bold}Usage:{reset}
-    bud {dim}[flags]{reset} {dim}[command]{reset}
+    cli {dim}[flags]{reset}
 
   {bold}Flags:{reset}
-    --log  {dim}specify the logger{reset}
-
-  {bold}Commands:{reset}
-    build  {dim}build your application{reset}
-    run    {dim}run your application{reset}
+    --delay    {dim}(default: 1h30m){reset}
+    --timeout  {dim}request timeout (default: 5m){reset}
 
 `)
 }
 
-func TestEmptyUsage(t *testing.T) {
+func TestFlagBool(t *testing.T) {
 	is := is.New(t)
 	actual := new(bytes.Buffer)
-	cli := commander.New("bud").Writer(actual)
-	cli.Flag("log", "").Bool(nil)
-	cli.Command("run", "")
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var flag bool
+	cli.Flag("flag", "cli flag").Bool(&flag)
 	ctx := context.Background()
-	err := cli.Parse(ctx, []string{"-h"})
+	err := cli.Parse(ctx, []string{"--flag"})
 	is.NoErr(err)
-	isEqual(t, actual.String(), `
-  {bold}Usage:{reset}
-    bud {dim}[flags]{reset} {dim}[command]{reset}
+	is.Equal(1, called)
+	is.Equal(flag, true)
+	isEqual(t, actual.String(), ``)
+}
+func TestFlagBoolDefault(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var flag bool
+	cli.Flag("flag", "cli flag").Bool(&flag).Default(true)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(flag, true)
+	isEqual(t, actual.String(), ``)
+}
 
-  {bold}Flags:{reset}
-    --log
+func TestFlagBoolRequired(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var flag bool
+	cli.Flag("flag", "cli flag").Bool(&flag)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.Equal(err.Error(), "missing --flag")
+}
 
-  {bold}Commands:{reset}
-    run
+func TestFlagEnum(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var flag string
+	cli.Flag("log", "log level").Enum(&flag, "debug", "info", "warn", "error")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--log", "warn"})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(flag, "warn")
+	isEqual(t, actual.String(), ``)
+}
 
-`)
+func TestFlagEnumInvalid(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var flag string
+	cli.Flag("log", "log level").Enum(&flag, "debug", "info", "warn", "error")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--log", "trace"})
+	is.True(err != nil)
+	is.Equal(err.Error(), `invalid value "trace" for flag -log: expected one of debug, info, warn, error`)
+	is.Equal(0, called)
 }
 
-func TestSubHelpShort(t *testing.T) {
+func TestFlagEnumDefault(t *testing.T) {
 	is := is.New(t)
 	actual := new(bytes.Buffer)
-	cli := commander.New("bud").Writer(actual)
-	cli.Flag("log", "specify the logger").Short('L').Bool(nil).Default(false)
-	cli.Flag("debug", "set the debugger").Bool(nil).Default(true)
-	var trace []string
+	called := 0
+	cli := commander.New("cli").Writer(actual)
 	cli.Run(func(ctx context.Context) error {
-		trace = append(trace, "bud")
+		called++
 		return nil
 	})
-	{
-		sub := cli.Command("run", "run your application")
-		sub.Run(func(ctx context.Context) error {
-			trace = append(trace, "run")
-			return nil
-		})
-	}
-	{
-		sub := cli.Command("build", "build your application")
-		sub.Run(func(ctx context.Context) error {
-			trace = append(trace, "build")
-			return nil
-		})
-	}
+	var flag string
+	cli.Flag("log", "log level").Enum(&flag, "debug", "info", "warn", "error").Default("info")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(flag, "info")
+}
+
+func TestFlagEnumRequired(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var flag string
+	cli.Flag("log", "log level").Enum(&flag, "debug", "info")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.Equal(err.Error(), "missing --log")
+}
+
+func TestHelpFlagEnum(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Flag("log", "log level").Enum(nil, "debug", "info", "warn", "error").Default("info")
 	ctx := context.Background()
 	err := cli.Parse(ctx, []string{"-h"})
 	is.NoErr(err)
 	isEqual(t, actual.String(), `
   {bold}Usage:{reset}
-    bud {dim}[flags]{reset} {dim}[command]{reset}
+    cli {dim}[flags]{reset}
 
   {bold}Flags:{reset}
-    -L, --log  {dim}specify the logger{reset}
-    --debug    {dim}set the debugger{reset}
-
-  {bold}Commands:{reset}
-    build  {dim}build your application{reset}
-    run    {dim}run your application{reset}
+    --log  {dim}log level (one of: debug|info|warn|error, default: info){reset}
 
 `)
 }
 
-func TestArgString(t *testing.T) {
+func TestFlagEnv(t *testing.T) {
 	is := is.New(t)
+	t.Setenv("CLI_FLAG", "env")
 	actual := new(bytes.Buffer)
 	called := 0
 	cli := commander.New("cli").Writer(actual)
@@ -561,18 +740,18 @@ func TestArgString(t *testing.T) {
 		called++
 		return nil
 	})
-	var arg string
-	cli.Arg("arg").String(&arg)
+	var flag string
+	cli.Flag("flag", "cli flag").Env("CLI_FLAG").String(&flag).Default("default")
 	ctx := context.Background()
-	err := cli.Parse(ctx, []string{"cool"})
+	err := cli.Parse(ctx, []string{})
 	is.NoErr(err)
 	is.Equal(1, called)
-	is.Equal(arg, "cool")
-	isEqual(t, actual.String(), ``)
+	is.Equal(flag, "env")
 }
 
-func TestArgStringDefault(t *testing.T) {
+func TestFlagEnvPrecedence(t *testing.T) {
 	is := is.New(t)
+	t.Setenv("CLI_FLAG", "env")
 	actual := new(bytes.Buffer)
 	called := 0
 	cli := commander.New("cli").Writer(actual)
@@ -580,17 +759,16 @@ func TestArgStringDefault(t *testing.T) {
 		called++
 		return nil
 	})
-	var arg string
-	cli.Arg("arg").String(&arg).Default("default")
+	var flag string
+	cli.Flag("flag", "cli flag").Short('f').Env("CLI_FLAG").String(&flag)
 	ctx := context.Background()
-	err := cli.Parse(ctx, []string{})
+	err := cli.Parse(ctx, []string{"-f", "flag"})
 	is.NoErr(err)
 	is.Equal(1, called)
-	is.Equal(arg, "default")
-	isEqual(t, actual.String(), ``)
+	is.Equal(flag, "flag")
 }
 
-func TestArgStringRequired(t *testing.T) {
+func TestFlagEnvUnset(t *testing.T) {
 	is := is.New(t)
 	actual := new(bytes.Buffer)
 	called := 0
@@ -599,95 +777,68 @@ func TestArgStringRequired(t *testing.T) {
 		called++
 		return nil
 	})
-	var arg string
-	cli.Arg("arg").String(&arg)
+	var flag int
+	cli.Flag("flag", "cli flag").Env("CLI_UNSET_FLAG").Int(&flag).Default(5)
 	ctx := context.Background()
 	err := cli.Parse(ctx, []string{})
-	is.Equal(err.Error(), "missing arg")
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(flag, 5)
 }
 
-func TestSubArgString(t *testing.T) {
+func TestFlagEnvInvalid(t *testing.T) {
 	is := is.New(t)
+	t.Setenv("CLI_FLAG", "ten")
 	actual := new(bytes.Buffer)
-	called := 0
 	cli := commander.New("cli").Writer(actual)
 	cli.Run(func(ctx context.Context) error {
-		called++
 		return nil
 	})
-	var arg string
-	cli.Command("build", "build command")
-	cli.Command("run", "run command")
-	cli.Arg("arg").String(&arg)
+	var flag int
+	cli.Flag("flag", "cli flag").Env("CLI_FLAG").Int(&flag)
 	ctx := context.Background()
-	err := cli.Parse(ctx, []string{"deploy"})
+	err := cli.Parse(ctx, []string{})
+	is.True(err != nil)
+	is.True(strings.HasPrefix(err.Error(), `invalid value "ten" for $CLI_FLAG:`))
+}
+
+func TestHelpFlagEnv(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	cli.Flag("log", "log level").Env("BUD_LOG").String(nil).Default("info")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-h"})
 	is.NoErr(err)
-	is.Equal(1, called)
-	is.Equal(arg, "deploy")
-	isEqual(t, actual.String(), ``)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    bud {dim}[flags]{reset}
+
+  {bold}Flags:{reset}
+    --log  {dim}log level (env: $BUD_LOG){reset}
+
+`)
 }
 
-// TestInterrupt tests interrupts canceling context. It spawns a copy of itself
-// to run a subcommand. I learned this trick from Mitchell Hashimoto's excellent
-// "Advanced Testing with Go" talk. We use stdout to synchronize between the
-// process and subprocess.
-func TestInterrupt(t *testing.T) {
+func TestFlagCount(t *testing.T) {
 	is := is.New(t)
-	if value := os.Getenv("TEST_INTERRUPT"); value == "" {
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-		// Ignore -test.count otherwise this will continue recursively
-		var args []string
-		for _, arg := range os.Args[1:] {
-			if strings.HasPrefix(arg, "-test.count=") {
-				continue
-			}
-			args = append(args, arg)
-		}
-		cmd := exec.CommandContext(ctx, os.Args[0], append(args, "-test.v=true", "-test.run=^TestInterrupt$")...)
-		cmd.Env = append(os.Environ(), "TEST_INTERRUPT=1")
-		stdout, err := cmd.StdoutPipe()
-		is.NoErr(err)
-		cmd.Stderr = os.Stderr
-		is.NoErr(cmd.Start())
-		scanner := bufio.NewScanner(stdout)
-		for scanner.Scan() {
-			line := scanner.Text()
-			if line == "ready" {
-				break
-			}
-		}
-		cmd.Process.Signal(os.Interrupt)
-		for scanner.Scan() {
-			line := scanner.Text()
-			if line == "cancelled" {
-				break
-			}
-		}
-		if err := cmd.Wait(); err != nil {
-			is.True(errors.Is(err, context.Canceled))
-		}
-		return
-	}
-	cli := commander.New("cli")
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
 	cli.Run(func(ctx context.Context) error {
-		os.Stdout.Write([]byte("ready\n"))
-		<-ctx.Done()
-		os.Stdout.Write([]byte("cancelled\n"))
+		called++
 		return nil
 	})
+	var verbose int
+	cli.Flag("verbose", "verbosity").Short('v').Count(&verbose)
 	ctx := context.Background()
-	if err := cli.Parse(ctx, []string{}); err != nil {
-		if errors.Is(err, context.Canceled) {
-			return
-		}
-		is.NoErr(err)
-	}
+	err := cli.Parse(ctx, []string{"-v", "--verbose", "-v"})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(verbose, 3)
 }
 
-// TODO: example support
-
-func TestArgsStrings(t *testing.T) {
+func TestFlagCountCombined(t *testing.T) {
 	is := is.New(t)
 	actual := new(bytes.Buffer)
 	called := 0
@@ -696,36 +847,3306 @@ func TestArgsStrings(t *testing.T) {
 		called++
 		return nil
 	})
+	var verbose int
 	var args []string
-	cli.Command("build", "build command")
-	cli.Command("run", "run command")
-	cli.Args("custom").Strings(&args)
+	cli.Interspersed(false)
+	cli.Flag("verbose", "verbosity").Short('v').Count(&verbose)
+	cli.Args("args").Strings(&args)
 	ctx := context.Background()
-	err := cli.Parse(ctx, []string{"new", "view"})
+	err := cli.Parse(ctx, []string{"-vvv", "-v", "a", "-vv"})
 	is.NoErr(err)
 	is.Equal(1, called)
+	is.Equal(verbose, 4)
 	is.Equal(len(args), 2)
-	is.Equal(args[0], "new")
-	is.Equal(args[1], "view")
-	isEqual(t, actual.String(), ``)
+	is.Equal(args[0], "a")
+	is.Equal(args[1], "-vv")
 }
 
-func TestUsageError(t *testing.T) {
+func TestFlagCountExplicit(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var verbose int
+	cli.Flag("verbose", "verbosity").Short('v').Count(&verbose)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--verbose=5"})
+	is.NoErr(err)
+	is.Equal(verbose, 5)
+}
+
+func TestFlagCountDefault(t *testing.T) {
 	is := is.New(t)
 	actual := new(bytes.Buffer)
 	called := 0
 	cli := commander.New("cli").Writer(actual)
 	cli.Run(func(ctx context.Context) error {
 		called++
-		return commander.Usage()
+		return nil
 	})
+	verbose := 10
+	cli.Flag("verbose", "verbosity").Short('v').Count(&verbose)
 	ctx := context.Background()
 	err := cli.Parse(ctx, []string{})
 	is.NoErr(err)
-	is.NoErr(err)
-	isEqual(t, actual.String(), `
-  {bold}Usage:{reset}
-    cli
+	is.Equal(1, called)
+	is.Equal(verbose, 0)
+}
 
-`)
+type semver struct {
+	major, minor, patch int
+}
+
+func (v *semver) Set(value string) error {
+	if _, err := fmt.Sscanf(value, "v%d.%d.%d", &v.major, &v.minor, &v.patch); err != nil {
+		return fmt.Errorf("invalid version %q", value)
+	}
+	return nil
+}
+
+func (v *semver) String() string {
+	return fmt.Sprintf("v%d.%d.%d", v.major, v.minor, v.patch)
+}
+
+func TestFlagCustom(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	version := new(semver)
+	cli.Flag("version", "release version").Custom(version)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--version", "v1.2.3"})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(version.String(), "v1.2.3")
+}
+
+func TestFlagCustomInvalid(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	version := new(semver)
+	cli.Flag("version", "release version").Custom(version)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--version", "1.2"})
+	is.True(err != nil)
+	is.Equal(err.Error(), `invalid value "1.2" for flag -version: invalid version "1.2"`)
+}
+
+func TestFlagCustomDefault(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	version := new(semver)
+	cli.Flag("version", "release version").Custom(version).Default("v0.1.0")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(version.String(), "v0.1.0")
+}
+
+func TestFlagCustomRequired(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	cli.Flag("version", "release version").Custom(new(semver))
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.Equal(err.Error(), "missing --version")
+}
+
+func TestArgCustomOptional(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	version := &semver{1, 0, 0}
+	cli.Arg("version").Custom(version).Optional()
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(version.String(), "v1.0.0")
+}
+
+func TestFlagBoolNegated(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var cache bool
+	cli.Flag("cache", "cache builds").Bool(&cache).Default(true)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--no-cache"})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(cache, false)
+}
+
+func TestFlagBoolNegatedFalse(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var cache bool
+	cli.Flag("cache", "cache builds").Bool(&cache).Default(true)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--no-cache=false"})
+	is.NoErr(err)
+	is.Equal(cache, true)
+}
+
+func TestFlagBoolNotNegatable(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var cache bool
+	cli.Flag("cache", "cache builds").Bool(&cache).Default(false)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--no-cache"})
+	is.True(err != nil)
+	is.Equal(err.Error(), "unknown flag --no-cache, did you mean --cache?\n  usage: cli [flags]")
+}
+
+func TestFlagBoolNegatedEnv(t *testing.T) {
+	is := is.New(t)
+	t.Setenv("CLI_CACHE", "true")
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var cache bool
+	cli.Flag("cache", "cache builds").Env("CLI_CACHE").Bool(&cache).Default(true)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--no-cache"})
+	is.NoErr(err)
+	is.Equal(cache, false)
+}
+
+func TestFlagStrings(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var flags []string
+	cli.Flag("flag", "cli flag").Strings(&flags)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--flag", "1", "--flag", "2"})
+	is.NoErr(err)
+	is.Equal(len(flags), 2)
+	is.Equal(flags[0], "1")
+	is.Equal(flags[1], "2")
+}
+
+func TestFlagStringsRequired(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var flags []string
+	cli.Flag("flag", "cli flag").Strings(&flags)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.Equal(err.Error(), "missing --flag")
+}
+
+func TestFlagStringsDefault(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var flags []string
+	cli.Flag("flag", "cli flag").Strings(&flags).Default("a", "b")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.Equal(len(flags), 2)
+	is.Equal(flags[0], "a")
+	is.Equal(flags[1], "b")
+}
+
+func TestFlagInts(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var flags []int
+	cli.Flag("flag", "cli flag").Ints(&flags)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--flag", "1", "--flag", "2"})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(len(flags), 2)
+	is.Equal(flags[0], 1)
+	is.Equal(flags[1], 2)
+}
+
+func TestFlagIntsInvalid(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var flags []int
+	cli.Flag("flag", "cli flag").Ints(&flags)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--flag", "1", "--flag", "two"})
+	is.True(err != nil)
+	is.True(strings.HasPrefix(err.Error(), `invalid value "two" for flag -flag:`))
+}
+
+func TestFlagIntsRequired(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var flags []int
+	cli.Flag("flag", "cli flag").Ints(&flags)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.Equal(err.Error(), "missing --flag")
+}
+
+func TestFlagIntsDefault(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var flags []int
+	cli.Flag("flag", "cli flag").Ints(&flags).Default(3, 4)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.Equal(len(flags), 2)
+	is.Equal(flags[0], 3)
+	is.Equal(flags[1], 4)
+}
+
+func TestFlagFloat64s(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var flags []float64
+	cli.Flag("flag", "cli flag").Float64s(&flags)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--flag", "0.5", "--flag", "2"})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(len(flags), 2)
+	is.Equal(flags[0], 0.5)
+	is.Equal(flags[1], 2.0)
+}
+
+func TestFlagFloat64sRequired(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var flags []float64
+	cli.Flag("flag", "cli flag").Float64s(&flags)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.Equal(err.Error(), "missing --flag")
+}
+
+func TestFlagFloat64sDefault(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var flags []float64
+	cli.Flag("flag", "cli flag").Float64s(&flags).Default(0.1)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.Equal(len(flags), 1)
+	is.Equal(flags[0], 0.1)
+}
+
+func TestArgsInts(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("sum").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var args []int
+	cli.Args("numbers").Ints(&args)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"1", "2", "3"})
+	is.NoErr(err)
+	is.Equal(len(args), 3)
+	is.Equal(args[2], 3)
+}
+
+func TestArgsDefault(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var dirs []string
+	cli.Args("dirs").Strings(&dirs).Default("a", "b")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.Equal(dirs, []string{"a", "b"})
+	// Passed values replace the default
+	dirs = nil
+	err = cli.Parse(ctx, []string{"c"})
+	is.NoErr(err)
+	is.Equal(dirs, []string{"c"})
+}
+
+func TestArgsIntsDefault(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("sum").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var args []int
+	cli.Args("numbers").Ints(&args).Default(1, 2)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.Equal(args, []int{1, 2})
+}
+
+func TestFlagURL(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var endpoint url.URL
+	cli.Flag("endpoint", "api endpoint").URL(&endpoint)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--endpoint", "https://api.livebud.com:8080/v1?debug=1"})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(endpoint.Scheme, "https")
+	is.Equal(endpoint.Host, "api.livebud.com:8080")
+	is.Equal(endpoint.Path, "/v1")
+	is.Equal(endpoint.Query().Get("debug"), "1")
+}
+
+func TestFlagURLInvalid(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var endpoint url.URL
+	cli.Flag("endpoint", "api endpoint").URL(&endpoint)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--endpoint", "localhost"})
+	is.True(err != nil)
+	is.Equal(err.Error(), `invalid value "localhost" for flag -endpoint: expected an absolute URL like https://example.com`)
+}
+
+func TestFlagURLDefault(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var endpoint url.URL
+	cli.Flag("endpoint", "api endpoint").URL(&endpoint).Default("http://localhost:3000")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.Equal(endpoint.String(), "http://localhost:3000")
+}
+
+func TestFlagURLRequired(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var endpoint url.URL
+	cli.Flag("endpoint", "api endpoint").URL(&endpoint)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.Equal(err.Error(), "missing --endpoint")
+}
+
+func TestArgURL(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var endpoint url.URL
+	cli.Arg("endpoint").URL(&endpoint)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"http://localhost:3000/posts"})
+	is.NoErr(err)
+	is.Equal(endpoint.Path, "/posts")
+}
+
+func TestFlagPath(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var path string
+	cli.Flag("out", "output path").Path(&path)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--out", "does/not/exist"})
+	is.NoErr(err)
+	is.Equal(1, called)
+	wd, err := os.Getwd()
+	is.NoErr(err)
+	is.Equal(path, filepath.Join(wd, "does", "not", "exist"))
+}
+
+func TestFlagPathMustExist(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var path string
+	cli.Flag("config", "config file").Path(&path).MustExist()
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--config", "missing.json"})
+	is.True(err != nil)
+	is.Equal(err.Error(), `invalid value "missing.json" for flag -config: missing.json does not exist`)
+}
+
+func TestFlagPathDir(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file.txt")
+	is.NoErr(os.WriteFile(file, []byte("hi"), 0644))
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var path string
+	cli.Flag("chdir", "working directory").Path(&path).Dir()
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--chdir", file})
+	is.True(err != nil)
+	is.Equal(err.Error(), fmt.Sprintf(`invalid value %[1]q for flag -chdir: %[1]s is not a directory`, file))
+}
+
+func TestFlagPathDirDefault(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var path string
+	cli.Flag("chdir", "working directory").Path(&path).Dir().Default(".")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	wd, err := os.Getwd()
+	is.NoErr(err)
+	is.Equal(path, wd)
+}
+
+func TestFlagPathDefaultMissing(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var path string
+	cli.Flag("config", "config file").Path(&path).MustExist().Default("bud.json")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.True(err != nil)
+	is.Equal(err.Error(), "invalid default for --config: bud.json does not exist")
+}
+
+func TestArgPathDir(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var path string
+	cli.Arg("dir").Path(&path).Dir()
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{dir})
+	is.NoErr(err)
+	is.Equal(path, dir)
+}
+
+func TestFlagTime(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var at time.Time
+	cli.Flag("at", "schedule time").Time(&at)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--at", "2022-05-01T10:30:00Z"})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.True(at.Equal(time.Date(2022, 5, 1, 10, 30, 0, 0, time.UTC)))
+}
+
+func TestFlagTimeLayout(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var since time.Time
+	cli.Flag("since", "start date").Time(&since).Layout("2006-01-02")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--since", "2022-05-01"})
+	is.NoErr(err)
+	is.True(since.Equal(time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestFlagTimeInvalid(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var since time.Time
+	cli.Flag("since", "start date").Time(&since).Layout("2006-01-02")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--since", "yesterday"})
+	is.True(err != nil)
+	is.Equal(err.Error(), `invalid value "yesterday" for flag -since: expected a time like "2006-01-02"`)
+}
+
+func TestFlagTimeDefault(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var at time.Time
+	epoch := time.Unix(0, 0)
+	cli.Flag("at", "schedule time").Time(&at).Default(epoch)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.True(at.Equal(epoch))
+}
+
+func TestFlagTimeRequired(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var at time.Time
+	cli.Flag("at", "schedule time").Time(&at)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.Equal(err.Error(), "missing --at")
+}
+
+func TestFlagBytes(t *testing.T) {
+	is := is.New(t)
+	tests := map[string]int64{
+		"512":    512,
+		"512B":   512,
+		"512K":   512 << 10,
+		"10MB":   10e6,
+		"1GiB":   1 << 30,
+		"1.5gib": 3 << 29,
+		"2 Ti":   2 << 40,
+	}
+	for input, expect := range tests {
+		actual := new(bytes.Buffer)
+		cli := commander.New("cli").Writer(actual)
+		cli.Run(func(ctx context.Context) error {
+			return nil
+		})
+		var size int64
+		cli.Flag("limit", "cache limit").Bytes(&size)
+		ctx := context.Background()
+		err := cli.Parse(ctx, []string{"--limit", input})
+		is.NoErr(err)
+		is.Equal(size, expect)
+	}
+}
+
+func TestFlagBytesInvalid(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var size int64
+	cli.Flag("limit", "cache limit").Bytes(&size)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--limit", "10XB"})
+	is.True(err != nil)
+	is.Equal(err.Error(), `invalid value "10XB" for flag -limit: expected a size like 512K, 10MB or 1GiB`)
+}
+
+func TestFlagBytesDefault(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var size int64
+	cli.Flag("limit", "cache limit").Bytes(&size).Default(64 << 20)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.Equal(size, int64(64<<20))
+}
+
+func TestHelpFlagBytes(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Flag("cache", "cache limit").Bytes(nil).Default(64 << 20)
+	cli.Flag("upload", "upload limit").Bytes(nil).Default(10e6)
+	cli.Flag("chunk", "chunk size").Bytes(nil).Default(1000 + 1)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    cli {dim}[flags]{reset}
+
+  {bold}Flags:{reset}
+    --cache   {dim}cache limit (default: 64MiB){reset}
+    --chunk   {dim}chunk size (default: 1001B){reset}
+    --upload  {dim}upload limit (default: 10MB){reset}
+
+`)
+}
+
+func TestFlagIP(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var ip net.IP
+	cli.Flag("listen", "listen address").IP(&ip)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--listen", "::1"})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.True(ip.Equal(net.IPv6loopback))
+}
+
+func TestFlagIPInvalid(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var ip net.IP
+	cli.Flag("listen", "listen address").IP(&ip)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--listen", "localhost"})
+	is.True(err != nil)
+	is.Equal(err.Error(), `invalid value "localhost" for flag -listen: expected an IP address like 127.0.0.1 or ::1`)
+}
+
+func TestFlagIPDefault(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var ip net.IP
+	cli.Flag("listen", "listen address").IP(&ip).Default("127.0.0.1")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.Equal(ip.String(), "127.0.0.1")
+}
+
+func TestFlagCIDR(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var network net.IPNet
+	cli.Flag("allow", "allowed network").CIDR(&network)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--allow", "10.1.2.3/8"})
+	is.NoErr(err)
+	is.Equal(network.String(), "10.0.0.0/8")
+	is.True(network.Contains(net.ParseIP("10.200.0.1")))
+}
+
+func TestFlagCIDRInvalid(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var network net.IPNet
+	cli.Flag("allow", "allowed network").CIDR(&network)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--allow", "10.0.0.1"})
+	is.True(err != nil)
+	is.Equal(err.Error(), `invalid value "10.0.0.1" for flag -allow: expected a CIDR like 10.0.0.0/8 or fd00::/8`)
+}
+
+func TestFlagCIDRRequired(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var network net.IPNet
+	cli.Flag("allow", "allowed network").CIDR(&network)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.Equal(err.Error(), "missing --allow")
+}
+
+func TestFlagStringMap(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var flags map[string]string
+	cli.Flag("flag", "cli flag").StringMap(&flags)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--flag", "a:1 + 1", "--flag", "b:2"})
+	is.NoErr(err)
+	is.Equal(len(flags), 2)
+	is.Equal(flags["a"], "1 + 1")
+	is.Equal(flags["b"], "2")
+}
+
+func TestFlagStringMapRequired(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var flags map[string]string
+	cli.Flag("flag", "cli flag").StringMap(&flags)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.Equal(err.Error(), "missing --flag")
+}
+
+func TestFlagStringMapDefault(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var flags map[string]string
+	cli.Flag("flag", "cli flag").StringMap(&flags).Default(map[string]string{
+		"a": "1",
+		"b": "2",
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.Equal(len(flags), 2)
+	is.Equal(flags["a"], "1")
+	is.Equal(flags["b"], "2")
+}
+
+func TestFlagStringMapEquals(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var flags map[string]string
+	cli.Flag("flag", "cli flag").StringMap(&flags)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--flag", "api=http://localhost:3000", "--flag", "dir:C:\\app"})
+	is.NoErr(err)
+	is.Equal(len(flags), 2)
+	is.Equal(flags["api"], "http://localhost:3000")
+	is.Equal(flags["dir"], "C:\\app")
+}
+
+func TestFlagStringMapDelimiter(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var flags map[string]string
+	cli.Flag("flag", "cli flag").StringMap(&flags).Delimiter('=')
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--flag", "host:port=localhost:3000"})
+	is.NoErr(err)
+	is.Equal(len(flags), 1)
+	is.Equal(flags["host:port"], "localhost:3000")
+}
+
+func TestFlagStringMapDelimiterInvalid(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var flags map[string]string
+	cli.Flag("flag", "cli flag").StringMap(&flags).Delimiter('=')
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--flag", "a:1"})
+	is.True(err != nil)
+	is.Equal(err.Error(), `invalid value "a:1" for flag -flag: invalid key=value pair for "a:1"`)
+}
+
+func TestArgStringMap(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var args map[string]string
+	cli.Arg("arg").StringMap(&args)
+	// Can have only one arg
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"a:1 + 1"})
+	is.NoErr(err)
+	is.Equal(len(args), 1)
+	is.Equal(args["a"], "1 + 1")
+}
+
+func TestArgStringMapRequired(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var args map[string]string
+	cli.Arg("arg").StringMap(&args)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.Equal(err.Error(), "missing arg")
+}
+
+func TestArgStringMapDefault(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var args map[string]string
+	cli.Arg("arg").StringMap(&args).Default(map[string]string{
+		"a": "1",
+		"b": "2",
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.Equal(len(args), 2)
+	is.Equal(args["a"], "1")
+	is.Equal(args["b"], "2")
+}
+
+func validPort(value string) error {
+	port, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("port must be a number")
+	} else if port < 1 || port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535")
+	}
+	return nil
+}
+
+func TestFlagValidate(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var port int
+	cli.Flag("port", "port").Validate(validPort).Int(&port)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--port", "3000"})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(port, 3000)
+}
+
+func TestFlagValidateInvalid(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var port int
+	cli.Flag("port", "port").Validate(validPort).Int(&port)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--port", "70000"})
+	is.True(err != nil)
+	is.Equal(err.Error(), `invalid value "70000" for flag -port: port must be between 1 and 65535`)
+	is.Equal(0, called)
+}
+
+func TestFlagValidateBool(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var embed bool
+	cli.Flag("embed", "embed assets").Validate(func(value string) error {
+		return fmt.Errorf("embedding is disabled")
+	}).Bool(&embed).Default(false)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--embed"})
+	is.True(err != nil)
+	is.Equal(err.Error(), `invalid boolean flag embed: embedding is disabled`)
+}
+
+func TestFlagValidateEnv(t *testing.T) {
+	is := is.New(t)
+	t.Setenv("CLI_PORT", "0")
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var port int
+	cli.Flag("port", "port").Env("CLI_PORT").Validate(validPort).Int(&port)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.True(err != nil)
+	is.Equal(err.Error(), `invalid value "0" for $CLI_PORT: port must be between 1 and 65535`)
+}
+
+func TestArgValidate(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var port int
+	cli.Arg("port").Validate(validPort).Int(&port)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"http"})
+	is.True(err != nil)
+	is.Equal(err.Error(), `invalid value "http" for port: port must be a number`)
+	is.Equal(0, called)
+}
+
+func TestArgsValidate(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var actions []string
+	cli.Args("actions").Validate(func(value string) error {
+		if strings.ToLower(value) != value {
+			return fmt.Errorf("must be lowercase")
+		}
+		return nil
+	}).Strings(&actions)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"index", "Show"})
+	is.True(err != nil)
+	is.Equal(err.Error(), `invalid value "Show" for actions: must be lowercase`)
+}
+
+func TestRequireTogether(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var user, password string
+	cli.Flag("user", "username").String(&user).Optional()
+	cli.Flag("password", "password").String(&password).Optional()
+	cli.RequireTogether("user", "password")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--user", "alice"})
+	is.True(err != nil)
+	is.Equal(err.Error(), "missing --password, which must be used together with --user")
+	is.Equal(0, called)
+}
+
+func TestRequireTogetherAll(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var user, password string
+	cli.Flag("user", "username").String(&user).Optional()
+	cli.Flag("password", "password").String(&password).Optional()
+	cli.RequireTogether("user", "password")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--user", "alice", "--password", "secret"})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(user, "alice")
+	is.Equal(password, "secret")
+}
+
+func TestRequireTogetherNone(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var user, password string
+	cli.Flag("user", "username").String(&user).Default("anonymous")
+	cli.Flag("password", "password").String(&password).Optional()
+	cli.RequireTogether("user", "password")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(user, "anonymous")
+}
+
+func TestRequireTogetherEnv(t *testing.T) {
+	is := is.New(t)
+	t.Setenv("CLI_PASSWORD", "secret")
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var user, password string
+	cli.Flag("user", "username").String(&user).Optional()
+	cli.Flag("password", "password").Env("CLI_PASSWORD").String(&password).Optional()
+	cli.Flag("token", "token").String(nil).Optional()
+	cli.RequireTogether("user", "password", "token")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-user", "alice"})
+	is.True(err != nil)
+	is.Equal(err.Error(), "missing --token, which must be used together with --user, --password")
+}
+
+type mapLoader map[string]interface{}
+
+func (m mapLoader) Load() (map[string]interface{}, error) {
+	return m, nil
+}
+
+func TestConfigFile(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bud.json")
+	is.NoErr(os.WriteFile(path, []byte(`{
+		"log": "debug",
+		"tags": ["a", "b"],
+		"env": { "B": "2", "A": "1" },
+		"run": { "port": 3000, "hot": false }
+	}`), 0644))
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual).ConfigFile(path)
+	var log string
+	var tags []string
+	var env map[string]string
+	var port int
+	var hot bool
+	cli.Flag("log", "log level").String(&log).Default("info")
+	cli.Flag("tags", "build tags").Strings(&tags)
+	cli.Flag("env", "environment").StringMap(&env)
+	sub := cli.Command("run", "run the server")
+	sub.Flag("port", "port").Int(&port)
+	sub.Flag("hot", "hot reload").Bool(&hot).Default(true)
+	sub.Run(func(ctx context.Context) error {
+		return nil
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"run"})
+	is.NoErr(err)
+	is.Equal(log, "debug")
+	is.Equal(len(tags), 2)
+	is.Equal(tags[1], "b")
+	is.Equal(env["A"], "1")
+	is.Equal(env["B"], "2")
+	is.Equal(port, 3000)
+	is.Equal(hot, false)
+}
+
+func TestConfigFileMissing(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual).ConfigFile(filepath.Join(t.TempDir(), "bud.json"))
+	var log string
+	cli.Flag("log", "log level").String(&log).Default("info")
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.Equal(log, "info")
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	is := is.New(t)
+	t.Setenv("BUD_ENV_FLAG", "env")
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual).Loader(mapLoader{
+		"cli_flag":    "config",
+		"env_flag":    "config",
+		"config_flag": "config",
+	})
+	var cliFlag, envFlag, configFlag, defaultFlag string
+	cli.Flag("cli_flag", "").Env("BUD_CLI_FLAG").String(&cliFlag).Default("default")
+	cli.Flag("env_flag", "").Env("BUD_ENV_FLAG").String(&envFlag).Default("default")
+	cli.Flag("config_flag", "").Env("BUD_CONFIG_FLAG").String(&configFlag).Default("default")
+	cli.Flag("default_flag", "").String(&defaultFlag).Default("default")
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--cli_flag", "cli"})
+	is.NoErr(err)
+	is.Equal(cliFlag, "cli")
+	is.Equal(envFlag, "env")
+	is.Equal(configFlag, "config")
+	is.Equal(defaultFlag, "default")
+}
+
+func TestConfigStringMapDelimiter(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual).Loader(mapLoader{
+		"hosts": map[string]interface{}{
+			"api:v1": "localhost:3000",
+		},
+	})
+	var hosts map[string]string
+	cli.Flag("hosts", "").StringMap(&hosts).Delimiter('=')
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.Equal(hosts["api:v1"], "localhost:3000")
+}
+
+func TestConfigInvalid(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual).Loader(mapLoader{
+		"port": "http",
+	})
+	cli.Flag("port", "").Int(nil)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.True(err != nil)
+	is.True(strings.HasPrefix(err.Error(), `invalid value "http" for --port in config:`))
+}
+
+func TestSub(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	var trace []string
+	cli.Run(func(ctx context.Context) error {
+		trace = append(trace, "bud")
+		return nil
+	})
+	{
+		sub := cli.Command("run", "run your application")
+		sub.Run(func(ctx context.Context) error {
+			trace = append(trace, "run")
+			return nil
+		})
+	}
+	{
+		sub := cli.Command("build", "build your application")
+		sub.Run(func(ctx context.Context) error {
+			trace = append(trace, "build")
+			return nil
+		})
+	}
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"build"})
+	is.NoErr(err)
+	is.Equal(len(trace), 1)
+	is.Equal(trace[0], "build")
+	isEqual(t, actual.String(), ``)
+}
+
+func TestSubHelp(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	cli.Flag("log", "specify the logger").Bool(nil)
+	cli.Command("run", "run your application")
+	cli.Command("build", "build your application")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    bud {dim}[flags]{reset} {dim}[command]{reset}
+
+  {bold}Flags:{reset}
+    --log  {dim}specify the logger{reset}
+
+  {bold}Commands:{reset}
+    build  {dim}build your application{reset}
+    run    {dim}run your application{reset}
+
+`)
+}
+
+func TestEmptyUsage(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	cli.Flag("log", "").Bool(nil)
+	cli.Command("run", "")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    bud {dim}[flags]{reset} {dim}[command]{reset}
+
+  {bold}Flags:{reset}
+    --log
+
+  {bold}Commands:{reset}
+    run
+
+`)
+}
+
+func TestSubHelpShort(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	cli.Flag("log", "specify the logger").Short('L').Bool(nil).Default(false)
+	cli.Flag("debug", "set the debugger").Bool(nil).Default(true)
+	var trace []string
+	cli.Run(func(ctx context.Context) error {
+		trace = append(trace, "bud")
+		return nil
+	})
+	{
+		sub := cli.Command("run", "run your application")
+		sub.Run(func(ctx context.Context) error {
+			trace = append(trace, "run")
+			return nil
+		})
+	}
+	{
+		sub := cli.Command("build", "build your application")
+		sub.Run(func(ctx context.Context) error {
+			trace = append(trace, "build")
+			return nil
+		})
+	}
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    bud {dim}[flags]{reset} {dim}[command]{reset}
+
+  {bold}Flags:{reset}
+    -L, --log     {dim}specify the logger{reset}
+    --[no-]debug  {dim}set the debugger{reset}
+
+  {bold}Commands:{reset}
+    build  {dim}build your application{reset}
+    run    {dim}run your application{reset}
+
+`)
+}
+
+func TestSubHelpGroups(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	cli.Command("version", "show the version")
+	cli.Command("run", "run the development server").Group("Development")
+	cli.Command("deploy", "deploy your application").Group("Deployment")
+	cli.Command("build", "build the production server").Group("Development")
+	cli.Command("tool", "extra tools").Group("Internal")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    bud {dim}[command]{reset}
+
+  {bold}Commands:{reset}
+    version  {dim}show the version{reset}
+
+  {bold}Development:{reset}
+    build  {dim}build the production server{reset}
+    run    {dim}run the development server{reset}
+
+  {bold}Deployment:{reset}
+    deploy  {dim}deploy your application{reset}
+
+  {bold}Internal:{reset}
+    tool  {dim}extra tools{reset}
+
+`)
+}
+
+func TestHelpFunc(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	cli.Command("run", "run your application")
+	cli.HelpFunc(func(w io.Writer, cmd *commander.Command) error {
+		if err := commander.RenderHelp(w, cmd); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "  Learn more about %s at https://livebud.com\n", cmd.Name())
+		return nil
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"run", "-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    run
+
+  Learn more about run at https://livebud.com
+`)
+}
+
+func TestHelpFuncError(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	cli.HelpFunc(func(w io.Writer, cmd *commander.Command) error {
+		return errors.New("unable to render help")
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-h"})
+	is.True(err != nil)
+	is.Equal(err.Error(), "unable to render help")
+}
+
+func TestHelpTemplate(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	cli.Flag("log", "log level").String(nil)
+	cli.Command("run", "run your application")
+	cli.HelpTemplate(`{{bold}}{{ $.Name }}{{reset}}{{ range $flag := $.Flags }} --{{ $flag.Name }}{{ end }}{{ range $cmd := $.Commands }} {{ $cmd.Name }}{{ end }}`)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `{bold}bud{reset} --log run`)
+}
+
+func TestColorDisabled(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual).Color(false)
+	cli.Flag("log", "log level").String(nil)
+	cli.Command("run", "run your application")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-h"})
+	is.NoErr(err)
+	is.True(!strings.Contains(actual.String(), "\033["))
+	isEqual(t, actual.String(), `
+  Usage:
+    bud [flags] [command]
+
+  Flags:
+    --log  log level
+
+  Commands:
+    run  run your application
+
+`)
+}
+
+func TestColorNoColorEnv(t *testing.T) {
+	is := is.New(t)
+	t.Setenv("NO_COLOR", "1")
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	cli.Command("run", "run your application")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-h"})
+	is.NoErr(err)
+	is.True(!strings.Contains(actual.String(), "\033["))
+}
+
+func TestColorForced(t *testing.T) {
+	is := is.New(t)
+	t.Setenv("NO_COLOR", "1")
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual).Color(true)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    bud
+
+`)
+}
+
+func TestColorNotTerminal(t *testing.T) {
+	is := is.New(t)
+	r, w, err := os.Pipe()
+	is.NoErr(err)
+	defer r.Close()
+	cli := commander.New("bud").Writer(w)
+	cli.Command("run", "run your application")
+	ctx := context.Background()
+	err = cli.Parse(ctx, []string{"-h"})
+	is.NoErr(err)
+	is.NoErr(w.Close())
+	out, err := io.ReadAll(r)
+	is.NoErr(err)
+	is.True(!strings.Contains(string(out), "\033["))
+	is.True(strings.Contains(string(out), "run your application"))
+}
+
+func TestHelpWidth(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual).Width(40)
+	cli.Flag("log", "filter logs by level, pattern or the name of the package").String(nil)
+	cli.Flag("embed", "embed assets").Bool(nil)
+	cli.Command("run", "run the development server and rebuild when files change")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    bud {dim}[flags]{reset} {dim}[command]{reset}
+
+  {bold}Flags:{reset}
+    --embed  {dim}embed assets{reset}
+    --log    {dim}filter logs by level,{reset}
+             {dim}pattern or the name of the{reset}
+             {dim}package{reset}
+
+  {bold}Commands:{reset}
+    run  {dim}run the development server and{reset}
+         {dim}rebuild when files change{reset}
+
+`)
+}
+
+func TestPromptFlags(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	questions := []string{}
+	cli := commander.New("bud").Writer(actual)
+	cli.PromptFunc(func(question string, secret bool) (string, error) {
+		questions = append(questions, fmt.Sprintf("%s %t", question, secret))
+		return "value", nil
+	})
+	login := cli.Command("login", "login to your account")
+	var user, password, region string
+	login.Flag("user", "your username").String(&user)
+	login.Flag("password", "").Secret().String(&password)
+	login.Flag("region", "region").String(&region).Default("us")
+	login.Run(func(ctx context.Context) error { return nil })
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"login", "--user=alice"})
+	is.NoErr(err)
+	is.Equal(questions, []string{"--password true"})
+	is.Equal(user, "alice")
+	is.Equal(password, "value")
+	is.Equal(region, "us")
+}
+
+func TestPromptArgs(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	questions := []string{}
+	cli := commander.New("cp").Writer(actual)
+	cli.PromptFunc(func(question string, secret bool) (string, error) {
+		questions = append(questions, question)
+		return "b.txt", nil
+	})
+	var src, dst string
+	cli.Arg("src").String(&src)
+	cli.Arg("dst").String(&dst)
+	cli.Run(func(ctx context.Context) error { return nil })
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"a.txt"})
+	is.NoErr(err)
+	is.Equal(questions, []string{"dst"})
+	is.Equal(src, "a.txt")
+	is.Equal(dst, "b.txt")
+}
+
+func TestPromptError(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	cli.PromptFunc(func(question string, secret bool) (string, error) {
+		return "", errors.New("interrupted")
+	})
+	cli.Flag("token", "api token").String(nil)
+	cli.Run(func(ctx context.Context) error { return nil })
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.True(err != nil)
+	is.Equal(err.Error(), "interrupted")
+}
+
+func docsCLI() *commander.CLI {
+	cli := commander.New("bud")
+	cli.Flag("chdir", "change the working directory").Short('C').String(nil).Default(".")
+	{
+		cli := cli.Command("run", "run the development server")
+		cli.Flag("hot", "hot reload the frontend").Bool(nil).Default(true)
+		cli.Flag("log", "log level").Enum(nil, "debug", "info").Default("info")
+	}
+	{
+		cli := cli.Command("new", "new scaffold")
+		cli.Command("controller", "new controller").
+			Example("bud new controller posts", "scaffold a posts controller")
+	}
+	return cli
+}
+
+func TestDocsMarkdown(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	err := commander.Docs(docsCLI()).Markdown(actual)
+	is.NoErr(err)
+	commandertest.Equal(t, actual.String(), "# bud\n"+
+		"\n"+
+		"```sh\n"+
+		"bud [flags] [command]\n"+
+		"```\n"+
+		"\n"+
+		"**Flags**\n"+
+		"\n"+
+		"- `-C, --chdir`: change the working directory\n"+
+		"\n"+
+		"**Commands**\n"+
+		"\n"+
+		"- `new`: new scaffold\n"+
+		"- `run`: run the development server\n"+
+		"\n"+
+		"## bud new\n"+
+		"\n"+
+		"new scaffold\n"+
+		"\n"+
+		"```sh\n"+
+		"bud new [command]\n"+
+		"```\n"+
+		"\n"+
+		"**Commands**\n"+
+		"\n"+
+		"- `controller`: new controller\n"+
+		"\n"+
+		"### bud new controller\n"+
+		"\n"+
+		"new controller\n"+
+		"\n"+
+		"```sh\n"+
+		"bud new controller\n"+
+		"```\n"+
+		"\n"+
+		"**Examples**\n"+
+		"\n"+
+		"```sh\n"+
+		"# scaffold a posts controller\n"+
+		"$ bud new controller posts\n"+
+		"```\n"+
+		"\n"+
+		"## bud run\n"+
+		"\n"+
+		"run the development server\n"+
+		"\n"+
+		"```sh\n"+
+		"bud run [flags]\n"+
+		"```\n"+
+		"\n"+
+		"**Flags**\n"+
+		"\n"+
+		"- `--[no-]hot`: hot reload the frontend\n"+
+		"- `--log`: log level (one of: debug|info, default: info)\n")
+}
+
+func TestDocsMan(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := docsCLI().Version("v0.1.0")
+	err := commander.Docs(cli).Man(actual)
+	is.NoErr(err)
+	commandertest.Equal(t, actual.String(), `.TH BUD 1 "" "bud v0.1.0"
+.SH NAME
+bud
+.SH SYNOPSIS
+.B bud [flags] [command]
+.SH OPTIONS
+.TP
+\fB\-C, \-\-chdir\fR
+change the working directory
+.SH COMMANDS
+.SS "bud new"
+new scaffold
+.PP
+.B bud new [command]
+.SS "bud new controller"
+new controller
+.PP
+.B bud new controller
+.PP
+scaffold a posts controller
+.PP
+.RS
+$ bud new controller posts
+.RE
+.SS "bud run"
+run the development server
+.PP
+.B bud run [flags]
+.PP
+Options:
+.TP
+\fB\-\-[no\-]hot\fR
+hot reload the frontend
+.TP
+\fB\-\-log\fR
+log level (one of: debug|info, default: info)
+`)
+}
+
+func TestVersion(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("bud").Writer(actual).Version("v0.2.1")
+	cli.Flag("chdir", "change directory").String(nil)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--version"})
+	is.NoErr(err)
+	is.Equal(0, called)
+	is.Equal(actual.String(), "bud v0.2.1\n")
+}
+
+func TestVersionShort(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual).Version("v0.2.1").Commit("abc1234").Built("2022-05-01")
+	cli.Command("run", "run command")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-V", "run"})
+	is.NoErr(err)
+	is.Equal(actual.String(), "bud v0.2.1\ncommit: abc1234\nbuilt: 2022-05-01\n")
+}
+
+func TestVersionTaken(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual).Version("v0.2.1")
+	var verbose bool
+	cli.Flag("verbose", "verbose").Short('V').Bool(&verbose).Default(false)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-V"})
+	is.NoErr(err)
+	is.Equal(verbose, true)
+	is.Equal(actual.String(), "")
+}
+
+func TestHelpVersion(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual).Version("v0.2.1")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    bud {dim}[flags]{reset}
+
+  {bold}Flags:{reset}
+    -V, --version  {dim}show the version{reset}
+
+`)
+}
+
+// captureStderr captures everything written to stderr while fn runs
+func captureStderr(t testing.TB, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+	fn()
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestFlagDeprecated(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	var level string
+	stderr := captureStderr(t, func() {
+		cli := commander.New("cli").Writer(actual)
+		cli.Run(func(ctx context.Context) error {
+			return nil
+		})
+		cli.Flag("log", "log level").Deprecated("use --log-level instead").String(&level).Optional()
+		cli.Flag("log-level", "log level").String(new(string)).Default("info")
+		ctx := context.Background()
+		err := cli.Parse(ctx, []string{"--log", "debug"})
+		is.NoErr(err)
+	})
+	is.Equal(level, "debug")
+	is.Equal(stderr, "warning: --log is deprecated, use --log-level instead\n")
+	isEqual(t, actual.String(), ``)
+}
+
+func TestFlagDeprecatedUnused(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	stderr := captureStderr(t, func() {
+		cli := commander.New("cli").Writer(actual)
+		cli.Run(func(ctx context.Context) error {
+			return nil
+		})
+		cli.Flag("log", "log level").Deprecated("use --log-level instead").String(new(string)).Optional()
+		ctx := context.Background()
+		err := cli.Parse(ctx, []string{})
+		is.NoErr(err)
+	})
+	is.Equal(stderr, "")
+}
+
+func TestCommandDeprecated(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	stderr := captureStderr(t, func() {
+		cli := commander.New("bud").Writer(actual)
+		sub := cli.Command("serve", "serve the app").Deprecated("use bud run instead")
+		sub.Run(func(ctx context.Context) error {
+			called++
+			return nil
+		})
+		ctx := context.Background()
+		err := cli.Parse(ctx, []string{"serve"})
+		is.NoErr(err)
+	})
+	is.Equal(1, called)
+	is.Equal(stderr, "warning: serve is deprecated, use bud run instead\n")
+}
+
+func TestHelpDeprecated(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	cli.Flag("log", "log level").Deprecated("use --log-level instead").String(nil)
+	cli.Command("serve", "serve the app").Deprecated("use run instead")
+	cli.Command("run", "run the app")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    bud {dim}[flags]{reset} {dim}[command]{reset}
+
+  {bold}Flags:{reset}
+    --log  {dim}log level (deprecated: use --log-level instead){reset}
+
+  {bold}Commands:{reset}
+    run    {dim}run the app{reset}
+    serve  {dim}serve the app (deprecated: use run instead){reset}
+
+`)
+}
+
+func TestArgString(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var arg string
+	cli.Arg("arg").String(&arg)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"cool"})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(arg, "cool")
+	isEqual(t, actual.String(), ``)
+}
+
+func TestArgStringDefault(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var arg string
+	cli.Arg("arg").String(&arg).Default("default")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(arg, "default")
+	isEqual(t, actual.String(), ``)
+}
+
+func TestArgStringRequired(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var arg string
+	cli.Arg("arg").String(&arg)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.Equal(err.Error(), "missing arg")
+}
+
+func TestSubArgString(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var arg string
+	cli.Command("build", "build command")
+	cli.Command("run", "run command")
+	cli.Arg("arg").String(&arg)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"deploy"})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(arg, "deploy")
+	isEqual(t, actual.String(), ``)
+}
+
+// TestInterrupt tests interrupts canceling context. It spawns a copy of itself
+// to run a subcommand. I learned this trick from Mitchell Hashimoto's excellent
+// "Advanced Testing with Go" talk. We use stdout to synchronize between the
+// process and subprocess.
+func TestInterrupt(t *testing.T) {
+	is := is.New(t)
+	if value := os.Getenv("TEST_INTERRUPT"); value == "" {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		// Ignore -test.count otherwise this will continue recursively
+		var args []string
+		for _, arg := range os.Args[1:] {
+			if strings.HasPrefix(arg, "-test.count=") {
+				continue
+			}
+			args = append(args, arg)
+		}
+		cmd := exec.CommandContext(ctx, os.Args[0], append(args, "-test.v=true", "-test.run=^TestInterrupt$")...)
+		cmd.Env = append(os.Environ(), "TEST_INTERRUPT=1")
+		stdout, err := cmd.StdoutPipe()
+		is.NoErr(err)
+		cmd.Stderr = os.Stderr
+		is.NoErr(cmd.Start())
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "ready" {
+				break
+			}
+		}
+		cmd.Process.Signal(os.Interrupt)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "cancelled" {
+				break
+			}
+		}
+		if err := cmd.Wait(); err != nil {
+			is.True(errors.Is(err, context.Canceled))
+		}
+		return
+	}
+	cli := commander.New("cli")
+	cli.Run(func(ctx context.Context) error {
+		os.Stdout.Write([]byte("ready\n"))
+		<-ctx.Done()
+		os.Stdout.Write([]byte("cancelled\n"))
+		return nil
+	})
+	ctx := context.Background()
+	if err := cli.Parse(ctx, []string{}); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		is.NoErr(err)
+	}
+}
+
+func TestHelpExamples(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	cli.Command("run", "run your application")
+	cli.Example("bud run", "")
+	{
+		cli := cli.Command("new", "new scaffold")
+		cli.Command("controller", "new controller").
+			Example("bud new controller posts", "scaffold a posts controller").
+			Example("bud new controller users index show", "scaffold users with actions")
+	}
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    bud {dim}[command]{reset}
+
+  {bold}Commands:{reset}
+    new  {dim}new scaffold{reset}
+    run  {dim}run your application{reset}
+
+  {bold}Examples:{reset}
+    $ bud run
+
+`)
+	actual.Reset()
+	err = cli.Parse(ctx, []string{"new", "controller", "-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    controller
+
+  {bold}Examples:{reset}
+    {dim}# scaffold a posts controller{reset}
+    $ bud new controller posts
+
+    {dim}# scaffold users with actions{reset}
+    $ bud new controller users index show
+
+`)
+}
+
+func TestHelpDescription(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	cli.Command("build", "build your application").
+		Description("Build compiles your application into a single binary.\n\nThe binary is written to bud/app.")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"build", "-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    build
+
+  {bold}Description:{reset}
+    Build compiles your application into a single binary.
+
+    The binary is written to bud/app.
+
+`)
+	actual.Reset()
+	cli.Width(40)
+	err = cli.Parse(ctx, []string{"build", "-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    build
+
+  {bold}Description:{reset}
+    Build compiles your application into
+    a single binary.
+
+    The binary is written to bud/app.
+
+`)
+}
+
+func raise(sig os.Signal) error {
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		return err
+	}
+	return p.Signal(sig)
+}
+
+func TestSignals(t *testing.T) {
+	is := is.New(t)
+	cli := commander.New("cli").Signals(os.Interrupt, syscall.SIGTERM)
+	cli.Run(func(ctx context.Context) error {
+		if err := raise(os.Interrupt); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Second):
+			return errors.New("expected the context to be cancelled")
+		}
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.True(errors.Is(err, context.Canceled))
+}
+
+func TestNoSignals(t *testing.T) {
+	is := is.New(t)
+	// Catch the interrupt so it doesn't kill the test process
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt)
+	defer signal.Stop(ch)
+	cli := commander.New("cli").Signals()
+	cli.Run(func(ctx context.Context) error {
+		if err := raise(os.Interrupt); err != nil {
+			return err
+		}
+		<-ch
+		select {
+		case <-ctx.Done():
+			return errors.New("expected the context not to be cancelled")
+		case <-time.After(50 * time.Millisecond):
+			return nil
+		}
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+}
+
+func TestGraceShutdown(t *testing.T) {
+	is := is.New(t)
+	cli := commander.New("cli").Grace(time.Second)
+	cli.Run(func(ctx context.Context) error {
+		if err := raise(os.Interrupt); err != nil {
+			return err
+		}
+		<-ctx.Done()
+		return nil
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.True(errors.Is(err, context.Canceled))
+}
+
+func TestGraceTimeout(t *testing.T) {
+	is := is.New(t)
+	cli := commander.New("cli").Grace(50 * time.Millisecond)
+	done := make(chan struct{})
+	defer close(done)
+	cli.Run(func(ctx context.Context) error {
+		if err := raise(os.Interrupt); err != nil {
+			return err
+		}
+		<-ctx.Done()
+		<-done // ignore the cancellation
+		return nil
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.True(errors.Is(err, commander.ErrForceQuit))
+	is.Equal(err.Error(), "commander: forced to quit: shutdown took longer than 50ms")
+}
+
+func TestGraceSecondSignal(t *testing.T) {
+	is := is.New(t)
+	cli := commander.New("cli").Grace(time.Minute)
+	done := make(chan struct{})
+	defer close(done)
+	cli.Run(func(ctx context.Context) error {
+		if err := raise(os.Interrupt); err != nil {
+			return err
+		}
+		<-ctx.Done()
+		if err := raise(os.Interrupt); err != nil {
+			return err
+		}
+		<-done // ignore the cancellation
+		return nil
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.True(errors.Is(err, commander.ErrForceQuit))
+	is.Equal(err.Error(), "commander: forced to quit: received a second signal")
+}
+
+func TestArgsStrings(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var args []string
+	cli.Command("build", "build command")
+	cli.Command("run", "run command")
+	cli.Args("custom").Strings(&args)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"new", "view"})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(len(args), 2)
+	is.Equal(args[0], "new")
+	is.Equal(args[1], "view")
+	isEqual(t, actual.String(), ``)
+}
+
+func TestArgsTerminator(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	var trace []string
+	var args []string
+	var flag bool
+	cli.Run(func(ctx context.Context) error {
+		trace = append(trace, "bud")
+		return nil
+	})
+	cli.Flag("flag", "bud flag").Bool(&flag).Default(false)
+	cli.Args("args").Strings(&args)
+	{
+		sub := cli.Command("run", "run your application")
+		sub.Run(func(ctx context.Context) error {
+			trace = append(trace, "run")
+			return nil
+		})
+	}
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--", "run", "--flag", "--"})
+	is.NoErr(err)
+	is.Equal(len(trace), 1)
+	is.Equal(trace[0], "bud")
+	is.Equal(flag, false)
+	is.Equal(len(args), 3)
+	is.Equal(args[0], "run")
+	is.Equal(args[1], "--flag")
+	is.Equal(args[2], "--")
+}
+
+func TestSubArgsTerminator(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	var args []string
+	var flag bool
+	sub := cli.Command("run", "run your application")
+	sub.Flag("flag", "run flag").Bool(&flag).Default(false)
+	sub.Args("args").Strings(&args)
+	sub.Run(func(ctx context.Context) error {
+		return nil
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"run", "--flag", "--", "--inspect", "-v"})
+	is.NoErr(err)
+	is.Equal(flag, true)
+	is.Equal(len(args), 2)
+	is.Equal(args[0], "--inspect")
+	is.Equal(args[1], "-v")
+}
+
+func TestInterspersed(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cp").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return nil
+	})
+	var src, dst string
+	var force bool
+	var mode int
+	cli.Flag("force", "overwrite files").Short('f').Bool(&force).Default(false)
+	cli.Flag("mode", "file mode").Int(&mode).Default(0644)
+	cli.Arg("src").String(&src)
+	cli.Arg("dst").String(&dst)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"a.txt", "--mode", "600", "b.txt", "-f"})
+	is.NoErr(err)
+	is.Equal(1, called)
+	is.Equal(src, "a.txt")
+	is.Equal(dst, "b.txt")
+	is.Equal(force, true)
+	is.Equal(mode, 600)
+}
+
+func TestInterspersedRequired(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var src, dst string
+	cli.Flag("dst", "destination").String(&dst)
+	cli.Arg("src").String(&src)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"a.txt", "--dst", "b.txt"})
+	is.NoErr(err)
+	is.Equal(src, "a.txt")
+	is.Equal(dst, "b.txt")
+}
+
+func TestInterspersedTerminator(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var force bool
+	var args []string
+	cli.Flag("force", "force").Bool(&force).Default(false)
+	cli.Args("args").Strings(&args)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"a", "--force", "--", "b", "--force"})
+	is.NoErr(err)
+	is.Equal(force, true)
+	is.Equal(len(args), 3)
+	is.Equal(args[0], "a")
+	is.Equal(args[1], "b")
+	is.Equal(args[2], "--force")
+}
+
+func TestInterspersedHelp(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cmd := commander.New("cp").Writer(actual)
+	cmd.Arg("src").String(nil)
+	ctx := context.Background()
+	err := cmd.Parse(ctx, []string{"a.txt", "-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    cp {dim}<src>{reset}
+
+`)
+}
+
+func TestNotInterspersed(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	var args []string
+	var chdir string
+	cli.Flag("chdir", "change directory").Short('C').String(&chdir).Default(".")
+	cli.Args("args").Strings(&args)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	cli.Interspersed(false)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-C", "app", "custom", "--flag", "-C", "x"})
+	is.NoErr(err)
+	is.Equal(chdir, "app")
+	is.Equal(len(args), 4)
+	is.Equal(args[0], "custom")
+	is.Equal(args[1], "--flag")
+}
+
+func TestSubInterspersed(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	var path string
+	var actions []string
+	var force bool
+	sub := cli.Command("new", "new scaffold")
+	sub.Flag("force", "overwrite").Bool(&force).Default(false)
+	sub.Arg("path").String(&path)
+	sub.Args("actions").Strings(&actions)
+	sub.Run(func(ctx context.Context) error {
+		return nil
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"new", "posts", "index", "--force", "show"})
+	is.NoErr(err)
+	is.Equal(path, "posts")
+	is.Equal(force, true)
+	is.Equal(len(actions), 2)
+	is.Equal(actions[0], "index")
+	is.Equal(actions[1], "show")
+}
+
+func TestUsageError(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	called := 0
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		called++
+		return commander.Usage()
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    cli
+
+`)
+}
+
+func TestExitCodeUsage(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Flag("log", "log level").String(nil)
+	cli.Run(func(ctx context.Context) error { return nil })
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--log"})
+	is.True(errors.Is(err, commander.ErrUsage))
+	is.Equal(commander.ExitCode(err), 2)
+}
+
+func TestExitCodeRun(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	errBuild := errors.New("build failed")
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error { return errBuild })
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.Equal(err, errBuild)
+	is.Equal(commander.ExitCode(err), 1)
+}
+
+func TestExitCodeCanceled(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error { return nil })
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := cli.Parse(ctx, []string{})
+	is.True(errors.Is(err, context.Canceled))
+	is.Equal(commander.ExitCode(err), 130)
+	is.Equal(commander.ExitCode(nil), 0)
+}
+
+func TestExec(t *testing.T) {
+	is := is.New(t)
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"cli", "blargle"}
+	code := 0
+	stderr := captureStderr(t, func() {
+		cli := commander.New("cli").Writer(new(bytes.Buffer))
+		cli.Run(func(ctx context.Context) error { return nil })
+		code = cli.Exec(context.Background())
+	})
+	is.Equal(code, 2)
+	is.Equal(stderr, "cli: unexpected blargle\n")
+}
+
+func TestErrWriter(t *testing.T) {
+	is := is.New(t)
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"cli", "blargle"}
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(stdout).ErrWriter(stderr)
+	cli.Run(func(ctx context.Context) error { return nil })
+	code := cli.Exec(context.Background())
+	is.Equal(code, 2)
+	is.Equal(stdout.String(), "")
+	is.Equal(stderr.String(), "cli: unexpected blargle\n")
+}
+
+func TestErrWriterWarning(t *testing.T) {
+	is := is.New(t)
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(stdout).ErrWriter(stderr)
+	cli.Flag("old", "old flag").Deprecated("use --new").Bool(new(bool)).Default(false)
+	cli.Run(func(ctx context.Context) error { return nil })
+	err := cli.Parse(context.Background(), []string{"--old"})
+	is.NoErr(err)
+	is.Equal(stdout.String(), "")
+	is.Equal(stderr.String(), "warning: --old is deprecated, use --new\n")
+}
+
+// writeExecutable writes a shell script to dir
+func writeExecutable(t testing.TB, dir, name, script string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExternal(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	writeExecutable(t, dir, "cli-hello", `echo "hello $@ from $GREETING"`)
+	t.Setenv("PATH", dir+string(filepath.ListSeparator)+os.Getenv("PATH"))
+	t.Setenv("GREETING", "plugin")
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual).External(true)
+	var log string
+	cli.Flag("log", "log level").String(&log).Default("info")
+	cli.Command("run", "run your application").Run(func(ctx context.Context) error { return nil })
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--log=debug", "hello", "world", "--name=bud"})
+	is.NoErr(err)
+	is.Equal(log, "debug")
+	is.Equal(actual.String(), "hello world --name=bud from plugin\n")
+}
+
+func TestExternalSubcommand(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	writeExecutable(t, dir, "cli-tool-hello", `echo "hello $@"`)
+	t.Setenv("PATH", dir+string(filepath.ListSeparator)+os.Getenv("PATH"))
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual).External(true)
+	cli.Command("tool", "run a tool")
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"tool", "hello", "world"})
+	is.NoErr(err)
+	is.Equal(actual.String(), "hello world\n")
+}
+
+func TestExternalExitCode(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	writeExecutable(t, dir, "cli-fail", `echo "failed" >&2; exit 3`)
+	t.Setenv("PATH", dir+string(filepath.ListSeparator)+os.Getenv("PATH"))
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"cli", "fail"}
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(stdout).ErrWriter(stderr).External(true)
+	code := cli.Exec(context.Background())
+	is.Equal(code, 3)
+	is.Equal(stderr.String(), "failed\n")
+}
+
+func TestExternalDisabled(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	writeExecutable(t, dir, "cli-hello", `echo "hello"`)
+	t.Setenv("PATH", dir+string(filepath.ListSeparator)+os.Getenv("PATH"))
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"hello"})
+	is.True(err != nil)
+	is.Equal(err.Error(), "unexpected hello")
+}
+
+type deployConfig struct {
+	Region   string `json:"region"`
+	Replicas int    `json:"replicas"`
+}
+
+func TestFlagJSON(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var config deployConfig
+	cli.Flag("config", "deploy config").JSON(&config)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--config", `{"region":"us-east-1","replicas":3}`})
+	is.NoErr(err)
+	is.Equal(config.Region, "us-east-1")
+	is.Equal(config.Replicas, 3)
+}
+
+func TestFlagJSONFile(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "deploy.json")
+	err := os.WriteFile(path, []byte(`{"region":"eu-west-1","replicas":2}`), 0644)
+	is.NoErr(err)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var config deployConfig
+	cli.Flag("config", "deploy config").JSON(&config)
+	ctx := context.Background()
+	err = cli.Parse(ctx, []string{"--config", "@" + path})
+	is.NoErr(err)
+	is.Equal(config.Region, "eu-west-1")
+	is.Equal(config.Replicas, 2)
+}
+
+func TestFlagJSONInvalid(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var config deployConfig
+	cli.Flag("config", "deploy config").JSON(&config)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--config", `{"replicas":"three"}`})
+	is.True(err != nil)
+	is.True(strings.HasPrefix(err.Error(), `invalid value "{\"replicas\":\"three\"}" for flag -config: unable to decode JSON.`))
+	is.Equal(commander.ExitCode(err), 2)
+}
+
+func TestFlagJSONDefault(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var config deployConfig
+	cli.Flag("config", "deploy config").JSON(&config).Default(`{"replicas":1}`)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.Equal(config.Replicas, 1)
+}
+
+func TestArgJSONRequired(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return nil
+	})
+	var config deployConfig
+	cli.Arg("config").JSON(&config)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.Equal(err.Error(), "missing config")
+}
+
+func TestExit(t *testing.T) {
+	is := is.New(t)
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"cli", "build"}
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cleaned := false
+	cli := commander.New("cli").Writer(stdout).ErrWriter(stderr)
+	cli.Command("build", "build your application").Run(func(ctx context.Context) error {
+		defer func() { cleaned = true }()
+		return commander.Exit(3, "build failed")
+	})
+	code := cli.Exec(context.Background())
+	is.Equal(code, 3)
+	is.True(cleaned)
+	is.Equal(stderr.String(), "cli: build failed\n")
+}
+
+func TestExitWrapped(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error {
+		return fmt.Errorf("deploy: %w", commander.Exit(4, "no credentials"))
+	})
+	err := cli.Parse(context.Background(), []string{})
+	is.True(err != nil)
+	is.Equal(err.Error(), "deploy: no credentials")
+	is.Equal(commander.ExitCode(err), 4)
+}
+
+func TestExitSilent(t *testing.T) {
+	is := is.New(t)
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"cli"}
+	stderr := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(new(bytes.Buffer)).ErrWriter(stderr)
+	cli.Run(func(ctx context.Context) error {
+		return commander.Exit(5, "")
+	})
+	code := cli.Exec(context.Background())
+	is.Equal(code, 5)
+	is.Equal(stderr.String(), "")
+}
+
+func TestDefaultCommand(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	trace := []string{}
+	cli.Run(func(ctx context.Context) error {
+		trace = append(trace, "bud")
+		return nil
+	})
+	var port string
+	run := cli.Command("run", "run your application").Default()
+	run.Flag("port", "port").String(&port).Default("3000")
+	run.Run(func(ctx context.Context) error {
+		trace = append(trace, "run:"+port)
+		return nil
+	})
+	cli.Command("build", "build your application").Run(func(ctx context.Context) error {
+		trace = append(trace, "build")
+		return nil
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.NoErr(err)
+	is.Equal(trace, []string{"run:3000"})
+}
+
+func TestDefaultCommandFlags(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	var port string
+	var files []string
+	run := cli.Command("run", "run your application").Default()
+	run.Flag("port", "port").String(&port).Default("3000")
+	run.Args("files").Strings(&files)
+	run.Run(func(ctx context.Context) error { return nil })
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"main.go", "--port=8080"})
+	is.NoErr(err)
+	is.Equal(port, "8080")
+	is.Equal(files, []string{"main.go"})
+}
+
+func TestDefaultCommandTopLevelArgs(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	trace := []string{}
+	var dir string
+	cli.Arg("dir").String(&dir).Optional()
+	cli.Run(func(ctx context.Context) error {
+		trace = append(trace, "bud:"+dir)
+		return nil
+	})
+	cli.Command("run", "run your application").Default().Run(func(ctx context.Context) error {
+		trace = append(trace, "run")
+		return nil
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"app"})
+	is.NoErr(err)
+	is.Equal(trace, []string{"bud:app"})
+}
+
+func TestDefaultCommandExplicit(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	trace := []string{}
+	cli.Command("run", "run your application").Default().Run(func(ctx context.Context) error {
+		trace = append(trace, "run")
+		return nil
+	})
+	cli.Command("build", "build your application").Run(func(ctx context.Context) error {
+		trace = append(trace, "build")
+		return nil
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"build"})
+	is.NoErr(err)
+	is.Equal(trace, []string{"build"})
+}
+
+func TestFlagShortOnly(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error { return nil })
+	var dryRun bool
+	cli.Flag("n", "dry run").Bool(&dryRun).Default(false)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-n"})
+	is.NoErr(err)
+	is.Equal(dryRun, true)
+}
+
+func TestFlagShortOnlyRequired(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error { return nil })
+	cli.Flag("o", "output file").String(nil)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.Equal(err.Error(), "missing -o")
+}
+
+func TestHelpFlagShortOnly(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Flag("log", "log level").Short('L').String(nil)
+	cli.Flag("n", "dry run").Bool(nil).Default(true)
+	cli.Flag("embed", "embed assets").Bool(nil)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    cli {dim}[flags]{reset}
+
+  {bold}Flags:{reset}
+    -L, --log  {dim}log level{reset}
+    -n         {dim}dry run{reset}
+    --embed    {dim}embed assets{reset}
+
+`)
+}
+
+func TestFlagShortCombined(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error { return nil })
+	var all, force, quiet bool
+	var verbose int
+	cli.Flag("all", "all files").Short('a').Bool(&all).Default(false)
+	cli.Flag("force", "force").Short('f').Bool(&force).Default(false)
+	cli.Flag("q", "quiet").Bool(&quiet).Default(false)
+	cli.Flag("verbose", "verbosity").Short('v').Count(&verbose)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-afqvv"})
+	is.NoErr(err)
+	is.True(all)
+	is.True(force)
+	is.True(quiet)
+	is.Equal(verbose, 2)
+}
+
+func TestFlagShortCombinedValue(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error { return nil })
+	var force bool
+	var output string
+	var files []string
+	cli.Flag("force", "force").Short('f').Bool(&force).Default(false)
+	cli.Flag("output", "output file").Short('o').String(&output)
+	cli.Args("files").Strings(&files)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-fo", "out.txt", "a.txt"})
+	is.NoErr(err)
+	is.True(force)
+	is.Equal(output, "out.txt")
+	is.Equal(files, []string{"a.txt"})
+}
+
+func TestFlagShortCombinedLongName(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("cli").Writer(actual)
+	cli.Run(func(ctx context.Context) error { return nil })
+	var l, o, g bool
+	var log string
+	cli.Flag("l", "").Bool(&l).Default(false)
+	cli.Flag("o", "").Bool(&o).Default(false)
+	cli.Flag("g", "").Bool(&g).Default(false)
+	cli.Flag("log", "log level").String(&log)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-log", "info"})
+	is.NoErr(err)
+	is.Equal(log, "info")
+	is.True(!l && !o && !g)
+}
+
+type buildCommand struct {
+	Minify  bool              `flag:"minify" help:"minify output" default:"true"`
+	Embed   bool              `flag:"embed" help:"embed assets"`
+	Port    int               `flag:"port" help:"port to listen on" short:"p" default:"3000"`
+	Log     string            `flag:"log" help:"log level" env:"BIND_LOG" default:"info"`
+	Timeout time.Duration     `flag:"timeout" help:"build timeout" default:"1m"`
+	Vars    map[string]string `flag:"var" help:"build variables"`
+	Dir     string            `arg:"dir" default:"."`
+	Files   []string          `args:"files"`
+	ignored string
+	ran     bool
+}
+
+func (b *buildCommand) Run(ctx context.Context) error {
+	b.ran = true
+	return nil
+}
+
+func TestBind(t *testing.T) {
+	is := is.New(t)
+	t.Setenv("BIND_LOG", "debug")
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	build := new(buildCommand)
+	commander.Bind(cli.Command("build", "build your app"), build)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"build", "--no-minify", "-p", "8080", "--var", "a:1", "app", "a.go", "b.go"})
+	is.NoErr(err)
+	is.True(build.ran)
+	is.Equal(build.Minify, false)
+	is.Equal(build.Embed, false)
+	is.Equal(build.Port, 8080)
+	is.Equal(build.Log, "debug")
+	is.Equal(build.Timeout, time.Minute)
+	is.Equal(build.Vars, map[string]string{"a": "1"})
+	is.Equal(build.Dir, "app")
+	is.Equal(build.Files, []string{"a.go", "b.go"})
+}
+
+func TestBindRequired(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	build := new(buildCommand)
+	commander.Bind(cli, build)
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.True(err != nil)
+	is.Equal(err.Error(), "missing --var")
+	is.True(!build.ran)
+}
+
+func TestHelpBind(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	commander.Bind(cli, new(buildCommand))
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"-h"})
+	is.NoErr(err)
+	isEqual(t, actual.String(), `
+  {bold}Usage:{reset}
+    bud {dim}[flags]{reset} {dim}[dir]{reset} {dim}[files...]{reset}
+
+  {bold}Flags:{reset}
+    -p, --port     {dim}port to listen on{reset}
+    --embed        {dim}embed assets{reset}
+    --log          {dim}log level (env: $BIND_LOG){reset}
+    --[no-]minify  {dim}minify output{reset}
+    --timeout      {dim}build timeout (default: 1m){reset}
+    --var          {dim}build variables{reset}
+
+`)
+}
+
+func TestBindUnsupported(t *testing.T) {
+	is := is.New(t)
+	defer func() {
+		is.Equal(recover(), "commander: unable to bind .Ch. unsupported type *chan int")
+	}()
+	cli := commander.New("bud")
+	commander.Bind(cli, &struct {
+		Ch chan int `flag:"ch"`
+	}{})
+}
+
+type testLogger struct {
+	level string
+}
+
+type testDB struct {
+	log *testLogger
+}
+
+func TestProvide(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	var level string
+	cli.Flag("log", "log level").String(&level).Default("info")
+	calls := 0
+	cli.Provide(func() (*testLogger, error) {
+		calls++
+		return &testLogger{level}, nil
+	})
+	cli.Provide(func(ctx context.Context, log *testLogger) *testDB {
+		return &testDB{log}
+	})
+	var db *testDB
+	var log *testLogger
+	cli.Command("migrate", "migrate the database").Run(func(ctx context.Context, d *testDB, l *testLogger) error {
+		db, log = d, l
+		return nil
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--log=debug", "migrate"})
+	is.NoErr(err)
+	is.Equal(calls, 1)
+	is.Equal(log.level, "debug")
+	is.Equal(db.log, log)
+}
+
+func TestProvideError(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	cli.Provide(func() (*testLogger, error) {
+		return nil, errors.New("unable to open log")
+	})
+	called := false
+	cli.Run(func(log *testLogger) error {
+		called = true
+		return nil
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.True(err != nil)
+	is.Equal(err.Error(), "unable to open log")
+	is.True(!called)
+}
+
+func TestProvideMissing(t *testing.T) {
+	is := is.New(t)
+	actual := new(bytes.Buffer)
+	cli := commander.New("bud").Writer(actual)
+	cli.Run(func(ctx context.Context, db *testDB) error {
+		return nil
+	})
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{})
+	is.True(err != nil)
+	is.Equal(err.Error(), "commander: no provider for *commander_test.testDB")
+}
+
+func TestProvideInvalidRun(t *testing.T) {
+	is := is.New(t)
+	defer func() {
+		is.Equal(recover(), "commander: run must be a function that returns an error, not func(context.Context)")
+	}()
+	cli := commander.New("bud")
+	cli.Run(func(ctx context.Context) {})
+}
+
+// wideCLI has hundreds of commands, like a CLI with generated commands
+func wideCLI(w io.Writer) *commander.CLI {
+	cli := commander.New("bud").Writer(w)
+	for i := 0; i < 30; i++ {
+		cli.Flag(fmt.Sprintf("flag-%d", i), "a flag that configures the command in some way").String(nil).Default("value")
+	}
+	for i := 0; i < 500; i++ {
+		cmd := cli.Command(fmt.Sprintf("command-%03d", i), "a generated command that does something useful")
+		cmd.Run(func(ctx context.Context) error { return nil })
+	}
+	return cli
+}
+
+// helpCommand parses -h to get the command without rendering its help
+func helpCommand(b *testing.B, cli *commander.CLI, args ...string) *commander.Command {
+	var command *commander.Command
+	cli.HelpFunc(func(w io.Writer, cmd *commander.Command) error {
+		command = cmd
+		return nil
+	})
+	if err := cli.Parse(context.Background(), append(args, "-h")); err != nil {
+		b.Fatal(err)
+	}
+	return command
+}
+
+func BenchmarkHelp(b *testing.B) {
+	cmd := helpCommand(b, wideCLI(io.Discard))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := commander.RenderHelp(io.Discard, cmd); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkHelpNoColor(b *testing.B) {
+	cmd := helpCommand(b, wideCLI(io.Discard).Color(false))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := commander.RenderHelp(io.Discard, cmd); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReplaceEscapeCodes(b *testing.B) {
+	out := new(bytes.Buffer)
+	cmd := helpCommand(b, wideCLI(out))
+	if err := commander.RenderHelp(out, cmd); err != nil {
+		b.Fatal(err)
+	}
+	help := out.String()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		commandertest.Decode(help)
+	}
+}
+
+func TestIntrospect(t *testing.T) {
+	is := is.New(t)
+	cli := commander.New("bud")
+	cli.Flag("chdir", "change the working directory").Short('C').String(nil).Default(".")
+	cli.Flag("log", "log level").String(nil)
+	cli.Flag("timeout", "request timeout").Duration(nil).Default(time.Minute)
+	cli.Flag("v", "verbosity").Count(nil)
+	build := cli.Command("build", "build the production server")
+	build.Flag("embed", "embed assets").Bool(nil).Default(true)
+	cli.Command("run", "run the development server")
+	flags := cli.Flags()
+	is.Equal(len(flags), 4)
+	is.Equal(flags[0].Name(), "chdir")
+	is.Equal(flags[0].Usage(), "change the working directory")
+	is.Equal(flags[0].Shorthand(), byte('C'))
+	defval, ok := flags[0].Default()
+	is.True(ok)
+	is.Equal(defval, ".")
+	is.Equal(flags[1].Shorthand(), byte(0))
+	_, ok = flags[1].Default()
+	is.True(!ok)
+	defval, ok = flags[2].Default()
+	is.True(ok)
+	is.Equal(defval, "1m")
+	is.Equal(flags[3].Shorthand(), byte('v'))
+	defval, ok = flags[3].Default()
+	is.True(ok)
+	is.Equal(defval, "0")
+	commands := cli.Commands()
+	is.Equal(len(commands), 2)
+	is.Equal(commands[0].Name(), "build")
+	is.Equal(commands[0].Usage(), "build the production server")
+	is.Equal(commands[1].Name(), "run")
+	is.Equal(len(commands[1].Commands()), 0)
+	flags = commands[0].Flags()
+	is.Equal(len(flags), 1)
+	is.Equal(flags[0].Name(), "embed")
+	defval, ok = flags[0].Default()
+	is.True(ok)
+	is.Equal(defval, "true")
+}
+
+func TestUnknownFlagSuggest(t *testing.T) {
+	is := is.New(t)
+	cli := commander.New("cli")
+	cli.Flag("log", "log level").String(new(string)).Default("info")
+	cli.Flag("embed", "embed assets").Bool(new(bool)).Default(false)
+	cli.Args("files").Strings(new([]string))
+	cli.Run(func(ctx context.Context) error { return nil })
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--lgo=debug"})
+	is.True(err != nil)
+	is.True(errors.Is(err, commander.ErrUsage))
+	is.Equal(err.Error(), "unknown flag --lgo, did you mean --log?\n  usage: cli [flags] [files...]")
+}
+
+func TestUnknownFlagSuggestMany(t *testing.T) {
+	is := is.New(t)
+	cli := commander.New("cli")
+	cli.Flag("log", "log level").String(new(string)).Default("info")
+	cli.Flag("lot", "lot size").Int(new(int)).Default(1)
+	cli.Flag("embed", "embed assets").Bool(new(bool)).Default(false)
+	cli.Run(func(ctx context.Context) error { return nil })
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--lo"})
+	is.True(err != nil)
+	is.Equal(err.Error(), "unknown flag --lo, did you mean --log or --lot?\n  usage: cli [flags]")
+}
+
+func TestUnknownFlagNoSuggestion(t *testing.T) {
+	is := is.New(t)
+	cli := commander.New("cli")
+	cli.Flag("log", "log level").String(new(string)).Default("info")
+	cli.Run(func(ctx context.Context) error { return nil })
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"--watch"})
+	is.True(err != nil)
+	is.Equal(err.Error(), "unknown flag --watch\n  usage: cli [flags]")
+}
+
+func TestUnknownFlagSubcommand(t *testing.T) {
+	is := is.New(t)
+	cli := commander.New("bud")
+	cli.Flag("log", "log level").String(new(string)).Default("info")
+	build := cli.Command("build", "build your app")
+	build.Flag("embed", "embed assets").Bool(new(bool)).Default(false)
+	build.Flag("minify", "minify assets").Bool(new(bool)).Default(true)
+	build.Run(func(ctx context.Context) error { return nil })
+	ctx := context.Background()
+	err := cli.Parse(ctx, []string{"build", "--minfy"})
+	is.True(err != nil)
+	is.True(errors.Is(err, commander.ErrUsage))
+	is.Equal(err.Error(), "unknown flag --minfy, did you mean --minify?\n  usage: bud build [flags]")
 }
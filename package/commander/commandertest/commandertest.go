@@ -0,0 +1,108 @@
+// Package commandertest runs command trees in tests and checks their output.
+package commandertest
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/livebud/bud/package/commander"
+	"github.com/matthewmueller/diff"
+)
+
+// Result of running the command
+type Result struct {
+	Stdout string
+	Stderr string
+	Err    error
+	Code   int
+}
+
+// Run the CLI with the args, capturing stdout and stderr separately. The error
+// returned from parsing is kept in the result rather than written to stderr,
+// along with the exit code that Exec would return.
+func Run(ctx context.Context, cli *commander.CLI, args ...string) *Result {
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	cli.Writer(stdout).ErrWriter(stderr)
+	err := cli.Parse(ctx, args)
+	return &Result{
+		Stdout: stdout.String(),
+		Stderr: stderr.String(),
+		Err:    err,
+		Code:   commander.ExitCode(err),
+	}
+}
+
+// ExpectCode fails the test if the command didn't exit with the code
+func (r *Result) ExpectCode(t testing.TB, code int) {
+	t.Helper()
+	if r.Code == code {
+		return
+	}
+	if r.Err != nil {
+		t.Fatalf("commandertest: expected exit code %d but got %d. %s", code, r.Code, r.Err)
+	}
+	t.Fatalf("commandertest: expected exit code %d but got %d", code, r.Code)
+}
+
+// ExpectStdout fails the test if stdout doesn't equal the expected output.
+// Escape codes in stdout are decoded before comparing.
+func (r *Result) ExpectStdout(t testing.TB, expected string) {
+	t.Helper()
+	Equal(t, r.Stdout, expected)
+}
+
+// ExpectStderr fails the test if stderr doesn't equal the expected output.
+// Escape codes in stderr are decoded before comparing.
+func (r *Result) ExpectStderr(t testing.TB, expected string) {
+	t.Helper()
+	Equal(t, r.Stderr, expected)
+}
+
+// escapeCodes are the codes that commander uses to color output
+var escapeCodes = strings.NewReplacer(
+	"\033[0m", `{reset}`,
+	"\033[1m", `{bold}`,
+	"\033[37m", `{dim}`,
+	"\033[4m", `{underline}`,
+	"\033[36m", `{teal}`,
+	"\033[34m", `{blue}`,
+	"\033[33m", `{yellow}`,
+	"\033[31m", `{red}`,
+	"\033[32m", `{green}`,
+)
+
+// Decode replaces escape codes with readable names like {bold} and {reset}
+func Decode(str string) string {
+	return escapeCodes.Replace(str)
+}
+
+var ansi = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// Strip removes all escape codes
+func Strip(str string) string {
+	return ansi.ReplaceAllString(str, "")
+}
+
+// Equal fails the test with a diff if the actual output doesn't equal the
+// expected output. Escape codes in the actual output are decoded first.
+func Equal(t testing.TB, actual, expected string) {
+	t.Helper()
+	actual = Decode(actual)
+	if expected == actual {
+		return
+	}
+	var b bytes.Buffer
+	b.WriteString("\n\x1b[4mExpect\x1b[0m:\n")
+	b.WriteString(expected)
+	b.WriteString("\n\n")
+	b.WriteString("\x1b[4mActual\x1b[0m: \n")
+	b.WriteString(actual)
+	b.WriteString("\n\n")
+	b.WriteString("\x1b[4mDifference\x1b[0m: \n")
+	b.WriteString(diff.String(expected, actual))
+	b.WriteString("\n")
+	t.Fatal(b.String())
+}
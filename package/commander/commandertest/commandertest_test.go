@@ -0,0 +1,78 @@
+package commandertest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/livebud/bud/package/commander"
+	"github.com/livebud/bud/package/commander/commandertest"
+	"github.com/matryer/is"
+)
+
+func TestRun(t *testing.T) {
+	is := is.New(t)
+	cli := commander.New("cli").Version("v0.1.0")
+	cli.Run(func(ctx context.Context) error { return nil })
+	ctx := context.Background()
+	result := commandertest.Run(ctx, cli, "--version")
+	is.NoErr(result.Err)
+	result.ExpectCode(t, 0)
+	result.ExpectStdout(t, "cli v0.1.0\n")
+	result.ExpectStderr(t, "")
+}
+
+func TestRunUsageError(t *testing.T) {
+	is := is.New(t)
+	cli := commander.New("cli")
+	cli.Flag("log", "log level").String(new(string)).Default("info")
+	cli.Run(func(ctx context.Context) error { return nil })
+	ctx := context.Background()
+	result := commandertest.Run(ctx, cli, "--lgo")
+	is.True(result.Err != nil)
+	result.ExpectCode(t, 2)
+	is.Equal(result.Stdout, "")
+}
+
+func TestRunExit(t *testing.T) {
+	cli := commander.New("cli")
+	cli.Run(func(ctx context.Context) error {
+		return commander.Exit(3, "")
+	})
+	ctx := context.Background()
+	result := commandertest.Run(ctx, cli)
+	result.ExpectCode(t, 3)
+}
+
+func TestRunHelp(t *testing.T) {
+	cli := commander.New("cli")
+	cli.Flag("log", "log level").String(new(string)).Default("info")
+	ctx := context.Background()
+	result := commandertest.Run(ctx, cli, "-h")
+	result.ExpectCode(t, 0)
+	result.ExpectStdout(t, `
+  {bold}Usage:{reset}
+    cli {dim}[flags]{reset}
+
+  {bold}Flags:{reset}
+    --log  {dim}log level{reset}
+
+`)
+}
+
+func TestRunStderr(t *testing.T) {
+	cli := commander.New("cli")
+	cli.Flag("debug", "debug mode").Deprecated("use --log").Bool(new(bool)).Default(false)
+	cli.Run(func(ctx context.Context) error { return nil })
+	ctx := context.Background()
+	result := commandertest.Run(ctx, cli, "--debug")
+	result.ExpectCode(t, 0)
+	result.ExpectStdout(t, "")
+	result.ExpectStderr(t, "warning: --debug is deprecated, use --log\n")
+}
+
+func TestDecodeStrip(t *testing.T) {
+	is := is.New(t)
+	str := "\033[1mUsage:\033[0m \033[37;1mcli\033[0m"
+	is.Equal(commandertest.Decode(str), "{bold}Usage:{reset} \033[37;1mcli{reset}")
+	is.Equal(commandertest.Strip(str), "Usage: cli")
+}
@@ -0,0 +1,74 @@
+package commander
+
+import "strconv"
+
+// Count increments each time the flag is repeated, so -v -v -v or -vvv is 3.
+// Counts are always optional and start at 0 unless a default is provided.
+type Count struct {
+	target *int
+	defval int
+}
+
+func (v *Count) Default(value int) {
+	v.defval = value
+}
+
+type countValue struct {
+	inner *Count
+	set   bool
+}
+
+func (v *countValue) verify(displayName string) error {
+	if !v.set {
+		*v.inner.target = v.inner.defval
+	}
+	return nil
+}
+
+func (v *countValue) optional() bool {
+	return true
+}
+
+func (v *countValue) Get() interface{} {
+	if v.set {
+		return *v.inner.target
+	}
+	return v.inner.defval
+}
+
+func (v *countValue) Set(val string) error {
+	// Support an explicit count with --verbose=3
+	if n, err := strconv.Atoi(val); err == nil {
+		*v.inner.target = n
+		v.set = true
+		return nil
+	}
+	increment, err := strconv.ParseBool(val)
+	if err != nil {
+		return err
+	}
+	if !v.set {
+		*v.inner.target = 0
+		v.set = true
+	}
+	if !increment {
+		*v.inner.target = 0
+		return nil
+	}
+	*v.inner.target++
+	return nil
+}
+
+func (v *countValue) String() string {
+	if v.inner == nil {
+		return ""
+	} else if v.set {
+		return strconv.Itoa(*v.inner.target)
+	}
+	return strconv.Itoa(v.inner.defval)
+}
+
+// IsBoolFlag allows -v to increment without a value
+func (v *countValue) IsBoolFlag() bool {
+	return true
+}
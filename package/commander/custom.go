@@ -0,0 +1,79 @@
+package commander
+
+import "fmt"
+
+// Value is the interface for custom flag and argument types
+type Value interface {
+	Set(value string) error
+	String() string
+}
+
+type Custom struct {
+	target   Value
+	defval   *string // default value passed through Set
+	optional bool
+}
+
+// Default value is passed through the custom value's Set method
+func (v *Custom) Default(value string) {
+	v.defval = &value
+}
+
+// Optional leaves the custom value as-is when it's not provided
+func (v *Custom) Optional() {
+	v.optional = true
+}
+
+type customValue struct {
+	inner *Custom
+	set   bool
+}
+
+func (v *customValue) verify(displayName string) error {
+	if v.set {
+		return nil
+	} else if v.inner.defval != nil {
+		if err := v.inner.target.Set(*v.inner.defval); err != nil {
+			return fmt.Errorf("invalid default %q for %s: %w", *v.inner.defval, displayName, err)
+		}
+		return nil
+	} else if v.inner.optional {
+		return nil
+	}
+	return fmt.Errorf("missing %s", displayName)
+}
+
+func (v *customValue) optional() bool {
+	return v.inner.defval != nil || v.inner.optional
+}
+
+func (v *customValue) Get() interface{} {
+	return v.inner.target
+}
+
+func (v *customValue) Set(val string) error {
+	if err := v.inner.target.Set(val); err != nil {
+		return err
+	}
+	v.set = true
+	return nil
+}
+
+func (v *customValue) String() string {
+	if v.inner == nil || v.inner.target == nil {
+		return ""
+	} else if v.set {
+		return v.inner.target.String()
+	} else if v.inner.defval != nil {
+		return *v.inner.defval
+	}
+	return ""
+}
+
+// IsBoolFlag allows custom values to behave like boolean flags
+func (v *customValue) IsBoolFlag() bool {
+	if b, ok := v.inner.target.(interface{ IsBoolFlag() bool }); ok {
+		return b.IsBoolFlag()
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package commander
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Docs generates reference documentation from the command tree
+func Docs(cli *CLI) *Documentation {
+	return &Documentation{cli.root}
+}
+
+type Documentation struct {
+	root *Command
+}
+
+// docCommand is a command along with its full path from the root
+type docCommand struct {
+	*generateCommand
+	path []string
+}
+
+func (d *docCommand) Path() string {
+	return strings.Join(d.path, " ")
+}
+
+// Synopsis is the usage line without any color codes
+func (d *docCommand) Synopsis() string {
+	synopsis := d.Path()
+	if len(d.c.flags) > 0 {
+		synopsis += " [flags]"
+	}
+	for _, arg := range d.Args() {
+		synopsis += " " + arg
+	}
+	return synopsis
+}
+
+// walk the commands depth-first in alphabetical order
+func (d *Documentation) walk(fn func(cmd *docCommand)) {
+	var walk func(cmd *generateCommand, path []string)
+	walk = func(cmd *generateCommand, path []string) {
+		fn(&docCommand{cmd, path})
+		for _, sub := range cmd.Commands() {
+			walk(sub, append(path[:len(path):len(path)], sub.c.name))
+		}
+	}
+	walk(&generateCommand{d.root}, []string{d.root.name})
+}
+
+// Markdown writes the documentation as markdown
+func (d *Documentation) Markdown(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	first := true
+	d.walk(func(cmd *docCommand) {
+		if !first {
+			bw.WriteString("\n")
+		}
+		first = false
+		heading := strings.Repeat("#", min(len(cmd.path), 6))
+		fmt.Fprintf(bw, "%s %s\n\n", heading, cmd.Path())
+		if cmd.c.usage != "" {
+			fmt.Fprintf(bw, "%s\n\n", cmd.c.usage)
+		}
+		if cmd.c.description != "" {
+			fmt.Fprintf(bw, "%s\n\n", cmd.c.description)
+		}
+		fmt.Fprintf(bw, "```sh\n%s\n```\n", cmd.Synopsis())
+		if flags := cmd.Flags(); len(flags) > 0 {
+			bw.WriteString("\n**Flags**\n\n")
+			for _, flag := range flags {
+				bw.WriteString("- `" + flag.synopsis() + "`")
+				if usage := flag.Usage(); usage != "" {
+					bw.WriteString(": " + usage)
+				}
+				bw.WriteString("\n")
+			}
+		}
+		if commands := cmd.Commands(); len(commands) > 0 {
+			bw.WriteString("\n**Commands**\n\n")
+			for _, sub := range commands {
+				bw.WriteString("- `" + sub.c.name + "`")
+				if usage := sub.Usage(); usage != "" {
+					bw.WriteString(": " + usage)
+				}
+				bw.WriteString("\n")
+			}
+		}
+		if len(cmd.c.examples) > 0 {
+			bw.WriteString("\n**Examples**\n\n```sh\n")
+			for i, example := range cmd.c.examples {
+				if i > 0 {
+					bw.WriteString("\n")
+				}
+				if example.Usage != "" {
+					bw.WriteString("# " + example.Usage + "\n")
+				}
+				bw.WriteString("$ " + example.Command + "\n")
+			}
+			bw.WriteString("```\n")
+		}
+	})
+	return bw.Flush()
+}
+
+// Man writes the documentation as a roff man page in section 1
+func (d *Documentation) Man(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	name := d.root.name
+	fmt.Fprintf(bw, ".TH %s 1", roff(strings.ToUpper(name)))
+	if d.root.config.version != "" {
+		fmt.Fprintf(bw, " \"\" \"%s\"", roff(name+" "+d.root.config.version))
+	}
+	bw.WriteString("\n")
+	bw.WriteString(".SH NAME\n")
+	if d.root.usage != "" {
+		fmt.Fprintf(bw, "%s \\- %s\n", roff(name), roff(d.root.usage))
+	} else {
+		fmt.Fprintf(bw, "%s\n", roff(name))
+	}
+	first := true
+	d.walk(func(cmd *docCommand) {
+		if first {
+			bw.WriteString(".SH SYNOPSIS\n")
+		} else {
+			fmt.Fprintf(bw, ".SS \"%s\"\n", roff(cmd.Path()))
+			if cmd.c.usage != "" {
+				fmt.Fprintf(bw, "%s\n.PP\n", roff(cmd.c.usage))
+			}
+		}
+		fmt.Fprintf(bw, ".B %s\n", roff(cmd.Synopsis()))
+		if flags := cmd.Flags(); len(flags) > 0 {
+			if first {
+				bw.WriteString(".SH OPTIONS\n")
+			} else {
+				bw.WriteString(".PP\nOptions:\n")
+			}
+			for _, flag := range flags {
+				fmt.Fprintf(bw, ".TP\n\\fB%s\\fR\n", roff(flag.synopsis()))
+				if usage := flag.Usage(); usage != "" {
+					fmt.Fprintf(bw, "%s\n", roff(usage))
+				}
+			}
+		}
+		for _, example := range cmd.c.examples {
+			bw.WriteString(".PP\n")
+			if example.Usage != "" {
+				fmt.Fprintf(bw, "%s\n.PP\n", roff(example.Usage))
+			}
+			fmt.Fprintf(bw, ".RS\n$ %s\n.RE\n", roff(example.Command))
+		}
+		if first && len(cmd.c.commands) > 0 {
+			bw.WriteString(".SH COMMANDS\n")
+		}
+		first = false
+	})
+	return bw.Flush()
+}
+
+// roff escapes text for man pages
+func roff(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\e`)
+	text = strings.ReplaceAll(text, "-", `\-`)
+	if strings.HasPrefix(text, ".") || strings.HasPrefix(text, "'") {
+		text = `\&` + text
+	}
+	return text
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,90 @@
+package commander
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Duration struct {
+	target *time.Duration
+	defval *time.Duration // default value
+}
+
+func (v *Duration) Default(value time.Duration) {
+	v.defval = &value
+}
+
+func (v *Duration) Optional() {
+	v.defval = new(time.Duration)
+}
+
+type durationValue struct {
+	inner *Duration
+	set   bool
+}
+
+func (v *durationValue) verify(displayName string) error {
+	if v.set {
+		return nil
+	} else if v.inner.defval != nil {
+		*v.inner.target = *v.inner.defval
+		return nil
+	}
+	return fmt.Errorf("missing %s", displayName)
+}
+
+func (v *durationValue) optional() bool {
+	return v.inner.defval != nil
+}
+
+func (v *durationValue) Get() interface{} {
+	if v.set {
+		return *v.inner.target
+	} else if v.inner.defval != nil {
+		return *v.inner.defval
+	}
+	return nil
+}
+
+func (v *durationValue) Set(val string) error {
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return err
+	}
+	*v.inner.target = d
+	v.set = true
+	return nil
+}
+
+func (v *durationValue) String() string {
+	if v.inner == nil {
+		return ""
+	} else if v.set {
+		return formatDuration(*v.inner.target)
+	} else if v.inner.defval != nil {
+		return formatDuration(*v.inner.defval)
+	}
+	return ""
+}
+
+// Default value shown in the help output
+func (v *durationValue) defaultString() (string, bool) {
+	if v.inner == nil || v.inner.defval == nil || *v.inner.defval == 0 {
+		return "", false
+	}
+	return formatDuration(*v.inner.defval), true
+}
+
+// formatDuration trims the trailing zero units from the duration, so 5m0s
+// becomes 5m and 1h0m0s becomes 1h.
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
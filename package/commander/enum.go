@@ -0,0 +1,95 @@
+package commander
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Enum struct {
+	target  *string
+	defval  *string // default value
+	choices []string
+}
+
+func (v *Enum) Default(value string) {
+	if !v.allowed(value) {
+		// Panic is okay here because defaults are set during initialization. We
+		// want to fail fast for invalid usage.
+		panic(fmt.Sprintf("commander: default %q is not one of %s", value, strings.Join(v.choices, ", ")))
+	}
+	v.defval = &value
+}
+
+func (v *Enum) Optional() {
+	v.defval = new(string)
+}
+
+func (v *Enum) allowed(value string) bool {
+	for _, choice := range v.choices {
+		if value == choice {
+			return true
+		}
+	}
+	return false
+}
+
+type enumValue struct {
+	inner *Enum
+	set   bool
+}
+
+func (v *enumValue) verify(displayName string) error {
+	if v.set {
+		return nil
+	} else if v.inner.defval != nil {
+		*v.inner.target = *v.inner.defval
+		return nil
+	}
+	return fmt.Errorf("missing %s", displayName)
+}
+
+func (v *enumValue) optional() bool {
+	return v.inner.defval != nil
+}
+
+func (v *enumValue) Get() interface{} {
+	if v.set {
+		return *v.inner.target
+	} else if v.inner.defval != nil {
+		return *v.inner.defval
+	}
+	return nil
+}
+
+func (v *enumValue) Set(val string) error {
+	if !v.inner.allowed(val) {
+		return fmt.Errorf("expected one of %s", strings.Join(v.inner.choices, ", "))
+	}
+	*v.inner.target = val
+	v.set = true
+	return nil
+}
+
+func (v *enumValue) String() string {
+	if v.inner == nil {
+		return ""
+	} else if v.set {
+		return *v.inner.target
+	} else if v.inner.defval != nil {
+		return *v.inner.defval
+	}
+	return ""
+}
+
+// Choices shown in the help output
+func (v *enumValue) choices() []string {
+	return v.inner.choices
+}
+
+// Default value shown in the help output
+func (v *enumValue) defaultString() (string, bool) {
+	if v.inner == nil || v.inner.defval == nil || *v.inner.defval == "" {
+		return "", false
+	}
+	return *v.inner.defval, true
+}
@@ -0,0 +1,124 @@
+package commander
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// ErrUsage matches errors caused by invalid usage of the command, like unknown
+// flags, missing arguments or invalid values
+var ErrUsage = errors.New("commander: invalid usage")
+
+// usageError is returned for errors that happen before the command runs
+type usageError struct {
+	err error
+}
+
+func (u *usageError) Error() string {
+	return u.err.Error()
+}
+
+func (u *usageError) Unwrap() error {
+	return u.err
+}
+
+func (u *usageError) Is(target error) bool {
+	return target == ErrUsage
+}
+
+// runError is returned by the command's run function
+type runError struct {
+	err error
+}
+
+func (r *runError) Error() string {
+	return r.err.Error()
+}
+
+func (r *runError) Unwrap() error {
+	return r.err
+}
+
+// execute parses the args and runs the command. Errors returned before the
+// command runs are usage errors, otherwise the command's error is returned
+// as-is.
+func (c *Command) execute(ctx context.Context, args []string) error {
+	err := c.parse(ctx, args)
+	if err == nil {
+		return nil
+	}
+	var run *runError
+	if errors.As(err, &run) {
+		return run.err
+	}
+	return &usageError{err}
+}
+
+// exitCoder is implemented by errors that carry their own exit code, like
+// Exit and *exec.ExitError from external subcommands
+type exitCoder interface {
+	ExitCode() int
+}
+
+// Exit returns an error that exits with the given code. This allows commands
+// to exit with specific codes without calling os.Exit and skipping deferred
+// cleanup. The message is written to the error writer unless it's empty.
+func Exit(code int, message string) error {
+	return &exitError{code, message}
+}
+
+type exitError struct {
+	code    int
+	message string
+}
+
+func (e *exitError) Error() string {
+	return e.message
+}
+
+func (e *exitError) ExitCode() int {
+	return e.code
+}
+
+// ExitCode maps an error returned from Parse to an exit code. Usage errors exit
+// with 2, interrupts exit with 130 and other errors exit with 1.
+func ExitCode(err error) int {
+	var coder exitCoder
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, context.Canceled), errors.Is(err, ErrForceQuit):
+		return 130
+	case errors.Is(err, ErrUsage):
+		return 2
+	case errors.As(err, &coder) && coder.ExitCode() >= 0:
+		return coder.ExitCode()
+	default:
+		return 1
+	}
+}
+
+// Exec parses the command-line arguments, runs the command and returns the exit
+// code. Errors are written to the error writer, except for interrupts.
+func (c *CLI) Exec(ctx context.Context) int {
+	return c.exit(c.Parse(ctx, os.Args[1:]))
+}
+
+// exit writes the error and returns the exit code
+func (c *CLI) exit(err error) int {
+	code := ExitCode(err)
+	// External subcommands write their own errors
+	var exitErr *exec.ExitError
+	if code != 0 && code != 130 && err.Error() != "" && !errors.As(err, &exitErr) {
+		fmt.Fprintf(c.config.errWriter, "%s: %s\n", c.root.name, err)
+	}
+	return code
+}
+
+// Main runs the CLI and exits the process with the exit code
+func Main(cli *CLI) {
+	os.Exit(cli.Exec(context.Background()))
+}
@@ -0,0 +1,48 @@
+package commander
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// path of command names from the root to this command
+func (c *Command) path() []string {
+	if c.parent == nil {
+		return []string{c.name}
+	}
+	return append(c.parent.path(), c.name)
+}
+
+// executable name of the command, including the names of the parent commands
+func (c *Command) executable() string {
+	return strings.Join(c.path(), "-")
+}
+
+// lookupExternal finds the external subcommand's executable, returning an
+// empty string if there isn't one
+func (c *Command) lookupExternal(name string) string {
+	if name == "" {
+		return ""
+	}
+	path, err := exec.LookPath(c.executable() + "-" + name)
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// runExternal runs the external subcommand, forwarding the arguments and the
+// environment. The subcommand's exit code is surfaced through ExitCode.
+func (c *Command) runExternal(ctx context.Context, path string, args []string) error {
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = c.config.writer
+	cmd.Stderr = c.config.errWriter
+	cmd.Env = os.Environ()
+	if err := cmd.Run(); err != nil {
+		return &runError{err}
+	}
+	return nil
+}
@@ -1,10 +1,68 @@
 package commander
 
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
 type Flag struct {
-	name  string
-	usage string
-	value value
-	short byte
+	name       string
+	usage      string
+	value      value
+	short      byte
+	env        string
+	validators []func(value string) error
+	deprecated string
+	secret     bool
+}
+
+// Secret masks the input when prompting for the flag
+func (f *Flag) Secret() *Flag {
+	f.secret = true
+	return f
+}
+
+// Name of the flag
+func (f *Flag) Name() string {
+	return f.name
+}
+
+// Usage describes the flag
+func (f *Flag) Usage() string {
+	return f.usage
+}
+
+// Shorthand returns the flag's single character name or 0 if it doesn't have
+// one
+func (f *Flag) Shorthand() byte {
+	if f.short != 0 {
+		return f.short
+	} else if f.shortOnly() {
+		return f.name[0]
+	}
+	return 0
+}
+
+// Default returns the flag's default value, if it has one
+func (f *Flag) Default() (string, bool) {
+	if d, ok := f.value.(defaulter); ok {
+		return d.defaultString()
+	} else if o, ok := f.value.(optioner); !ok || !o.optional() {
+		return "", false
+	}
+	return f.value.String(), true
+}
+
+// Deprecated warns when the flag is used and annotates the help output
+func (f *Flag) Deprecated(message string) *Flag {
+	f.deprecated = message
+	return f
 }
 
 func (f *Flag) Short(short byte) *Flag {
@@ -12,43 +70,355 @@ func (f *Flag) Short(short byte) *Flag {
 	return f
 }
 
+// Env loads the flag's value from an environment variable when the flag isn't
+// passed in. Flags take precedence over the environment, which takes
+// precedence over the default value.
+func (f *Flag) Env(name string) *Flag {
+	f.env = name
+	return f
+}
+
+// Validate the flag's value before it's set. Validators run in the order
+// they're added.
+func (f *Flag) Validate(validate func(value string) error) *Flag {
+	f.validators = append(f.validators, validate)
+	return f
+}
+
+// set validates and sets the value
+func (f *Flag) set(val string) error {
+	for _, validate := range f.validators {
+		if err := validate(val); err != nil {
+			return err
+		}
+	}
+	return f.value.Set(val)
+}
+
+// flagValue returns the value to register with the flag set
+func (f *Flag) flagValue() flag.Value {
+	if len(f.validators) == 0 {
+		return f.value
+	}
+	if b, ok := f.value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+		return &validatedBoolValue{validatedValue{f}}
+	}
+	return &validatedValue{f}
+}
+
+// validatedValue runs the flag's validators before setting the value
+type validatedValue struct {
+	f *Flag
+}
+
+func (v *validatedValue) Set(val string) error {
+	return v.f.set(val)
+}
+
+func (v *validatedValue) String() string {
+	if v.f == nil || v.f.value == nil {
+		return ""
+	}
+	return v.f.value.String()
+}
+
+type validatedBoolValue struct {
+	validatedValue
+}
+
+func (v *validatedBoolValue) IsBoolFlag() bool {
+	return true
+}
+
 func (f *Flag) Int(target *int) *Int {
 	value := &Int{target: target}
 	f.value = &intValue{inner: value}
 	return value
 }
 
+func (f *Flag) Float64(target *float64) *Float64 {
+	value := &Float64{target: target}
+	f.value = &float64Value{inner: value}
+	return value
+}
+
+func (f *Flag) Duration(target *time.Duration) *Duration {
+	value := &Duration{target: target}
+	f.value = &durationValue{inner: value}
+	return value
+}
+
 func (f *Flag) String(target *string) *String {
 	value := &String{target: target}
 	f.value = &stringValue{inner: value}
 	return value
 }
 
+func (f *Flag) Enum(target *string, choices ...string) *Enum {
+	value := &Enum{target: target, choices: choices}
+	f.value = &enumValue{inner: value}
+	return value
+}
+
+func (f *Flag) URL(target *url.URL) *URL {
+	value := &URL{target: target}
+	f.value = &urlValue{inner: value}
+	return value
+}
+
+func (f *Flag) Path(target *string) *Path {
+	value := &Path{target: target}
+	f.value = &pathValue{inner: value}
+	return value
+}
+
+func (f *Flag) Time(target *time.Time) *Time {
+	value := &Time{target: target, layout: time.RFC3339}
+	f.value = &timeValue{inner: value}
+	return value
+}
+
+func (f *Flag) Bytes(target *int64) *Bytes {
+	value := &Bytes{target: target}
+	f.value = &bytesValue{inner: value}
+	return value
+}
+
+func (f *Flag) IP(target *net.IP) *IP {
+	value := &IP{target: target}
+	f.value = &ipValue{inner: value}
+	return value
+}
+
+func (f *Flag) CIDR(target *net.IPNet) *CIDR {
+	value := &CIDR{target: target}
+	f.value = &cidrValue{inner: value}
+	return value
+}
+
 func (f *Flag) Strings(target *[]string) *Strings {
 	value := &Strings{target: target}
 	f.value = &stringsValue{inner: value}
 	return value
 }
 
+func (f *Flag) Ints(target *[]int) *Ints {
+	value := &Ints{target: target}
+	f.value = &intsValue{inner: value}
+	return value
+}
+
+func (f *Flag) Float64s(target *[]float64) *Float64s {
+	value := &Float64s{target: target}
+	f.value = &float64sValue{inner: value}
+	return value
+}
+
 func (f *Flag) StringMap(target *map[string]string) *StringMap {
 	value := &StringMap{target: target}
 	f.value = &stringMapValue{inner: value}
 	return value
 }
 
+func (f *Flag) Count(target *int) *Count {
+	value := &Count{target: target}
+	f.value = &countValue{inner: value}
+	return value
+}
+
 func (f *Flag) Bool(target *bool) *Bool {
 	value := &Bool{target: target}
 	f.value = &boolValue{inner: value}
 	return value
 }
 
-func (f *Flag) verify(name string) error {
-	return f.value.verify("--" + name)
+func (f *Flag) JSON(target interface{}) *JSON {
+	value := &JSON{target: target}
+	f.value = &jsonValue{inner: value}
+	return value
+}
+
+func (f *Flag) Custom(target Value) *Custom {
+	value := &Custom{target: target}
+	f.value = &customValue{inner: value}
+	return value
+}
+
+// negation returns the --no-<name> form of the flag for boolean flags that
+// default to true
+func (f *Flag) negation() (*negatedValue, bool) {
+	b, ok := f.value.(*boolValue)
+	if !ok || !b.negatable() || f.shortOnly() {
+		return nil, false
+	}
+	return &negatedValue{b}, true
+}
+
+func (f *Flag) verify() error {
+	return f.value.verify(f.displayName())
+}
+
+// shortOnly is true for flags with a single character name, like -n
+func (f *Flag) shortOnly() bool {
+	return len(f.name) == 1
+}
+
+// displayName of the flag, e.g. --log or -n for short-only flags
+func (f *Flag) displayName() string {
+	if f.shortOnly() {
+		return "-" + f.name
+	}
+	return "--" + f.name
+}
+
+// isBool returns true if the flag doesn't take a value
+func (f *Flag) isBool() bool {
+	b, ok := f.value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// expandShorts expands combined short flags, turning -abc into -a -b -c and
+// -vvv into -v -v -v so the flag parser sees each one. Like the flag parser,
+// expansion stops at the first positional argument.
+func expandShorts(flags []*Flag, args []string) []string {
+	shorts := map[byte]*Flag{}
+	for _, flag := range flags {
+		if short := flag.Shorthand(); short != 0 {
+			shorts[short] = flag
+		}
+	}
+	if len(shorts) == 0 {
+		return args
+	}
+	expanded := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			return append(expanded, args[i:]...)
+		}
+		if split, ok := splitShorts(flags, shorts, arg); ok {
+			expanded = append(expanded, split...)
+			arg = split[len(split)-1]
+		} else {
+			expanded = append(expanded, arg)
+		}
+		// Skip over the flag's value
+		if takesValue(flags, arg) && i+1 < len(args) {
+			i++
+			expanded = append(expanded, args[i])
+		}
+	}
+	return expanded
+}
+
+// splitShorts splits combined short flags. Every flag except the last must be
+// a boolean, so the last flag can take a value.
+func splitShorts(flags []*Flag, shorts map[byte]*Flag, arg string) ([]string, bool) {
+	if len(arg) < 3 || arg[1] == '-' || strings.Contains(arg, "=") {
+		return nil, false
+	}
+	// Flags can be passed in with a single dash, e.g. -log
+	name := arg[1:]
+	for _, flag := range flags {
+		if flag.name == name || "no-"+flag.name == name {
+			return nil, false
+		}
+	}
+	split := make([]string, 0, len(name))
+	for i := 0; i < len(name); i++ {
+		flag, ok := shorts[name[i]]
+		if !ok || (i < len(name)-1 && !flag.isBool()) {
+			return nil, false
+		}
+		split = append(split, "-"+string(name[i]))
+	}
+	return split, true
+}
+
+// takesValue returns true if the flag argument is followed by a separate value
+func takesValue(flags []*Flag, arg string) bool {
+	name := strings.TrimLeft(arg, "-")
+	if strings.Contains(name, "=") {
+		return false
+	}
+	for _, flag := range flags {
+		if flag.name != name && (flag.short == 0 || string(flag.short) != name) {
+			continue
+		}
+		return !flag.isBool()
+	}
+	return false
+}
+
+// suppliedFlags returns the flags that were passed in on the command-line
+func suppliedFlags(fset *flag.FlagSet, flags []*Flag) map[*Flag]bool {
+	seen := map[string]bool{}
+	fset.Visit(func(f *flag.Flag) {
+		seen[f.Name] = true
+	})
+	supplied := map[*Flag]bool{}
+	for _, flag := range flags {
+		if seen[flag.name] || seen["no-"+flag.name] || (flag.short != 0 && seen[string(flag.short)]) {
+			supplied[flag] = true
+		}
+	}
+	return supplied
+}
+
+// loadEnv sets the flags that weren't supplied from the environment, marking
+// them as supplied
+func loadEnv(flags []*Flag, supplied map[*Flag]bool) error {
+	for _, flag := range flags {
+		if flag.env == "" || supplied[flag] {
+			continue
+		}
+		value, ok := os.LookupEnv(flag.env)
+		if !ok {
+			continue
+		}
+		if err := flag.set(value); err != nil {
+			return fmt.Errorf("invalid value %q for $%s: %w", value, flag.env, err)
+		}
+		supplied[flag] = true
+	}
+	return nil
+}
+
+// warnDeprecated warns about deprecated flags that were supplied
+func warnDeprecated(w io.Writer, flags []*Flag, supplied map[*Flag]bool) {
+	for _, flag := range flags {
+		if flag.deprecated != "" && supplied[flag] {
+			fmt.Fprintf(w, "warning: %s is deprecated, %s\n", flag.displayName(), flag.deprecated)
+		}
+	}
+}
+
+// verifyTogether verifies that if any flag in a group is supplied, all of the
+// flags in that group are supplied
+func verifyTogether(groups [][]string, flags []*Flag, supplied map[*Flag]bool) error {
+	byName := make(map[string]*Flag, len(flags))
+	for _, flag := range flags {
+		byName[flag.name] = flag
+	}
+	for _, group := range groups {
+		var given, missing []string
+		for _, name := range group {
+			if supplied[byName[name]] {
+				given = append(given, "--"+name)
+			} else {
+				missing = append(missing, "--"+name)
+			}
+		}
+		if len(given) > 0 && len(missing) > 0 {
+			return fmt.Errorf("missing %s, which must be used together with %s", strings.Join(missing, ", "), strings.Join(given, ", "))
+		}
+	}
+	return nil
 }
 
 func verifyFlags(flags []*Flag) error {
 	for _, flag := range flags {
-		if err := flag.verify(flag.name); err != nil {
+		if err := flag.verify(); err != nil {
 			return err
 		}
 	}
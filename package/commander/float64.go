@@ -0,0 +1,68 @@
+package commander
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type Float64 struct {
+	target *float64
+	defval *float64
+}
+
+func (v *Float64) Default(value float64) {
+	v.defval = &value
+}
+
+func (v *Float64) Optional() {
+	v.defval = new(float64)
+}
+
+type float64Value struct {
+	inner *Float64
+	set   bool
+}
+
+func (v *float64Value) verify(displayName string) error {
+	if v.set {
+		return nil
+	} else if v.inner.defval != nil {
+		*v.inner.target = *v.inner.defval
+		return nil
+	}
+	return fmt.Errorf("missing %s", displayName)
+}
+
+func (v *float64Value) optional() bool {
+	return v.inner.defval != nil
+}
+
+func (v *float64Value) Get() interface{} {
+	if v.set {
+		return *v.inner.target
+	} else if v.inner.defval != nil {
+		return *v.inner.defval
+	}
+	return nil
+}
+
+func (v *float64Value) Set(val string) error {
+	n, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return err
+	}
+	*v.inner.target = n
+	v.set = true
+	return nil
+}
+
+func (v *float64Value) String() string {
+	if v.inner == nil {
+		return ""
+	} else if v.set {
+		return strconv.FormatFloat(*v.inner.target, 'g', -1, 64)
+	} else if v.inner.defval != nil {
+		return strconv.FormatFloat(*v.inner.defval, 'g', -1, 64)
+	}
+	return ""
+}
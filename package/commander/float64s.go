@@ -0,0 +1,77 @@
+package commander
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Float64s struct {
+	target *[]float64
+	defval *[]float64 // default value
+}
+
+func (v *Float64s) Default(values ...float64) {
+	v.defval = &values
+}
+
+func (v *Float64s) Optional() {
+	v.defval = new([]float64)
+}
+
+type float64sValue struct {
+	inner *Float64s
+	set   bool
+}
+
+func (v *float64sValue) verify(displayName string) error {
+	if v.set {
+		return nil
+	} else if v.inner.defval != nil {
+		*v.inner.target = *v.inner.defval
+		return nil
+	}
+	return fmt.Errorf("missing %s", displayName)
+}
+
+func (v *float64sValue) optional() bool {
+	return v.inner.defval != nil
+}
+
+func (v *float64sValue) Get() interface{} {
+	if v.set {
+		return *v.inner.target
+	} else if v.inner.defval != nil {
+		return *v.inner.defval
+	}
+	return nil
+}
+
+func (v *float64sValue) Set(val string) error {
+	n, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return err
+	}
+	*v.inner.target = append(*v.inner.target, n)
+	v.set = true
+	return nil
+}
+
+func (v *float64sValue) String() string {
+	if v.inner == nil {
+		return ""
+	} else if v.set {
+		return formatFloat64s(*v.inner.target)
+	} else if v.inner.defval != nil {
+		return formatFloat64s(*v.inner.defval)
+	}
+	return ""
+}
+
+func formatFloat64s(values []float64) string {
+	strs := make([]string, len(values))
+	for i, value := range values {
+		strs[i] = strconv.FormatFloat(value, 'g', -1, 64)
+	}
+	return strings.Join(strs, ", ")
+}
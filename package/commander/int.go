@@ -33,6 +33,10 @@ func (v *intValue) verify(displayName string) error {
 	return fmt.Errorf("missing %s", displayName)
 }
 
+func (v *intValue) optional() bool {
+	return v.inner.defval != nil
+}
+
 func (v *intValue) Get() interface{} {
 	return *v.inner.target
 }
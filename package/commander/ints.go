@@ -0,0 +1,77 @@
+package commander
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Ints struct {
+	target *[]int
+	defval *[]int // default value
+}
+
+func (v *Ints) Default(values ...int) {
+	v.defval = &values
+}
+
+func (v *Ints) Optional() {
+	v.defval = new([]int)
+}
+
+type intsValue struct {
+	inner *Ints
+	set   bool
+}
+
+func (v *intsValue) verify(displayName string) error {
+	if v.set {
+		return nil
+	} else if v.inner.defval != nil {
+		*v.inner.target = *v.inner.defval
+		return nil
+	}
+	return fmt.Errorf("missing %s", displayName)
+}
+
+func (v *intsValue) optional() bool {
+	return v.inner.defval != nil
+}
+
+func (v *intsValue) Get() interface{} {
+	if v.set {
+		return *v.inner.target
+	} else if v.inner.defval != nil {
+		return *v.inner.defval
+	}
+	return nil
+}
+
+func (v *intsValue) Set(val string) error {
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		return err
+	}
+	*v.inner.target = append(*v.inner.target, n)
+	v.set = true
+	return nil
+}
+
+func (v *intsValue) String() string {
+	if v.inner == nil {
+		return ""
+	} else if v.set {
+		return formatInts(*v.inner.target)
+	} else if v.inner.defval != nil {
+		return formatInts(*v.inner.defval)
+	}
+	return ""
+}
+
+func formatInts(values []int) string {
+	strs := make([]string, len(values))
+	for i, value := range values {
+		strs[i] = strconv.Itoa(value)
+	}
+	return strings.Join(strs, ", ")
+}
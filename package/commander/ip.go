@@ -0,0 +1,74 @@
+package commander
+
+import (
+	"fmt"
+	"net"
+)
+
+type IP struct {
+	target *net.IP
+	defval *net.IP // default value
+}
+
+func (v *IP) Default(value string) {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		// Panic is okay here because defaults are set during initialization. We
+		// want to fail fast for invalid usage.
+		panic(fmt.Sprintf("commander: invalid default IP address %q", value))
+	}
+	v.defval = &ip
+}
+
+func (v *IP) Optional() {
+	v.defval = new(net.IP)
+}
+
+type ipValue struct {
+	inner *IP
+	set   bool
+}
+
+func (v *ipValue) verify(displayName string) error {
+	if v.set {
+		return nil
+	} else if v.inner.defval != nil {
+		*v.inner.target = *v.inner.defval
+		return nil
+	}
+	return fmt.Errorf("missing %s", displayName)
+}
+
+func (v *ipValue) optional() bool {
+	return v.inner.defval != nil
+}
+
+func (v *ipValue) Get() interface{} {
+	if v.set {
+		return *v.inner.target
+	} else if v.inner.defval != nil {
+		return *v.inner.defval
+	}
+	return nil
+}
+
+func (v *ipValue) Set(val string) error {
+	ip := net.ParseIP(val)
+	if ip == nil {
+		return fmt.Errorf("expected an IP address like 127.0.0.1 or ::1")
+	}
+	*v.inner.target = ip
+	v.set = true
+	return nil
+}
+
+func (v *ipValue) String() string {
+	if v.inner == nil {
+		return ""
+	} else if v.set {
+		return v.inner.target.String()
+	} else if v.inner.defval != nil && *v.inner.defval != nil {
+		return v.inner.defval.String()
+	}
+	return ""
+}
@@ -0,0 +1,85 @@
+package commander
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type JSON struct {
+	target   interface{}
+	defval   *string // default JSON value
+	optional bool
+}
+
+// Default JSON value that's decoded into the target
+func (v *JSON) Default(value string) {
+	v.defval = &value
+}
+
+// Optional leaves the target as-is when it's not provided
+func (v *JSON) Optional() {
+	v.optional = true
+}
+
+type jsonValue struct {
+	inner *JSON
+	set   bool
+}
+
+func (v *jsonValue) verify(displayName string) error {
+	if v.set {
+		return nil
+	} else if v.inner.defval != nil {
+		if err := v.decode([]byte(*v.inner.defval)); err != nil {
+			return fmt.Errorf("invalid default %q for %s: %w", *v.inner.defval, displayName, err)
+		}
+		return nil
+	} else if v.inner.optional {
+		return nil
+	}
+	return fmt.Errorf("missing %s", displayName)
+}
+
+func (v *jsonValue) optional() bool {
+	return v.inner.defval != nil || v.inner.optional
+}
+
+func (v *jsonValue) Get() interface{} {
+	return v.inner.target
+}
+
+// Set decodes inline JSON or the contents of the file when the value starts
+// with "@"
+func (v *jsonValue) Set(val string) error {
+	data := []byte(val)
+	if strings.HasPrefix(val, "@") {
+		var err error
+		data, err = os.ReadFile(val[1:])
+		if err != nil {
+			return err
+		}
+	}
+	if err := v.decode(data); err != nil {
+		return err
+	}
+	v.set = true
+	return nil
+}
+
+func (v *jsonValue) decode(data []byte) error {
+	if err := json.Unmarshal(data, v.inner.target); err != nil {
+		return fmt.Errorf("unable to decode JSON. %w", err)
+	}
+	return nil
+}
+
+func (v *jsonValue) String() string {
+	if v.inner == nil {
+		return ""
+	} else if v.inner.defval != nil {
+		return *v.inner.defval
+	}
+	return ""
+}
@@ -0,0 +1,118 @@
+package commander
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// Loader loads flag values from an external source like a config file. Keys
+// are flag names. Subcommand flags are nested under the subcommand's name.
+//
+//	{ "log": "info", "run": { "port": 3000 } }
+//
+// Decoded JSON, YAML and TOML documents all satisfy this shape.
+type Loader interface {
+	Load() (map[string]interface{}, error)
+}
+
+// configFile loads flag values from a JSON file. A missing file is ignored.
+type configFile string
+
+func (path configFile) Load() (map[string]interface{}, error) {
+	data, err := os.ReadFile(string(path))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return map[string]interface{}{}, nil
+		}
+		return nil, err
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("commander: unable to parse %s. %w", string(path), err)
+	}
+	return values, nil
+}
+
+// section returns the config values for this command
+func (c *Command) section() map[string]interface{} {
+	if c.parent == nil {
+		return c.config.values
+	}
+	parent := c.parent.section()
+	if parent == nil {
+		return nil
+	}
+	section, _ := parent[c.name].(map[string]interface{})
+	return section
+}
+
+// loadConfig sets the flags that weren't supplied from the config values,
+// marking them as supplied
+func loadConfig(flags []*Flag, values map[string]interface{}, supplied map[*Flag]bool) error {
+	if len(values) == 0 {
+		return nil
+	}
+	for _, flag := range flags {
+		value, ok := values[flag.name]
+		if !ok || value == nil || supplied[flag] {
+			continue
+		}
+		for _, str := range configStrings(value, delimiterOf(flag.value)) {
+			if err := flag.set(str); err != nil {
+				return fmt.Errorf("invalid value %q for %s in config: %w", str, flag.displayName(), err)
+			}
+		}
+		supplied[flag] = true
+	}
+	return nil
+}
+
+// delimiterOf returns the delimiter between keys and values for the value
+func delimiterOf(v value) string {
+	if m, ok := v.(*stringMapValue); ok {
+		return m.delimiter()
+	}
+	return ":"
+}
+
+// configStrings turns a decoded config value into the strings passed to Set.
+// Lists set each item and objects set each key/value pair, separated by the
+// delimiter.
+func configStrings(value interface{}, delimiter string) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{v}
+	case bool:
+		return []string{strconv.FormatBool(v)}
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	case []interface{}:
+		strs := []string{}
+		for _, item := range v {
+			strs = append(strs, configStrings(item, delimiter)...)
+		}
+		return strs
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		strs := make([]string, 0, len(keys))
+		for _, key := range keys {
+			for _, str := range configStrings(v[key], delimiter) {
+				strs = append(strs, key+delimiter+str)
+			}
+		}
+		return strs
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
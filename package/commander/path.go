@@ -0,0 +1,116 @@
+package commander
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Path resolves to an absolute path, optionally checking that it exists
+type Path struct {
+	target    *string
+	defval    *string // default value
+	mustExist bool
+	dir       bool
+}
+
+// MustExist requires the path to exist
+func (v *Path) MustExist() *Path {
+	v.mustExist = true
+	return v
+}
+
+// Dir requires the path to be an existing directory
+func (v *Path) Dir() *Path {
+	v.mustExist = true
+	v.dir = true
+	return v
+}
+
+func (v *Path) Default(value string) {
+	v.defval = &value
+}
+
+func (v *Path) Optional() {
+	v.defval = new(string)
+}
+
+// resolve the path to an absolute path and check it
+func (v *Path) resolve(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if !v.mustExist {
+		return abs, nil
+	}
+	fi, err := os.Stat(abs)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("%s does not exist", path)
+		}
+		return "", err
+	}
+	if v.dir && !fi.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", path)
+	}
+	return abs, nil
+}
+
+type pathValue struct {
+	inner *Path
+	set   bool
+}
+
+func (v *pathValue) verify(displayName string) error {
+	if v.set {
+		return nil
+	} else if v.inner.defval != nil {
+		// Optional paths are left empty
+		if *v.inner.defval == "" {
+			*v.inner.target = ""
+			return nil
+		}
+		abs, err := v.inner.resolve(*v.inner.defval)
+		if err != nil {
+			return fmt.Errorf("invalid default for %s: %w", displayName, err)
+		}
+		*v.inner.target = abs
+		return nil
+	}
+	return fmt.Errorf("missing %s", displayName)
+}
+
+func (v *pathValue) optional() bool {
+	return v.inner.defval != nil
+}
+
+func (v *pathValue) Get() interface{} {
+	if v.set {
+		return *v.inner.target
+	} else if v.inner.defval != nil {
+		return *v.inner.defval
+	}
+	return nil
+}
+
+func (v *pathValue) Set(val string) error {
+	abs, err := v.inner.resolve(val)
+	if err != nil {
+		return err
+	}
+	*v.inner.target = abs
+	v.set = true
+	return nil
+}
+
+func (v *pathValue) String() string {
+	if v.inner == nil {
+		return ""
+	} else if v.set {
+		return *v.inner.target
+	} else if v.inner.defval != nil {
+		return *v.inner.defval
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package commander
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Bowery/prompt"
+	"github.com/mattn/go-isatty"
+)
+
+// promptTerminal asks the user for a value in the terminal, masking the input
+// for secrets
+func promptTerminal(question string, secret bool) (string, error) {
+	if secret {
+		return prompt.Password(question)
+	}
+	return prompt.Basic(question, true)
+}
+
+// isTerminal returns true if stdin is connected to a terminal
+func isTerminal() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())
+}
+
+// required is true when the value needs to be provided
+func required(v value) bool {
+	o, ok := v.(optioner)
+	return ok && !o.optional()
+}
+
+// promptFlags asks for the required flags that weren't supplied, marking them
+// as supplied
+func promptFlags(ask func(question string, secret bool) (string, error), flags []*Flag, supplied map[*Flag]bool) error {
+	if ask == nil {
+		return nil
+	}
+	for _, flag := range flags {
+		if supplied[flag] || !required(flag.value) {
+			continue
+		}
+		question := flag.displayName()
+		if flag.usage != "" {
+			question = flag.usage + " (" + question + ")"
+		}
+		value, err := ask(question, flag.secret)
+		if err != nil {
+			return err
+		}
+		if err := flag.set(value); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %w", value, flag.displayName(), err)
+		}
+		supplied[flag] = true
+	}
+	return nil
+}
+
+// promptArgs asks for the required args that weren't passed in
+func promptArgs(ask func(question string, secret bool) (string, error), args []*Arg) error {
+	if ask == nil {
+		return nil
+	}
+	for _, arg := range args {
+		if !required(arg.value) {
+			continue
+		}
+		value, err := ask(arg.Name, arg.secret)
+		if err != nil {
+			return err
+		}
+		if err := arg.set(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
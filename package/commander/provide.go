@@ -0,0 +1,106 @@
+package commander
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Provide registers a function that provides a dependency to commands. The
+// provider returns the dependency and optionally an error. Providers may
+// depend on the context and other provided dependencies.
+//
+//	cli.Provide(func() (*Logger, error) { ... })
+//	cli.Run(func(ctx context.Context, log *Logger) error { ... })
+//
+// Dependencies are provided once each time the CLI is parsed, right before the
+// command runs.
+func (c *CLI) Provide(provider interface{}) *CLI {
+	fn := reflect.ValueOf(provider)
+	t := fn.Type()
+	if t.Kind() != reflect.Func || t.NumOut() == 0 || t.NumOut() > 2 ||
+		(t.NumOut() == 2 && t.Out(1) != errorType) {
+		// Panic is okay here because settings commands should be done during
+		// initialization. We want to fail fast for invalid usage.
+		panic(fmt.Sprintf("commander: provider must be a function that returns a value and an optional error, not %s", t))
+	}
+	if c.config.providers == nil {
+		c.config.providers = map[reflect.Type]reflect.Value{}
+	}
+	c.config.providers[t.Out(0)] = fn
+	return c
+}
+
+// runner turns the run function into a function that provides the
+// dependencies when it's called
+func (c *config) runner(run interface{}) func(ctx context.Context) error {
+	if run == nil {
+		return nil
+	} else if fn, ok := run.(func(ctx context.Context) error); ok {
+		return fn
+	}
+	fn := reflect.ValueOf(run)
+	t := fn.Type()
+	if t.Kind() != reflect.Func || t.NumOut() != 1 || t.Out(0) != errorType {
+		// Panic is okay here because settings commands should be done during
+		// initialization. We want to fail fast for invalid usage.
+		panic(fmt.Sprintf("commander: run must be a function that returns an error, not %s", t))
+	}
+	return func(ctx context.Context) error {
+		in, err := c.resolveAll(ctx, t, map[reflect.Type]reflect.Value{}, map[reflect.Type]bool{})
+		if err != nil {
+			return err
+		}
+		out := fn.Call(in)
+		if err, _ := out[0].Interface().(error); err != nil {
+			return err
+		}
+		return nil
+	}
+}
+
+// resolveAll resolves the function's parameters
+func (c *config) resolveAll(ctx context.Context, fn reflect.Type, cache map[reflect.Type]reflect.Value, seen map[reflect.Type]bool) ([]reflect.Value, error) {
+	in := make([]reflect.Value, fn.NumIn())
+	for i := range in {
+		value, err := c.resolve(ctx, fn.In(i), cache, seen)
+		if err != nil {
+			return nil, err
+		}
+		in[i] = value
+	}
+	return in, nil
+}
+
+// resolve a dependency by calling its provider
+func (c *config) resolve(ctx context.Context, t reflect.Type, cache map[reflect.Type]reflect.Value, seen map[reflect.Type]bool) (reflect.Value, error) {
+	if t == contextType {
+		return reflect.ValueOf(&ctx).Elem(), nil
+	} else if value, ok := cache[t]; ok {
+		return value, nil
+	}
+	provider, ok := c.providers[t]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("commander: no provider for %s", t)
+	} else if seen[t] {
+		return reflect.Value{}, fmt.Errorf("commander: %s depends on itself", t)
+	}
+	seen[t] = true
+	in, err := c.resolveAll(ctx, provider.Type(), cache, seen)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	out := provider.Call(in)
+	if len(out) == 2 {
+		if err, _ := out[1].Interface().(error); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	cache[t] = out[0]
+	return out[0], nil
+}
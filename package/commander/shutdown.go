@@ -0,0 +1,51 @@
+package commander
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// ErrForceQuit is returned when a command doesn't shut down within the grace
+// period or a second signal is received while shutting down
+var ErrForceQuit = errors.New("commander: forced to quit")
+
+// parseGracefully cancels the context on the first signal, then waits up to the
+// grace period for the command to finish. A second signal returns immediately.
+func (c *CLI) parseGracefully(parent context.Context, args []string) error {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	ch := make(chan os.Signal, 2)
+	signal.Notify(ch, c.config.signals...)
+	defer signal.Stop(ch)
+	errc := make(chan error, 1)
+	go func() { errc <- c.root.execute(ctx, args) }()
+	// Wait for the command to finish or the first signal
+	select {
+	case err := <-errc:
+		if err != nil {
+			return err
+		}
+		return ctx.Err()
+	case <-ch:
+		cancel()
+	case <-ctx.Done():
+	}
+	// Give the command some time to shut down
+	timer := time.NewTimer(c.config.grace)
+	defer timer.Stop()
+	select {
+	case err := <-errc:
+		if err != nil {
+			return err
+		}
+		return ctx.Err()
+	case <-ch:
+		return fmt.Errorf("%w: received a second signal", ErrForceQuit)
+	case <-timer.C:
+		return fmt.Errorf("%w: shutdown took longer than %s", ErrForceQuit, c.config.grace)
+	}
+}
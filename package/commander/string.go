@@ -30,6 +30,10 @@ func (v *stringValue) verify(displayName string) error {
 	return fmt.Errorf("missing %s", displayName)
 }
 
+func (v *stringValue) optional() bool {
+	return v.inner.defval != nil
+}
+
 func (v *stringValue) Get() interface{} {
 	if v.set {
 		return *v.inner.target
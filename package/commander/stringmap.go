@@ -3,11 +3,20 @@ package commander
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
 )
 
 type StringMap struct {
-	target *map[string]string
-	defval *map[string]string // default value
+	target    *map[string]string
+	defval    *map[string]string // default value
+	delimiter rune               // zero splits on the first ':' or '='
+}
+
+// Delimiter sets the rune that separates the keys from the values. By default,
+// pairs are split on the first ':' or '='.
+func (v *StringMap) Delimiter(delimiter rune) *StringMap {
+	v.delimiter = delimiter
+	return v
 }
 
 func (v *StringMap) Default(value map[string]string) {
@@ -33,6 +42,10 @@ func (v *stringMapValue) verify(displayName string) error {
 	return fmt.Errorf("missing %s", displayName)
 }
 
+func (v *stringMapValue) optional() bool {
+	return v.inner.defval != nil
+}
+
 func (v *stringMapValue) Get() interface{} {
 	if v.set {
 		return *v.inner.target
@@ -43,18 +56,31 @@ func (v *stringMapValue) Get() interface{} {
 }
 
 func (v *stringMapValue) Set(val string) error {
-	kv := strings.SplitN(val, ":", 2)
-	if len(kv) != 2 {
-		return fmt.Errorf("invalid key:value pair for %q", val)
+	i := strings.IndexAny(val, ":=")
+	if v.inner.delimiter != 0 {
+		i = strings.IndexRune(val, v.inner.delimiter)
+	}
+	if i < 0 {
+		return fmt.Errorf("invalid key%svalue pair for %q", v.delimiter(), val)
 	}
 	if *v.inner.target == nil {
 		*v.inner.target = map[string]string{}
 	}
-	(*v.inner.target)[kv[0]] = kv[1]
+	key := val[:i]
+	_, size := utf8.DecodeRuneInString(val[i:])
+	(*v.inner.target)[key] = val[i+size:]
 	v.set = true
 	return nil
 }
 
+// delimiter between the keys and values
+func (v *stringMapValue) delimiter() string {
+	if v.inner.delimiter == 0 {
+		return ":"
+	}
+	return string(v.inner.delimiter)
+}
+
 func (v *stringMapValue) String() string {
 	if v.inner == nil {
 		return ""
@@ -68,12 +94,13 @@ func (v *stringMapValue) String() string {
 
 // Format as a string
 func (v *stringMapValue) format(kv map[string]string) (out string) {
+	delimiter := v.delimiter()
 	i := 0
 	for k, v := range kv {
 		if i > 0 {
 			out += " "
 		}
-		out += k + ":" + v
+		out += k + delimiter + v
 		i++
 	}
 	return out
@@ -33,6 +33,10 @@ func (v *stringsValue) verify(displayName string) error {
 	return fmt.Errorf("missing %s", displayName)
 }
 
+func (v *stringsValue) optional() bool {
+	return v.inner.defval != nil
+}
+
 func (v *stringsValue) Get() interface{} {
 	if v.set {
 		return *v.inner.target
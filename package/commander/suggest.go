@@ -0,0 +1,103 @@
+package commander
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// maxSuggestions is the most flags suggested for an unknown flag
+const maxSuggestions = 3
+
+// unknownFlag turns the flag parser's error for an unknown flag into an error
+// that suggests the nearest flags and shows how to use the command
+func (c *Command) unknownFlag(err error) error {
+	const prefix = "flag provided but not defined: -"
+	if !strings.HasPrefix(err.Error(), prefix) {
+		return err
+	}
+	name := strings.TrimPrefix(err.Error(), prefix)
+	display := "--" + name
+	if len(name) == 1 {
+		display = "-" + name
+	}
+	var b strings.Builder
+	b.WriteString("unknown flag " + display)
+	if suggestions := c.suggestFlags(name); len(suggestions) > 0 {
+		b.WriteString(", did you mean " + orList(suggestions) + "?")
+	}
+	b.WriteString("\n  usage: " + (&docCommand{&generateCommand{c}, c.path()}).Synopsis())
+	return errors.New(b.String())
+}
+
+// suggestFlags returns the flags that are closest to the name
+func (c *Command) suggestFlags(name string) (suggestions []string) {
+	type suggestion struct {
+		name  string
+		edits int
+	}
+	var candidates []suggestion
+	for _, flag := range c.flags {
+		names := []string{flag.name}
+		if _, ok := flag.negation(); ok {
+			names = append(names, "no-"+flag.name)
+		}
+		for _, candidate := range names {
+			edits := distance(name, candidate)
+			if edits > max(1, len(name)/3) && !similar(name, candidate) {
+				continue
+			}
+			candidates = append(candidates, suggestion{flag.displayName(), edits})
+			break
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].edits < candidates[j].edits
+	})
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		suggestions = append(suggestions, candidates[i].name)
+	}
+	return suggestions
+}
+
+// similar is true when one name contains the other, like no-cache and cache
+func similar(a, b string) bool {
+	if len(a) < 3 || len(b) < 3 {
+		return false
+	}
+	return strings.Contains(a, b) || strings.Contains(b, a)
+}
+
+// distance returns the number of edits to turn a into b, where an edit
+// inserts, deletes or substitutes a character or swaps two adjacent ones
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	next := make([]int, len(b)+1)
+	for j := range curr {
+		curr[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		next[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			next[j] = min(min(curr[j]+1, next[j-1]+1), curr[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				next[j] = min(next[j], prev[j-2]+1)
+			}
+		}
+		prev, curr, next = curr, next, prev
+	}
+	return curr[len(b)]
+}
+
+// orList joins the items into a list like "a, b or c"
+func orList(items []string) string {
+	if len(items) == 1 {
+		return items[0]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " or " + items[len(items)-1]
+}
@@ -0,0 +1,75 @@
+package commander
+
+import (
+	"fmt"
+	"time"
+)
+
+type Time struct {
+	target *time.Time
+	defval *time.Time // default value
+	layout string
+}
+
+// Layout sets the layout used to parse the time. Defaults to RFC3339.
+func (v *Time) Layout(layout string) *Time {
+	v.layout = layout
+	return v
+}
+
+func (v *Time) Default(value time.Time) {
+	v.defval = &value
+}
+
+func (v *Time) Optional() {
+	v.defval = new(time.Time)
+}
+
+type timeValue struct {
+	inner *Time
+	set   bool
+}
+
+func (v *timeValue) verify(displayName string) error {
+	if v.set {
+		return nil
+	} else if v.inner.defval != nil {
+		*v.inner.target = *v.inner.defval
+		return nil
+	}
+	return fmt.Errorf("missing %s", displayName)
+}
+
+func (v *timeValue) optional() bool {
+	return v.inner.defval != nil
+}
+
+func (v *timeValue) Get() interface{} {
+	if v.set {
+		return *v.inner.target
+	} else if v.inner.defval != nil {
+		return *v.inner.defval
+	}
+	return nil
+}
+
+func (v *timeValue) Set(val string) error {
+	t, err := time.Parse(v.inner.layout, val)
+	if err != nil {
+		return fmt.Errorf("expected a time like %q", v.inner.layout)
+	}
+	*v.inner.target = t
+	v.set = true
+	return nil
+}
+
+func (v *timeValue) String() string {
+	if v.inner == nil {
+		return ""
+	} else if v.set {
+		return v.inner.target.Format(v.inner.layout)
+	} else if v.inner.defval != nil && !v.inner.defval.IsZero() {
+		return v.inner.defval.Format(v.inner.layout)
+	}
+	return ""
+}
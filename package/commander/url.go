@@ -0,0 +1,86 @@
+package commander
+
+import (
+	"fmt"
+	"net/url"
+)
+
+type URL struct {
+	target *url.URL
+	defval *url.URL // default value
+}
+
+func (v *URL) Default(value string) {
+	u, err := parseURL(value)
+	if err != nil {
+		// Panic is okay here because defaults are set during initialization. We
+		// want to fail fast for invalid usage.
+		panic(fmt.Sprintf("commander: invalid default URL %q. %s", value, err))
+	}
+	v.defval = u
+}
+
+func (v *URL) Optional() {
+	v.defval = new(url.URL)
+}
+
+type urlValue struct {
+	inner *URL
+	set   bool
+}
+
+func (v *urlValue) verify(displayName string) error {
+	if v.set {
+		return nil
+	} else if v.inner.defval != nil {
+		*v.inner.target = *v.inner.defval
+		return nil
+	}
+	return fmt.Errorf("missing %s", displayName)
+}
+
+func (v *urlValue) optional() bool {
+	return v.inner.defval != nil
+}
+
+func (v *urlValue) Get() interface{} {
+	if v.set {
+		return v.inner.target
+	} else if v.inner.defval != nil {
+		return v.inner.defval
+	}
+	return nil
+}
+
+func (v *urlValue) Set(val string) error {
+	u, err := parseURL(val)
+	if err != nil {
+		return err
+	}
+	*v.inner.target = *u
+	v.set = true
+	return nil
+}
+
+func (v *urlValue) String() string {
+	if v.inner == nil {
+		return ""
+	} else if v.set {
+		return v.inner.target.String()
+	} else if v.inner.defval != nil {
+		return v.inner.defval.String()
+	}
+	return ""
+}
+
+// parseURL parses an absolute URL like http://localhost:3000
+func parseURL(value string) (*url.URL, error) {
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("expected an absolute URL like https://example.com")
+	}
+	return u, nil
+}
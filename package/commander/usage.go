@@ -1,19 +1,69 @@
 package commander
 
 import (
-	"bytes"
+	"io"
 	"sort"
 	"strings"
-	"text/tabwriter"
-	"text/template"
 )
 
-func generateUsage(template *template.Template, c *Command) (string, error) {
-	buf := new(bytes.Buffer)
-	if err := template.Execute(buf, &generateCommand{c}); err != nil {
-		return "", err
+func generateUsage(w io.Writer, c *Command) error {
+	template, err := c.config.usageTemplate()
+	if err != nil {
+		return err
 	}
-	return buf.String(), nil
+	return template.Execute(w, &generateCommand{c})
+}
+
+// indent is how far the flags and commands are indented in the help output
+const indent = 4
+
+// row in the flags or commands section of the help output
+type row struct {
+	name        string
+	description string
+}
+
+// writeRows aligns the descriptions into a column after the names. When the
+// help output has a width, the descriptions wrap with a hanging indent that
+// lines up with the start of the column. The first line isn't indented
+// because the template indents it.
+func writeRows(config *config, rows []row) string {
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row.name))
+	}
+	offset := indent + width + 2
+	padding := strings.Repeat(" ", offset)
+	dim, reset := config.dim(), config.reset()
+	sb := new(strings.Builder)
+	sb.Grow(len(rows) * (offset + 64))
+	for i, row := range rows {
+		if i > 0 {
+			sb.WriteString("\n")
+			sb.WriteString(padding[:indent])
+		}
+		sb.WriteString(row.name)
+		if row.description == "" {
+			continue
+		}
+		sb.WriteString(padding[:width-len(row.name)+2])
+		if config.columns == 0 {
+			sb.WriteString(dim)
+			sb.WriteString(row.description)
+			sb.WriteString(reset)
+			continue
+		}
+		for j, line := range wrap(row.description, config.columns-offset) {
+			if j > 0 {
+				sb.WriteString("\n")
+				sb.WriteString(padding)
+			}
+			sb.WriteString(dim)
+			sb.WriteString(line)
+			sb.WriteString(reset)
+		}
+	}
+	return sb.String()
 }
 
 type generateCommand struct {
@@ -24,32 +74,80 @@ func (g *generateCommand) Name() string {
 	return g.c.name
 }
 
-type generateCommands []*generateCommand
+// Usage returns the command's usage along with any deprecation notice
+func (g *generateCommand) Usage() string {
+	if g.c.deprecated == "" {
+		return g.c.usage
+	} else if g.c.usage == "" {
+		return "(deprecated: " + g.c.deprecated + ")"
+	}
+	return g.c.usage + " (deprecated: " + g.c.deprecated + ")"
+}
 
-func (cmds generateCommands) Usage() (string, error) {
-	buf := new(bytes.Buffer)
-	tw := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
-	for _, cmd := range cmds {
-		tw.Write([]byte("\t\t" + cmd.c.name))
-		if cmd.c.usage != "" {
-			tw.Write([]byte("\t" + dim() + cmd.c.usage + reset()))
+// Description returns the indented description, wrapped to the width of the
+// help output
+func (g *generateCommand) Description() string {
+	if g.c.description == "" {
+		return ""
+	}
+	var lines []string
+	for _, line := range strings.Split(g.c.description, "\n") {
+		if g.c.config.columns == 0 || strings.TrimSpace(line) == "" {
+			lines = append(lines, strings.TrimRight(line, " \t"))
+			continue
 		}
-		tw.Write([]byte("\n"))
+		lines = append(lines, wrap(line, g.c.config.columns-indent)...)
 	}
-	if err := tw.Flush(); err != nil {
-		return "", err
+	padding := strings.Repeat(" ", indent)
+	sb := new(strings.Builder)
+	for i, line := range lines {
+		if i > 0 {
+			sb.WriteString("\n")
+			// Don't pad blank lines between paragraphs
+			if line != "" {
+				sb.WriteString(padding)
+			}
+		}
+		sb.WriteString(line)
 	}
-	return strings.TrimSpace(buf.String()), nil
+	return sb.String()
+}
+
+type generateCommands []*generateCommand
+
+func (cmds generateCommands) Usage() string {
+	if len(cmds) == 0 {
+		return ""
+	}
+	rows := make([]row, len(cmds))
+	for i, cmd := range cmds {
+		rows[i] = row{cmd.c.name, cmd.Usage()}
+	}
+	return writeRows(cmds[0].c.config, rows)
 }
 
 func (g *generateCommand) Args() (args []string) {
 	for i, arg := range g.c.args {
-		// TODO: differentiate between required and optional args
+		name := arg.Name
 		if i == 0 && len(g.c.commands) > 0 {
-			args = append(args, "<command|"+arg.Name+">")
+			name = "command|" + name
+		}
+		if arg.optional() {
+			args = append(args, "["+name+"]")
 			continue
 		}
-		args = append(args, "<"+arg.Name+">")
+		args = append(args, "<"+name+">")
+	}
+	if rest := g.c.restArgs; rest != nil {
+		name := rest.Name + "..."
+		if len(args) == 0 && len(g.c.commands) > 0 {
+			name = "command|" + name
+		}
+		if rest.min > 0 {
+			args = append(args, "<"+name+">")
+		} else {
+			args = append(args, "["+name+"]")
+		}
 	}
 	if len(args) == 0 && len(g.c.commands) > 0 {
 		args = append(args, "[command]")
@@ -57,6 +155,23 @@ func (g *generateCommand) Args() (args []string) {
 	return args
 }
 
+// ArgsUsage describes the args that have usage
+func (g *generateCommand) ArgsUsage() string {
+	var rows []row
+	for _, arg := range g.c.args {
+		if arg.usage != "" {
+			rows = append(rows, row{arg.Name, arg.usage})
+		}
+	}
+	if rest := g.c.restArgs; rest != nil && rest.usage != "" {
+		rows = append(rows, row{rest.Name + "...", rest.usage})
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+	return writeRows(g.c.config, rows)
+}
+
 func (g *generateCommand) Commands() (commands generateCommands) {
 	commands = make(generateCommands, len(g.c.commands))
 	i := 0
@@ -71,10 +186,63 @@ func (g *generateCommand) Commands() (commands generateCommands) {
 	return commands
 }
 
+type generateGroup struct {
+	Name     string
+	Commands generateCommands
+}
+
+// CommandGroups partitions the commands by group. Ungrouped commands come
+// first, followed by the groups in the order they were declared.
+func (g *generateCommand) CommandGroups() (groups []*generateGroup) {
+	commands := g.Commands()
+	if len(commands) == 0 {
+		return nil
+	}
+	byGroup := map[string]generateCommands{}
+	for _, cmd := range commands {
+		byGroup[cmd.c.group] = append(byGroup[cmd.c.group], cmd)
+	}
+	if cmds := byGroup[""]; len(cmds) > 0 {
+		groups = append(groups, &generateGroup{"Commands", cmds})
+	}
+	for _, name := range g.c.config.groups {
+		if cmds := byGroup[name]; len(cmds) > 0 {
+			groups = append(groups, &generateGroup{name, cmds})
+		}
+	}
+	return groups
+}
+
+func (g *generateCommand) Examples() *generateExamples {
+	if len(g.c.examples) == 0 {
+		return nil
+	}
+	return &generateExamples{g.c.config, g.c.examples}
+}
+
+type generateExamples struct {
+	config   *config
+	examples []*Example
+}
+
+func (g *generateExamples) Usage() string {
+	lines := make([]string, 0, len(g.examples)*3)
+	for i, example := range g.examples {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		if example.Usage != "" {
+			lines = append(lines, "    "+g.config.dim()+"# "+example.Usage+g.config.reset())
+		}
+		lines = append(lines, "    $ "+example.Command)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
 func (g *generateCommand) Flags() (flags generateFlags) {
 	flags = make(generateFlags, len(g.c.flags))
 	for i, flag := range g.c.flags {
-		flags[i] = &generateFlag{flag}
+		flags[i] = &generateFlag{flag, g.c.config}
 	}
 	// Sort by name
 	sort.Slice(flags, func(i, j int) bool {
@@ -83,42 +251,75 @@ func (g *generateCommand) Flags() (flags generateFlags) {
 			return flags[i].f.name < flags[j].f.name
 		}
 		// Shorts above non-shorts
-		return flags[i].f.short > flags[j].f.short
+		return hasShort(flags[i])
 	})
 	return flags
 }
 
 func hasShort(flag *generateFlag) bool {
-	return flag.f.short != 0
+	return flag.f.Shorthand() != 0
 }
 
 type generateFlag struct {
-	f *Flag
+	f      *Flag
+	config *config
 }
 
 func (g *generateFlag) Name() string {
 	return g.f.name
 }
 
+// Usage returns the flag's usage along with any additional details
+func (g *generateFlag) Usage() string {
+	var details []string
+	if c, ok := g.f.value.(chooser); ok {
+		details = append(details, "one of: "+strings.Join(c.choices(), "|"))
+	}
+	if d, ok := g.f.value.(defaulter); ok {
+		if defval, ok := d.defaultString(); ok {
+			details = append(details, "default: "+defval)
+		}
+	}
+	if g.f.env != "" {
+		details = append(details, "env: $"+g.f.env)
+	}
+	if g.f.deprecated != "" {
+		details = append(details, "deprecated: "+g.f.deprecated)
+	}
+	if len(details) == 0 {
+		return g.f.usage
+	}
+	suffix := "(" + strings.Join(details, ", ") + ")"
+	if g.f.usage == "" {
+		return suffix
+	}
+	return g.f.usage + " " + suffix
+}
+
+// synopsis of the flag, e.g. -L, --log
+func (g *generateFlag) synopsis() string {
+	if g.f.shortOnly() {
+		return "-" + g.f.name
+	}
+	name := "--" + g.f.name
+	if _, ok := g.f.negation(); ok {
+		name = "--[no-]" + g.f.name
+	}
+	if g.f.short != 0 {
+		return "-" + string(g.f.short) + ", " + name
+	}
+	return name
+}
+
 type generateFlags []*generateFlag
 
-func (flags generateFlags) Usage() (string, error) {
-	buf := new(bytes.Buffer)
-	tw := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
-	for _, flag := range flags {
-		tw.Write([]byte("\t\t"))
-		if flag.f.short != 0 {
-			tw.Write([]byte("-" + string(flag.f.short) + ", "))
-		}
-		tw.Write([]byte("--" + flag.f.name))
-		if flag.f.usage != "" {
-			tw.Write([]byte("\t"))
-			tw.Write([]byte(dim() + flag.f.usage + reset()))
-		}
-		tw.Write([]byte("\n"))
+func (flags generateFlags) Usage() string {
+	if len(flags) == 0 {
+		return ""
 	}
-	if err := tw.Flush(); err != nil {
-		return "", err
+	rows := make([]row, len(flags))
+	for i, flag := range flags {
+		rows[i] = row{flag.synopsis(), flag.Usage()}
 	}
-	return strings.TrimSpace(buf.String()), nil
+	return writeRows(flags[0].config, rows)
 }
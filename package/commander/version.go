@@ -0,0 +1,86 @@
+package commander
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// versionValue is the built-in --version flag
+type versionValue struct {
+	set bool
+}
+
+func (v *versionValue) verify(displayName string) error {
+	return nil
+}
+
+func (v *versionValue) Get() interface{} {
+	return v.set
+}
+
+func (v *versionValue) Set(val string) (err error) {
+	v.set, err = strconv.ParseBool(val)
+	return err
+}
+
+func (v *versionValue) String() string {
+	return strconv.FormatBool(v.set)
+}
+
+func (v *versionValue) IsBoolFlag() bool {
+	return true
+}
+
+// addVersionFlag adds --version and -V to the command, unless they're already
+// taken
+func (c *Command) addVersionFlag() {
+	if c.config.version == "" || c.lookupFlag("version") != nil {
+		return
+	}
+	flag := &Flag{
+		name:  "version",
+		usage: "show the version",
+		value: new(versionValue),
+	}
+	if c.lookupShort('V') == nil {
+		flag.short = 'V'
+	}
+	c.flags = append(c.flags, flag)
+}
+
+// showVersion returns true if --version was passed in
+func (c *Command) showVersion() bool {
+	flag := c.lookupFlag("version")
+	if flag == nil {
+		return false
+	}
+	v, ok := flag.value.(*versionValue)
+	return ok && v.set
+}
+
+func (c *Command) lookupShort(short byte) *Flag {
+	for _, flag := range c.flags {
+		if flag.Shorthand() == short {
+			return flag
+		}
+	}
+	return nil
+}
+
+func printVersion(w io.Writer, name string, config *config) error {
+	if _, err := fmt.Fprintf(w, "%s %s\n", name, config.version); err != nil {
+		return err
+	}
+	if config.commit != "" {
+		if _, err := fmt.Fprintf(w, "commit: %s\n", config.commit); err != nil {
+			return err
+		}
+	}
+	if config.built != "" {
+		if _, err := fmt.Fprintf(w, "built: %s\n", config.built); err != nil {
+			return err
+		}
+	}
+	return nil
+}
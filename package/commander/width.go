@@ -0,0 +1,42 @@
+package commander
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// minWrap is the narrowest column that descriptions are wrapped to. Anything
+// narrower is harder to read than letting the terminal wrap it.
+const minWrap = 20
+
+// useWidth decides how wide the help output written to w should be. An
+// explicit width wins, then the width of the terminal. Zero disables wrapping.
+func (c *config) useWidth(w io.Writer) int {
+	if c.width != 0 {
+		return c.width
+	}
+	if f, ok := w.(*os.File); ok {
+		return terminalWidth(f)
+	}
+	return 0
+}
+
+// wrap the text into lines no longer than width. Words longer than the width
+// are kept on their own line.
+func wrap(text string, width int) (lines []string) {
+	words := strings.Fields(text)
+	if width < minWrap || len(words) == 0 {
+		return []string{text}
+	}
+	line := words[0]
+	for _, word := range words[1:] {
+		if len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = word
+			continue
+		}
+		line += " " + word
+	}
+	return append(lines, line)
+}
@@ -0,0 +1,10 @@
+//go:build !(aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris)
+
+package commander
+
+import "os"
+
+// terminalWidth isn't supported on this platform, so help isn't wrapped
+func terminalWidth(f *os.File) int {
+	return 0
+}
@@ -18,7 +18,7 @@ var _ FileServer = (*Embed)(nil)
 func (e *Embed) GenerateFile(file *File) error {
 	file.Data = e.Data
 	file.Mode = e.Mode
-	file.modTime = e.ModTime
+	file.ModTime = e.ModTime
 	file.sys = e.Sys
 	return nil
 }
@@ -26,7 +26,7 @@ func (e *Embed) GenerateFile(file *File) error {
 func (e *Embed) ServeFile(file *File) error {
 	file.Data = e.Data
 	file.Mode = e.Mode
-	file.modTime = e.ModTime
+	file.ModTime = e.ModTime
 	file.sys = e.Sys
 	return nil
 }
@@ -11,7 +11,7 @@ type File struct {
 	path    string
 	Data    []byte
 	Mode    fs.FileMode
-	modTime time.Time
+	ModTime time.Time
 	sys     interface{}
 }
 
@@ -52,7 +52,7 @@ func (f *file) Stat() (fs.FileInfo, error) {
 	return &fileInfo{
 		name:    path.Base(f.path),
 		mode:    f.Mode &^ fs.ModeDir,
-		modTime: f.modTime,
+		modTime: f.ModTime,
 		size:    int64(len(f.Data)),
 		sys:     f.sys,
 	}, nil
@@ -7,7 +7,6 @@ import (
 	"path/filepath"
 
 	"github.com/livebud/bud/package/gomod"
-	"github.com/livebud/bud/package/parser"
 )
 
 var ErrNoMatch = errors.New("no match")
@@ -47,7 +46,7 @@ func (i *Injector) Find(currModule *gomod.Module, dep Dependency) (Declaration,
 	if err != nil {
 		return nil, err
 	}
-	pkg, err := parser.New(fsys, nextModule).Parse(rel)
+	pkg, err := i.parser.In(fsys, nextModule).Parse(rel)
 	if err != nil {
 		return nil, err
 	}
@@ -110,7 +110,7 @@ func (i *Injector) Wire(fn *Function) (*Provider, error) {
 		return nil, err
 	}
 	if fn.Imports == nil {
-		fn.Imports = imports.New()
+		fn.Imports = imports.New(imports.WithLocal(i.module.Import()))
 	}
 	return node.Generate(fn.Imports, fn.Name, fn.Target), nil
 }
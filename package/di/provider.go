@@ -91,6 +91,9 @@ func (p *Provider) File() string {
 	c.WriteString("// GENERATED. DO NOT EDIT.\n\n")
 	c.WriteString("import (\n")
 	for _, im := range p.Imports {
+		if im.NewGroup {
+			c.WriteString("\n")
+		}
 		c.WriteString("\t" + im.Name + ` "` + im.Path + `"` + "\n")
 	}
 	c.WriteString(")\n\n")
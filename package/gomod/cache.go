@@ -0,0 +1,190 @@
+package gomod
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/livebud/bud/package/modcache"
+	"golang.org/x/sync/singleflight"
+)
+
+// CacheStats are the hit and miss counts of the lookup cache
+type CacheStats struct {
+	Hits   int64
+	Misses int64
+}
+
+// NewCache creates an in-memory lookup cache
+func NewCache() *Cache {
+	return &Cache{
+		modules: map[string]*Module{},
+		dirs:    map[string]string{},
+	}
+}
+
+// LoadCache loads a lookup cache that persists the module cache directories it
+// resolves to path. Only those are persisted since a module version never
+// changes once it's downloaded. The file is created on the first write.
+func LoadCache(path string) (*Cache, error) {
+	cache := NewCache()
+	cache.path = path
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return cache, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &cache.dirs); err != nil {
+		return nil, fmt.Errorf("mod: unable to load the lookup cache %q. %w", path, err)
+	}
+	return cache, nil
+}
+
+// Cache of module lookups. Modules found through a module share its cache, so
+// looking up the same dependency again doesn't read go.mod, go.work or the
+// module cache again. Call Invalidate after go.mod or go.work files change.
+// It's safe for concurrent use.
+type Cache struct {
+	mu      sync.Mutex
+	modules map[string]*Module // directory -> module found from that directory
+	dirs    map[string]string  // module@version -> directory in the module cache
+	path    string             // optional file to persist dirs to
+	stats   CacheStats
+	group   singleflight.Group
+}
+
+// Stats returns the hit and miss counts
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// Invalidate the modules within the module or workspace directories.
+// Invalidating without directories clears the whole cache, including the
+// persisted module cache directories.
+func (c *Cache) Invalidate(moduleDirs ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(moduleDirs) == 0 {
+		c.modules = map[string]*Module{}
+		c.dirs = map[string]string{}
+		if c.path == "" {
+			return nil
+		}
+		if err := os.Remove(c.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	for _, moduleDir := range moduleDirs {
+		for dir, module := range c.modules {
+			if module.dir == moduleDir || (module.work != nil && module.work.Directory() == moduleDir) {
+				delete(c.modules, dir)
+			}
+		}
+	}
+	return nil
+}
+
+// find the module containing dir, loading it if it's not cached
+func (c *Cache) find(dir string, load func() (*Module, error)) (*Module, error) {
+	c.mu.Lock()
+	if module, ok := c.modules[dir]; ok {
+		c.stats.Hits++
+		c.mu.Unlock()
+		return module, nil
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+	// Concurrent lookups of the same directory share the load
+	value, err, _ := c.group.Do("module:"+dir, func() (interface{}, error) {
+		module, err := load()
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.modules[dir] = module
+		c.mu.Unlock()
+		return module, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*Module), nil
+}
+
+// replace the cached module with another
+func (c *Cache) replace(from, to *Module) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for dir, module := range c.modules {
+		if module == from {
+			c.modules[dir] = to
+		}
+	}
+}
+
+// resolveDirectory resolves the module version to its directory in the module
+// cache. Directories that have since been removed are resolved again.
+func (c *Cache) resolveDirectory(modCache *modcache.Cache, modulePath, version string) (string, error) {
+	key := filepath.Join(modCache.Directory(), modulePath+"@"+version)
+	c.mu.Lock()
+	dir, ok := c.dirs[key]
+	c.mu.Unlock()
+	if ok {
+		if _, err := os.Stat(dir); err == nil {
+			c.mu.Lock()
+			c.stats.Hits++
+			c.mu.Unlock()
+			return dir, nil
+		}
+	}
+	c.mu.Lock()
+	c.stats.Misses++
+	c.mu.Unlock()
+	value, err, _ := c.group.Do("dir:"+key, func() (interface{}, error) {
+		dir, err := modCache.ResolveDirectory(modulePath, version)
+		if err != nil {
+			return "", err
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.dirs[key] = dir
+		if err := c.save(); err != nil {
+			return "", err
+		}
+		return dir, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+// save the module cache directories if the cache is persisted. The caller must
+// hold the lock.
+func (c *Cache) save() error {
+	if c.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(c.dirs)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	// Write to a temporary file first, so readers never see a partial file
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
@@ -0,0 +1,175 @@
+package gomod_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/livebud/bud/package/gomod"
+	"github.com/livebud/bud/package/modcache"
+	"github.com/livebud/bud/package/vfs"
+	"github.com/matryer/is"
+)
+
+func writeCacheApp(t testing.TB) (appDir string, modCache *modcache.Cache) {
+	t.Helper()
+	t.Setenv("GOWORK", "off")
+	modCache = modcache.New(t.TempDir())
+	err := modCache.Write(modcache.Modules{
+		"mod.test/module@v1.2.3": modcache.Files{
+			"go.mod":   "module mod.test/module",
+			"const.go": "package module\nconst Answer = 42",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	appDir = t.TempDir()
+	err = vfs.Write(appDir, vfs.Map{
+		"go.mod": []byte("module app.com\n\nrequire mod.test/module v1.2.3\n"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return appDir, modCache
+}
+
+func TestCacheFind(t *testing.T) {
+	is := is.New(t)
+	appDir, modCache := writeCacheApp(t)
+	cache := gomod.NewCache()
+	module, err := gomod.Find(appDir, gomod.WithModCache(modCache), gomod.WithCache(cache))
+	is.NoErr(err)
+	is.Equal(module.Cache(), cache)
+	dep1, err := module.Find("mod.test/module")
+	is.NoErr(err)
+	dep2, err := module.Find("mod.test/module")
+	is.NoErr(err)
+	is.Equal(dep1, dep2)
+	is.Equal(dep1.Cache(), cache)
+	// Second lookup hits both the module cache directory and the module
+	is.Equal(cache.Stats(), gomod.CacheStats{Hits: 2, Misses: 3})
+	// Modules found with the same cache are shared
+	again, err := gomod.Find(appDir, gomod.WithModCache(modCache), gomod.WithCache(cache))
+	is.NoErr(err)
+	is.Equal(again, module)
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	is := is.New(t)
+	appDir, modCache := writeCacheApp(t)
+	cache := gomod.NewCache()
+	module, err := gomod.Find(appDir, gomod.WithModCache(modCache), gomod.WithCache(cache))
+	is.NoErr(err)
+	is.Equal(len(module.File().Requires()), 1)
+	err = os.WriteFile(filepath.Join(appDir, "go.mod"), []byte("module app.com\n"), 0644)
+	is.NoErr(err)
+	// Still cached
+	module, err = gomod.Find(appDir, gomod.WithModCache(modCache), gomod.WithCache(cache))
+	is.NoErr(err)
+	is.Equal(len(module.File().Requires()), 1)
+	// Invalidating other modules keeps the module cached
+	is.NoErr(cache.Invalidate(t.TempDir()))
+	module, err = gomod.Find(appDir, gomod.WithModCache(modCache), gomod.WithCache(cache))
+	is.NoErr(err)
+	is.Equal(len(module.File().Requires()), 1)
+	is.NoErr(cache.Invalidate(appDir))
+	module, err = gomod.Find(appDir, gomod.WithModCache(modCache), gomod.WithCache(cache))
+	is.NoErr(err)
+	is.Equal(len(module.File().Requires()), 0)
+}
+
+func TestCacheInvalidateWorkspace(t *testing.T) {
+	is := is.New(t)
+	dir := writeWorkspace(t)
+	cache := gomod.NewCache()
+	module, err := gomod.Find(filepath.Join(dir, "app"), gomod.WithCache(cache))
+	is.NoErr(err)
+	is.True(module.Workspace() != nil)
+	err = os.Remove(filepath.Join(dir, "go.work"))
+	is.NoErr(err)
+	is.NoErr(cache.Invalidate(dir))
+	module, err = gomod.Find(filepath.Join(dir, "app"), gomod.WithCache(cache))
+	is.NoErr(err)
+	is.Equal(module.Workspace(), nil)
+}
+
+func TestCacheLoad(t *testing.T) {
+	is := is.New(t)
+	appDir, modCache := writeCacheApp(t)
+	cachePath := filepath.Join(t.TempDir(), "cache", "gomod.json")
+	cache, err := gomod.LoadCache(cachePath)
+	is.NoErr(err)
+	module, err := gomod.Find(appDir, gomod.WithModCache(modCache), gomod.WithCache(cache))
+	is.NoErr(err)
+	dir, err := module.ResolveDirectory("mod.test/module")
+	is.NoErr(err)
+	is.Equal(dir, modCache.Directory("mod.test", "module@v1.2.3"))
+	is.Equal(cache.Stats(), gomod.CacheStats{Hits: 0, Misses: 2})
+	// A new cache loads the module cache directories from disk
+	cache, err = gomod.LoadCache(cachePath)
+	is.NoErr(err)
+	module, err = gomod.Find(appDir, gomod.WithModCache(modCache), gomod.WithCache(cache))
+	is.NoErr(err)
+	dir, err = module.ResolveDirectory("mod.test/module")
+	is.NoErr(err)
+	is.Equal(dir, modCache.Directory("mod.test", "module@v1.2.3"))
+	is.Equal(cache.Stats(), gomod.CacheStats{Hits: 1, Misses: 1})
+	// Invalidating everything removes the file
+	is.NoErr(cache.Invalidate())
+	_, err = os.Stat(cachePath)
+	is.True(os.IsNotExist(err))
+}
+
+func TestCacheRemovedDirectory(t *testing.T) {
+	is := is.New(t)
+	appDir, modCache := writeCacheApp(t)
+	cache := gomod.NewCache()
+	module, err := gomod.Find(appDir, gomod.WithModCache(modCache), gomod.WithCache(cache))
+	is.NoErr(err)
+	_, err = module.ResolveDirectory("mod.test/module")
+	is.NoErr(err)
+	// Removed directories are resolved again
+	is.NoErr(os.RemoveAll(modCache.Directory("mod.test", "module@v1.2.3")))
+	_, err = module.ResolveDirectory("mod.test/module")
+	is.True(err != nil)
+	is.Equal(cache.Stats(), gomod.CacheStats{Hits: 0, Misses: 3})
+}
+
+func TestReload(t *testing.T) {
+	is := is.New(t)
+	appDir, modCache := writeCacheApp(t)
+	cache := gomod.NewCache()
+	module, err := gomod.Find(appDir, gomod.WithModCache(modCache), gomod.WithCache(cache))
+	is.NoErr(err)
+	is.Equal(len(module.File().Requires()), 1)
+	err = os.WriteFile(filepath.Join(appDir, "go.mod"), []byte("module app.com\n"), 0644)
+	is.NoErr(err)
+	is.NoErr(module.Reload())
+	is.Equal(len(module.File().Requires()), 0)
+	_, err = module.ResolveDirectory("mod.test/module")
+	is.True(err != nil)
+	// Finding the module again returns the reloaded module
+	again, err := gomod.Find(appDir, gomod.WithModCache(modCache), gomod.WithCache(cache))
+	is.NoErr(err)
+	is.Equal(again, module)
+}
+
+func TestReloadKeepsDirectories(t *testing.T) {
+	is := is.New(t)
+	appDir, modCache := writeCacheApp(t)
+	cachePath := filepath.Join(t.TempDir(), "gomod.json")
+	cache, err := gomod.LoadCache(cachePath)
+	is.NoErr(err)
+	module, err := gomod.Find(appDir, gomod.WithModCache(modCache), gomod.WithCache(cache))
+	is.NoErr(err)
+	_, err = module.ResolveDirectory("mod.test/module")
+	is.NoErr(err)
+	is.NoErr(module.Reload())
+	// The persisted module cache directories survive reloading
+	_, err = os.Stat(cachePath)
+	is.NoErr(err)
+	_, err = module.ResolveDirectory("mod.test/module")
+	is.NoErr(err)
+	is.Equal(cache.Stats(), gomod.CacheStats{Hits: 1, Misses: 3})
+}
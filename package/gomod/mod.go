@@ -25,6 +25,7 @@ type Option = func(o *option)
 type option struct {
 	modCache *modcache.Cache
 	fsCache  *fscache.Cache // can be nil
+	cache    *Cache
 }
 
 // WithModCache uses a custom mod cache instead of the default
@@ -34,6 +35,14 @@ func WithModCache(cache *modcache.Cache) func(o *option) {
 	}
 }
 
+// WithCache shares a lookup cache between modules. Modules found from the
+// same root module share a cache by default.
+func WithCache(cache *Cache) func(o *option) {
+	return func(opt *option) {
+		opt.cache = cache
+	}
+}
+
 // WithFileCache uses a file cache
 func WithFSCache(cache *fscache.Cache) func(o *option) {
 	return func(opt *option) {
@@ -45,6 +54,7 @@ func Find(dir string, options ...Option) (*Module, error) {
 	opt := &option{
 		modCache: modcache.Default(),
 		fsCache:  nil,
+		cache:    NewCache(),
 	}
 	for _, option := range options {
 		option(opt)
@@ -57,6 +67,12 @@ func Find(dir string, options ...Option) (*Module, error) {
 }
 
 func find(opt *option, dir string) (*Module, error) {
+	return opt.cache.find(dir, func() (*Module, error) {
+		return load(opt, dir)
+	})
+}
+
+func load(opt *option, dir string) (*Module, error) {
 	moduleDir, err := Absolute(dir)
 	if err != nil {
 		return nil, fmt.Errorf("%w in %q", ErrFileNotFound, dir)
@@ -66,7 +82,19 @@ func find(opt *option, dir string) (*Module, error) {
 	if err != nil {
 		return nil, err
 	}
-	return parse(opt, modulePath, moduleData)
+	module, err := parse(opt, modulePath, moduleData)
+	if err != nil {
+		return nil, err
+	}
+	// Load the workspace if the module is one of the modules it uses
+	work, err := findWorkspace(moduleDir)
+	if err != nil {
+		return nil, err
+	}
+	if work != nil && work.uses(moduleDir) {
+		module.work = work
+	}
+	return module, nil
 }
 
 // Infer the module path from the $GOPATH. This only works if you work inside
@@ -79,6 +107,7 @@ func Infer(dir string) string {
 func Parse(path string, data []byte, options ...Option) (*Module, error) {
 	opt := &option{
 		modCache: modcache.Default(),
+		cache:    NewCache(),
 	}
 	for _, option := range options {
 		option(opt)
@@ -17,6 +17,7 @@ type Module struct {
 	opt  *option
 	file *File
 	dir  string
+	work *Workspace // nil if the module isn't in a workspace
 }
 
 // Directory returns the module directory (e.g. /Users/$USER/...)
@@ -35,6 +36,32 @@ func (m *Module) ModCache() string {
 	return m.opt.modCache.Directory()
 }
 
+// Cache returns the lookup cache shared by the modules found from this module
+func (m *Module) Cache() *Cache {
+	return m.opt.cache
+}
+
+// Reload go.mod and go.work after invalidating the module's cached lookups.
+// The module is updated in place, so everything holding onto it sees the
+// changes. Module cache directories are kept, since they never change.
+func (m *Module) Reload() error {
+	dirs := []string{m.dir}
+	if m.work != nil {
+		dirs = append(dirs, m.work.Directory())
+	}
+	if err := m.opt.cache.Invalidate(dirs...); err != nil {
+		return err
+	}
+	module, err := find(m.opt, m.dir)
+	if err != nil {
+		return err
+	}
+	m.file = module.file
+	m.work = module.work
+	m.opt.cache.replace(module, m)
+	return nil
+}
+
 // Import returns the module's import path (e.g. github.com/livebud/bud)
 func (m *Module) Import(subpaths ...string) string {
 	return m.file.Import(subpaths...)
@@ -45,6 +72,12 @@ func (m *Module) File() *File {
 	return m.file
 }
 
+// Workspace returns the go.work workspace that uses the module or nil if the
+// module isn't within a workspace
+func (m *Module) Workspace() *Workspace {
+	return m.work
+}
+
 // Find a dependency from an import path
 func (m *Module) Find(importPath string) (*Module, error) {
 	return m.FindIn(os.DirFS(m.dir), importPath)
@@ -108,6 +141,17 @@ func (m *Module) ResolveDirectoryIn(localFS fs.FS, importPath string) (directory
 	if gois.StdLib(importPath) {
 		return filepath.Join(stdDir, importPath), nil
 	}
+	// Handle the other modules in the workspace. The module itself is handled
+	// below, since it may be resolved within localFS.
+	if m.work != nil {
+		if mod := m.work.findModule(importPath); mod != nil && mod.dir != m.dir {
+			absdir := filepath.Join(mod.dir, strings.TrimPrefix(importPath, mod.path))
+			if _, err := os.Stat(absdir); err != nil {
+				return "", fmt.Errorf("mod: unable to resolve directory for workspace import path %q: %w", importPath, err)
+			}
+			return absdir, nil
+		}
+	}
 	// Handle local packages
 	modulePath := m.Import()
 	if contains(modulePath, importPath) {
@@ -123,6 +167,27 @@ func (m *Module) ResolveDirectoryIn(localFS fs.FS, importPath string) (directory
 		absdir := filepath.Join(m.dir, rel)
 		return absdir, nil
 	}
+	// Handle go.work replace, which takes precedence over go.mod replace
+	if m.work != nil {
+		if rep := m.work.findReplace(importPath); rep != nil {
+			relPath := strings.TrimPrefix(importPath, rep.Old.Path)
+			// Replacements with a version live in the module cache, otherwise
+			// they're relative to go.work
+			dir := m.work.Directory()
+			if rep.New.Version != "" {
+				if dir, err = m.opt.cache.resolveDirectory(m.opt.modCache, rep.New.Path, rep.New.Version); err != nil {
+					return "", err
+				}
+			} else if dir, err = resolvePath(dir, rep.New.Path); err != nil {
+				return "", err
+			}
+			absdir := filepath.Join(dir, relPath)
+			if _, err := os.Stat(absdir); err != nil {
+				return "", fmt.Errorf("mod: unable to resolve directory for replaced import path %q: %w", importPath, err)
+			}
+			return absdir, nil
+		}
+	}
 	// Handle replace
 	for _, rep := range m.file.Replaces() {
 		if contains(rep.Old.Path, importPath) {
@@ -143,7 +208,7 @@ func (m *Module) ResolveDirectoryIn(localFS fs.FS, importPath string) (directory
 	for _, req := range m.file.Requires() {
 		if contains(req.Mod.Path, importPath) {
 			relPath := strings.TrimPrefix(importPath, req.Mod.Path)
-			dir, err := m.opt.modCache.ResolveDirectory(req.Mod.Path, req.Mod.Version)
+			dir, err := m.opt.cache.resolveDirectory(m.opt.modCache, req.Mod.Path, req.Mod.Version)
 			if err != nil {
 				return "", err
 			}
@@ -163,6 +228,10 @@ func (m *Module) Hash() []byte {
 	code := m.File().Format()
 	h := xxhash.New()
 	h.Write(code)
+	// The workspace changes how imports resolve
+	if m.work != nil {
+		h.Write(m.work.data)
+	}
 	return h.Sum(nil)
 }
 
@@ -0,0 +1,236 @@
+package gomod
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// Workspace is a go.work file that uses multiple modules together
+type Workspace struct {
+	path     string
+	data     []byte
+	modules  []*workModule
+	replaces []*Replace
+}
+
+type workModule struct {
+	path string // Import path of the module
+	dir  string // Absolute directory of the module
+}
+
+// Directory returns the directory containing go.work
+func (w *Workspace) Directory() string {
+	return filepath.Dir(w.path)
+}
+
+// Uses returns the directories of the modules in the workspace
+func (w *Workspace) Uses() (dirs []string) {
+	for _, mod := range w.modules {
+		dirs = append(dirs, mod.dir)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// Replaces returns the replace directives of go.work, which take precedence
+// over the replace directives of each module
+func (w *Workspace) Replaces() []*Replace {
+	return w.replaces
+}
+
+// uses returns true if the module directory is part of the workspace
+func (w *Workspace) uses(dir string) bool {
+	for _, mod := range w.modules {
+		if mod.dir == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// findModule finds the workspace module containing the import path. Modules
+// are sorted from longest to shortest path, so nested modules match before the
+// modules containing them.
+func (w *Workspace) findModule(importPath string) *workModule {
+	for _, mod := range w.modules {
+		if contains(mod.path, importPath) {
+			return mod
+		}
+	}
+	return nil
+}
+
+// findReplace finds the go.work replace directive for the import path
+func (w *Workspace) findReplace(importPath string) *Replace {
+	for _, rep := range w.replaces {
+		if contains(rep.Old.Path, importPath) {
+			return rep
+		}
+	}
+	return nil
+}
+
+// findWorkspace finds the go.work file above dir. Like the go command, GOWORK
+// may point to the go.work file or turn workspaces off. It returns nil if
+// there's no workspace.
+func findWorkspace(dir string) (*Workspace, error) {
+	path, err := findWorkFile(dir)
+	if err != nil || path == "" {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseWorkspace(path, data)
+}
+
+func findWorkFile(dir string) (string, error) {
+	switch gowork := os.Getenv("GOWORK"); gowork {
+	case "off":
+		return "", nil
+	case "":
+	default:
+		return filepath.Abs(gowork)
+	}
+	for {
+		path := filepath.Join(dir, "go.work")
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		nextDir := filepath.Dir(dir)
+		if nextDir == dir {
+			return "", nil
+		}
+		dir = nextDir
+	}
+}
+
+// parseWorkspace parses a go.work file. The use and replace directives are read
+// from the syntax tree, since go.work shares the syntax of go.mod.
+func parseWorkspace(path string, data []byte) (*Workspace, error) {
+	file, err := modfile.ParseLax(path, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	work := &Workspace{path: path, data: data}
+	for _, stmt := range file.Syntax.Stmt {
+		switch x := stmt.(type) {
+		case *modfile.Line:
+			if err := work.add(x, x.Token[0], x.Token[1:]); err != nil {
+				return nil, err
+			}
+		case *modfile.LineBlock:
+			if len(x.Token) != 1 {
+				continue
+			}
+			for _, line := range x.Line {
+				if err := work.add(line, x.Token[0], line.Token); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	sort.SliceStable(work.modules, func(i, j int) bool {
+		return len(work.modules[i].path) > len(work.modules[j].path)
+	})
+	return work, nil
+}
+
+func (w *Workspace) add(line *modfile.Line, verb string, args []string) error {
+	switch verb {
+	case "use":
+		if len(args) != 1 {
+			return fmt.Errorf("mod: %s:%d: usage: use local/dir", w.path, line.Start.Line)
+		}
+		dir, err := unquote(args[0])
+		if err != nil {
+			return fmt.Errorf("mod: %s:%d: invalid quoted string. %w", w.path, line.Start.Line, err)
+		}
+		mod, err := loadWorkModule(w.Directory(), dir)
+		if err != nil {
+			return fmt.Errorf("mod: %s:%d: unable to load module %q. %w", w.path, line.Start.Line, dir, err)
+		}
+		w.modules = append(w.modules, mod)
+	case "replace":
+		rep, err := parseReplace(args)
+		if err != nil {
+			return fmt.Errorf("mod: %s:%d: %w", w.path, line.Start.Line, err)
+		}
+		w.replaces = append(w.replaces, rep)
+	}
+	return nil
+}
+
+// loadWorkModule reads the module path from the go.mod in dir
+func loadWorkModule(workDir, dir string) (*workModule, error) {
+	absdir, err := resolvePath(workDir, dir)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(absdir, "go.mod")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := modfile.ParseLax(path, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	if file.Module == nil {
+		return nil, fmt.Errorf("no module directive in %q", path)
+	}
+	return &workModule{file.Module.Mod.Path, absdir}, nil
+}
+
+// parseReplace parses "old [version] => new [version]"
+func parseReplace(args []string) (*Replace, error) {
+	arrow := -1
+	for i, arg := range args {
+		if arg == "=>" {
+			arrow = i
+		}
+	}
+	if arrow < 1 || arrow > 2 || len(args)-arrow < 2 || len(args)-arrow > 3 {
+		return nil, errors.New("usage: replace module/path [v1.2.3] => other/module v1.4 or replace module/path [v1.2.3] => ../local/directory")
+	}
+	oldVersion, err := parseVersion(args[:arrow])
+	if err != nil {
+		return nil, err
+	}
+	newVersion, err := parseVersion(args[arrow+1:])
+	if err != nil {
+		return nil, err
+	}
+	return &Replace{Old: oldVersion, New: newVersion}, nil
+}
+
+func parseVersion(args []string) (version module.Version, err error) {
+	if version.Path, err = unquote(args[0]); err != nil {
+		return version, err
+	}
+	if len(args) > 1 {
+		if version.Version, err = unquote(args[1]); err != nil {
+			return version, err
+		}
+	}
+	return version, nil
+}
+
+func unquote(token string) (string, error) {
+	if strings.HasPrefix(token, `"`) || strings.HasPrefix(token, "`") {
+		return strconv.Unquote(token)
+	}
+	return token, nil
+}
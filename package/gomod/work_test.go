@@ -0,0 +1,189 @@
+package gomod_test
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/livebud/bud/package/gomod"
+	"github.com/livebud/bud/package/modcache"
+	"github.com/livebud/bud/package/vfs"
+	"github.com/matryer/is"
+)
+
+func writeWorkspace(t testing.TB) string {
+	t.Helper()
+	t.Setenv("GOWORK", "")
+	dir := t.TempDir()
+	err := vfs.Write(dir, vfs.Map{
+		"go.work":               []byte("go 1.18\n\nuse (\n\t./app\n\t\"./lib\"\n\t./lib/nested\n)\n\nreplace mod.test/tool => ./tool\n"),
+		"app/go.mod":            []byte("module app.com\n\nrequire lib.com v1.0.0\n"),
+		"app/web/web.go":        []byte("package web"),
+		"lib/go.mod":            []byte("module lib.com\n"),
+		"lib/lib.go":            []byte("package lib"),
+		"lib/util/util.go":      []byte("package util"),
+		"lib/nested/go.mod":     []byte("module lib.com/nested\n"),
+		"lib/nested/nested.go":  []byte("package nested"),
+		"tool/go.mod":           []byte("module mod.test/tool\n"),
+		"tool/cmd/tool/main.go": []byte("package main"),
+		"outside/go.mod":        []byte("module outside.com\n"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestWorkspace(t *testing.T) {
+	is := is.New(t)
+	dir := writeWorkspace(t)
+	module, err := gomod.Find(filepath.Join(dir, "app", "web"))
+	is.NoErr(err)
+	is.Equal(module.Import(), "app.com")
+	is.Equal(module.Directory(), filepath.Join(dir, "app"))
+	work := module.Workspace()
+	is.True(work != nil)
+	is.Equal(work.Directory(), dir)
+	is.Equal(work.Uses(), []string{
+		filepath.Join(dir, "app"),
+		filepath.Join(dir, "lib"),
+		filepath.Join(dir, "lib", "nested"),
+	})
+	is.Equal(len(work.Replaces()), 1)
+	is.Equal(work.Replaces()[0].Old.Path, "mod.test/tool")
+	is.Equal(work.Replaces()[0].New.Path, "./tool")
+}
+
+func TestWorkspaceResolveDirectory(t *testing.T) {
+	is := is.New(t)
+	dir := writeWorkspace(t)
+	module, err := gomod.Find(filepath.Join(dir, "app"))
+	is.NoErr(err)
+	// Local packages
+	resolved, err := module.ResolveDirectory("app.com/web")
+	is.NoErr(err)
+	is.Equal(resolved, filepath.Join(dir, "app", "web"))
+	// Workspace modules take precedence over requires
+	resolved, err = module.ResolveDirectory("lib.com")
+	is.NoErr(err)
+	is.Equal(resolved, filepath.Join(dir, "lib"))
+	resolved, err = module.ResolveDirectory("lib.com/util")
+	is.NoErr(err)
+	is.Equal(resolved, filepath.Join(dir, "lib", "util"))
+	// Nested modules match before the modules containing them
+	resolved, err = module.ResolveDirectory("lib.com/nested")
+	is.NoErr(err)
+	is.Equal(resolved, filepath.Join(dir, "lib", "nested"))
+	// Replaces in go.work are relative to go.work
+	resolved, err = module.ResolveDirectory("mod.test/tool/cmd/tool")
+	is.NoErr(err)
+	is.Equal(resolved, filepath.Join(dir, "tool", "cmd", "tool"))
+	// Missing packages within workspace modules
+	_, err = module.ResolveDirectory("lib.com/missing")
+	is.True(errors.Is(err, fs.ErrNotExist))
+}
+
+func TestWorkspaceFind(t *testing.T) {
+	is := is.New(t)
+	dir := writeWorkspace(t)
+	module, err := gomod.Find(filepath.Join(dir, "app"))
+	is.NoErr(err)
+	lib, err := module.Find("lib.com/util")
+	is.NoErr(err)
+	is.Equal(lib.Import(), "lib.com")
+	is.Equal(lib.Directory(), filepath.Join(dir, "lib"))
+	// The other modules of the workspace resolve within the workspace too
+	is.True(lib.Workspace() != nil)
+	resolved, err := lib.ResolveDirectory("app.com/web")
+	is.NoErr(err)
+	is.Equal(resolved, filepath.Join(dir, "app", "web"))
+}
+
+func TestWorkspaceNotUsed(t *testing.T) {
+	is := is.New(t)
+	dir := writeWorkspace(t)
+	module, err := gomod.Find(filepath.Join(dir, "outside"))
+	is.NoErr(err)
+	is.Equal(module.Import(), "outside.com")
+	is.Equal(module.Workspace(), nil)
+}
+
+func TestWorkspaceOff(t *testing.T) {
+	is := is.New(t)
+	dir := writeWorkspace(t)
+	t.Setenv("GOWORK", "off")
+	module, err := gomod.Find(filepath.Join(dir, "app"))
+	is.NoErr(err)
+	is.Equal(module.Workspace(), nil)
+}
+
+func TestWorkspaceEnv(t *testing.T) {
+	is := is.New(t)
+	dir := writeWorkspace(t)
+	err := vfs.Write(dir, vfs.Map{
+		"other/go.work": []byte("go 1.18\n\nuse ../app\n"),
+	})
+	is.NoErr(err)
+	t.Setenv("GOWORK", filepath.Join(dir, "other", "go.work"))
+	module, err := gomod.Find(filepath.Join(dir, "app"))
+	is.NoErr(err)
+	is.True(module.Workspace() != nil)
+	is.Equal(module.Workspace().Directory(), filepath.Join(dir, "other"))
+	is.Equal(module.Workspace().Uses(), []string{filepath.Join(dir, "app")})
+}
+
+func TestWorkspaceVersionedReplace(t *testing.T) {
+	is := is.New(t)
+	t.Setenv("GOWORK", "")
+	modCache := modcache.New(t.TempDir())
+	err := modCache.Write(modcache.Modules{
+		"mod.test/module@v1.2.3": modcache.Files{
+			"go.mod":   "module mod.test/module",
+			"const.go": "package module\nconst Answer = 42",
+		},
+	})
+	is.NoErr(err)
+	dir := t.TempDir()
+	err = vfs.Write(dir, vfs.Map{
+		"go.work":    []byte("go 1.18\n\nuse ./app\n\nreplace other.test/module => mod.test/module v1.2.3\n"),
+		"app/go.mod": []byte("module app.com\n"),
+	})
+	is.NoErr(err)
+	module, err := gomod.Find(filepath.Join(dir, "app"), gomod.WithModCache(modCache))
+	is.NoErr(err)
+	resolved, err := module.ResolveDirectory("other.test/module")
+	is.NoErr(err)
+	is.Equal(resolved, modCache.Directory("mod.test", "module@v1.2.3"))
+}
+
+func TestWorkspaceMissingModule(t *testing.T) {
+	is := is.New(t)
+	t.Setenv("GOWORK", "")
+	dir := t.TempDir()
+	err := vfs.Write(dir, vfs.Map{
+		"go.work":    []byte("go 1.18\n\nuse ./app\nuse ./missing\n"),
+		"app/go.mod": []byte("module app.com\n"),
+	})
+	is.NoErr(err)
+	_, err = gomod.Find(filepath.Join(dir, "app"))
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), `go.work:4: unable to load module "./missing"`))
+	is.True(errors.Is(err, fs.ErrNotExist))
+}
+
+func TestWorkspaceHash(t *testing.T) {
+	is := is.New(t)
+	dir := writeWorkspace(t)
+	module, err := gomod.Find(filepath.Join(dir, "app"))
+	is.NoErr(err)
+	hash := module.Hash()
+	err = vfs.Write(dir, vfs.Map{
+		"go.work": []byte("go 1.18\n\nuse ./app\n"),
+	})
+	is.NoErr(err)
+	module, err = gomod.Find(filepath.Join(dir, "app"))
+	is.NoErr(err)
+	is.True(string(hash) != string(module.Hash()))
+}
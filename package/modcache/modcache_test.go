@@ -131,7 +131,7 @@ func TestWriteModuleFS(t *testing.T) {
 		"mod.test/one@v0.0.2/go.mod",
 	)
 	is.NoErr(err)
-	err = dsync.Dir(fsys, ".", vfs.OS(cacheDir), ".")
+	_, err = dsync.Dir(fsys, ".", vfs.OS(cacheDir), ".")
 	is.NoErr(err)
 	modCache := modcache.New(cacheDir)
 	dir, err := modCache.ResolveDirectory("mod.test/one", "v0.0.2")
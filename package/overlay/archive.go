@@ -0,0 +1,74 @@
+package overlay
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"testing/fstest"
+)
+
+// Zip reads a zip archive into a filesystem, e.g. a downloaded plugin bundle.
+// The filesystem can be mounted into the overlay.
+func Zip(r io.ReaderAt, size int64) (fs.FS, error) {
+	reader, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("overlay: unable to read zip. %w", err)
+	}
+	return reader, nil
+}
+
+// Tar reads a tar archive into a filesystem. Gzipped archives (.tar.gz) are
+// decompressed. Only directories and regular files are read.
+func Tar(r io.Reader) (fs.FS, error) {
+	br := bufio.NewReader(r)
+	// Decompress gzipped archives
+	if magic, err := br.Peek(2); err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("overlay: unable to read tar. %w", err)
+		}
+		defer gz.Close()
+		return readTar(gz)
+	}
+	return readTar(br)
+}
+
+func readTar(r io.Reader) (fs.FS, error) {
+	fsys := fstest.MapFS{}
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return fsys, nil
+			}
+			return nil, fmt.Errorf("overlay: unable to read tar. %w", err)
+		}
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if name == "." {
+			continue
+		}
+		if !fs.ValidPath(name) {
+			return nil, fmt.Errorf("overlay: unable to read tar. invalid path %q", header.Name)
+		}
+		info := header.FileInfo()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			fsys[name] = &fstest.MapFile{Mode: info.Mode(), ModTime: info.ModTime()}
+		case tar.TypeReg:
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				return nil, fmt.Errorf("overlay: unable to read %q from tar. %w", name, err)
+			}
+			fsys[name] = &fstest.MapFile{Data: data, Mode: info.Mode(), ModTime: info.ModTime()}
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package overlay
+
+import "github.com/livebud/bud/internal/fscache"
+
+type Option func(*option)
+
+type option struct {
+	cacheLimit int64
+}
+
+// WithCacheLimit bounds the cache of generated files to limit bytes, evicting
+// the least recently used files when it's exceeded. Evicted files are
+// regenerated the next time they're read. Defaults to 0, which doesn't bound
+// the cache.
+func WithCacheLimit(limit int64) Option {
+	return func(o *option) {
+		o.cacheLimit = limit
+	}
+}
+
+// CacheStats are the hit, miss and eviction counts of the cache along with
+// its current size
+type CacheStats = fscache.Stats
+
+// CacheStats returns the metrics of the cache
+func (f *FileSystem) CacheStats() CacheStats {
+	return f.cache.Stats()
+}
@@ -2,6 +2,7 @@ package overlay
 
 import (
 	"context"
+	"path/filepath"
 
 	"github.com/livebud/bud/package/conjure"
 )
@@ -13,11 +14,15 @@ type DirGenerator interface {
 type Dir struct {
 	fsys F
 	*conjure.Dir
+	gpath       string // generator path
+	diagnostics *diagnostics
 }
 
 func (d *Dir) GenerateFile(path string, fn func(ctx context.Context, fsys F, file *File) error) {
+	generator := filepath.Join(d.gpath, path)
 	d.Dir.GenerateFile(path, func(file *conjure.File) error {
-		return fn(context.TODO(), d.fsys, &File{file})
+		err := fn(context.TODO(), d.fsys, &File{file, d.fsys})
+		return d.diagnostics.record(generator, file.Path(), err)
 	})
 }
 
@@ -26,8 +31,10 @@ func (d *Dir) FileGenerator(path string, generator FileGenerator) {
 }
 
 func (d *Dir) GenerateDir(path string, fn func(ctx context.Context, fsys F, dir *Dir) error) {
+	generator := filepath.Join(d.gpath, path)
 	d.Dir.GenerateDir(path, func(dir *conjure.Dir) error {
-		return fn(context.TODO(), d.fsys, &Dir{d.fsys, dir})
+		err := fn(context.TODO(), d.fsys, &Dir{d.fsys, dir, generator, d.diagnostics})
+		return d.diagnostics.record(generator, dir.Path(), err)
 	})
 }
 
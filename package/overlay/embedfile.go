@@ -0,0 +1,105 @@
+package overlay
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/livebud/bud/internal/gotemplate"
+)
+
+//go:embed embed.gotext
+var embedTemplate string
+
+var embedGenerator = gotemplate.MustParse("embed.gotext", embedTemplate)
+
+type embedState struct {
+	Package string
+	Files   []*embedFile
+}
+
+type embedFile struct {
+	Path string // Quoted
+	Data string // Quoted
+	Mode string
+}
+
+// EmbedFile generates the files of the overlay's generators into a Go file at path
+// within the module. The Go file declares an FS variable containing the files,
+// so production builds don't rely on the bud directory existing on disk.
+// Project files, such as go.mod and the app's source code, aren't embedded.
+func EmbedFile(fsys *FileSystem, path string) error {
+	paths, _ := fsys.generators.within(".")
+	code, err := EmbedFS(fsys, path, paths...)
+	if err != nil {
+		return err
+	}
+	dirfs := fsys.module.DirFS()
+	if err := dirfs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return dirfs.WriteFile(path, code, 0644)
+}
+
+// Embed the generated files into a Go file at path
+func (f *FileSystem) Embed(path string) error {
+	return EmbedFile(f, path)
+}
+
+// EmbedFS generates the Go code for a file at path that embeds the files within
+// roots in fsys. Missing roots are skipped. The package is named after the
+// file's directory.
+func EmbedFS(fsys fs.FS, path string, roots ...string) ([]byte, error) {
+	state := &embedState{
+		Package: "main",
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		state.Package = filepath.Base(dir)
+	}
+	files := map[string]*embedFile{}
+	for _, root := range roots {
+		err := fs.WalkDir(fsys, root, func(fpath string, de fs.DirEntry, err error) error {
+			if err != nil {
+				if fpath == root && errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			// Don't embed the embed file itself
+			if de.IsDir() || fpath == path || files[fpath] != nil {
+				return nil
+			}
+			data, err := fs.ReadFile(fsys, fpath)
+			if err != nil {
+				return err
+			}
+			// Stat the file, since directory entries may not know the mode
+			info, err := fs.Stat(fsys, fpath)
+			if err != nil {
+				return err
+			}
+			files[fpath] = &embedFile{
+				Path: strconv.Quote(fpath),
+				Data: strconv.Quote(string(data)),
+				Mode: fmt.Sprintf("%#o", info.Mode().Perm()),
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	fpaths := make([]string, 0, len(files))
+	for fpath := range files {
+		fpaths = append(fpaths, fpath)
+	}
+	sort.Strings(fpaths)
+	for _, fpath := range fpaths {
+		state.Files = append(state.Files, files[fpath])
+	}
+	return embedGenerator.Generate(state)
+}
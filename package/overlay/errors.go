@@ -0,0 +1,123 @@
+package overlay
+
+import (
+	"errors"
+	"fmt"
+	"go/scanner"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// GenerateError is the error returned by a generator that failed. Opening the
+// generated path returns an error that wraps it, so it can be retrieved with
+// errors.As.
+type GenerateError struct {
+	Path      string // Path that was generated
+	Generator string // Path the generator was registered at
+	Err       error
+	Positions []Position // Source positions mentioned in the error
+}
+
+func (e *GenerateError) Error() string {
+	return fmt.Sprintf("overlay: unable to generate %q. %s", e.Path, e.Err)
+}
+
+func (e *GenerateError) Unwrap() error {
+	return e.Err
+}
+
+// Position in a source file
+type Position struct {
+	Path   string
+	Line   int
+	Column int // 0 if unknown
+}
+
+func (p Position) String() string {
+	if p.Column == 0 {
+		return fmt.Sprintf("%s:%d", p.Path, p.Line)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.Path, p.Line, p.Column)
+}
+
+// Errors returns the errors of the generators that failed the last time they
+// ran, sorted by path. Errors are removed once the path generates
+// successfully.
+func (f *FileSystem) Errors() []*GenerateError {
+	return f.diagnostics.list()
+}
+
+func newDiagnostics() *diagnostics {
+	return &diagnostics{errors: map[string]*GenerateError{}}
+}
+
+// diagnostics keeps the last error of each generated path
+type diagnostics struct {
+	mu     sync.Mutex
+	errors map[string]*GenerateError
+}
+
+// record the result of generating path, returning the error to pass on
+func (d *diagnostics) record(generator, path string, err error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err == nil {
+		delete(d.errors, path)
+		return nil
+	}
+	// The error came from a generated file this generator read, which has
+	// already been recorded
+	var gerr *GenerateError
+	if errors.As(err, &gerr) {
+		delete(d.errors, path)
+		return err
+	}
+	gerr = &GenerateError{
+		Path:      path,
+		Generator: generator,
+		Err:       err,
+		Positions: positionsOf(err),
+	}
+	d.errors[path] = gerr
+	return gerr
+}
+
+func (d *diagnostics) list() []*GenerateError {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	list := make([]*GenerateError, 0, len(d.errors))
+	for _, err := range d.errors {
+		list = append(list, err)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Path < list[j].Path
+	})
+	return list
+}
+
+// positionRe matches positions like view/index.go:10:4 within error messages
+var positionRe = regexp.MustCompile(`([\w./\-]+\.\w+):(\d+)(?::(\d+))?`)
+
+// positionsOf returns the source positions within the error. Go syntax errors
+// carry their positions, otherwise they're parsed from the message.
+func positionsOf(err error) (positions []Position) {
+	var list scanner.ErrorList
+	if errors.As(err, &list) {
+		for _, err := range list {
+			positions = append(positions, Position{err.Pos.Filename, err.Pos.Line, err.Pos.Column})
+		}
+		return positions
+	}
+	var serr scanner.Error
+	if errors.As(err, &serr) {
+		return []Position{{serr.Pos.Filename, serr.Pos.Line, serr.Pos.Column}}
+	}
+	for _, match := range positionRe.FindAllStringSubmatch(err.Error(), -1) {
+		line, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+		positions = append(positions, Position{match[1], line, column})
+	}
+	return positions
+}
@@ -13,9 +13,11 @@ type FileGenerator interface {
 // TODO: don't wrap, just extend
 type File struct {
 	*conjure.File
+	fsys F
 }
 
-// Link a path
+// Link the generated file to a path it reads from, so the file is
+// regenerated when the path is invalidated
 func (f *File) Link(path string) {
-
+	f.fsys.Link(f.Path(), path)
 }
@@ -0,0 +1,137 @@
+package overlay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// generators keeps track of the registered generator paths and the generator
+// paths they depend on
+type generators struct {
+	mu    sync.Mutex
+	paths []string
+	deps  map[string][]string
+}
+
+func newGenerators() *generators {
+	return &generators{deps: map[string][]string{}}
+}
+
+func (g *generators) add(path string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.paths = append(g.paths, path)
+}
+
+func (g *generators) depend(path string, deps ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.deps[path] = append(g.deps[path], deps...)
+}
+
+// within returns the generators within dir and their dependencies that are
+// also within dir
+func (g *generators) within(dir string) (paths []string, deps map[string][]string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	included := map[string]bool{}
+	for _, p := range g.paths {
+		if isWithin(dir, p) && !included[p] {
+			included[p] = true
+			paths = append(paths, p)
+		}
+	}
+	deps = map[string][]string{}
+	for _, p := range paths {
+		for _, dep := range g.deps[p] {
+			if included[dep] {
+				deps[p] = append(deps[p], dep)
+			}
+		}
+	}
+	return paths, deps
+}
+
+func isWithin(dir, p string) bool {
+	return dir == "." || p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// DependsOn declares that the generator at path reads what the generators at
+// deps generate. Generate waits for the dependencies to finish before running
+// the generator.
+func (f *FileSystem) DependsOn(path string, deps ...string) {
+	f.generators.depend(path, deps...)
+}
+
+// Generate runs the generators within dir concurrently. Each generator starts
+// once the generators it depends on have finished. The results are cached, so
+// a Sync that follows writes them out in the same order as before.
+func (f *FileSystem) Generate(ctx context.Context, dir string) error {
+	paths, deps := f.generators.within(dir)
+	if err := checkCycles(paths, deps); err != nil {
+		return err
+	}
+	done := make(map[string]chan struct{}, len(paths))
+	for _, p := range paths {
+		done[p] = make(chan struct{})
+	}
+	eg, ctx := errgroup.WithContext(ctx)
+	for _, p := range paths {
+		p := p
+		eg.Go(func() error {
+			defer close(done[p])
+			for _, dep := range deps[p] {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-done[dep]:
+				}
+			}
+			// Generators that don't generate anything are fine
+			if _, err := fs.Stat(f, p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+// checkCycles returns an error if the generators depend on each other, since
+// they would wait on each other forever
+func checkCycles(paths []string, deps map[string][]string) error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var visit func(p string, trail []string) error
+	visit = func(p string, trail []string) error {
+		switch state[p] {
+		case visiting:
+			return fmt.Errorf("overlay: generators depend on each other %s", strings.Join(append(trail, p), " -> "))
+		case visited:
+			return nil
+		}
+		state[p] = visiting
+		for _, dep := range deps[p] {
+			if err := visit(dep, append(trail, p)); err != nil {
+				return err
+			}
+		}
+		state[p] = visited
+		return nil
+	}
+	for _, p := range paths {
+		if err := visit(p, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
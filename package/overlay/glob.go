@@ -0,0 +1,96 @@
+package overlay
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// Glob returns the paths in fsys that match the pattern. Unlike fs.Glob, "**"
+// matches any number of directories, e.g. view/**/*.svelte. Only directories
+// that could contain a match are read, so generated directories outside of
+// the pattern aren't generated.
+func Glob(fsys fs.FS, pattern string) (matches []string, err error) {
+	segments := strings.Split(path.Clean(pattern), "/")
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, err
+		}
+	}
+	// Start from the longest directory without wildcards
+	i := 0
+	for i < len(segments)-1 && !hasMeta(segments[i]) {
+		i++
+	}
+	root := path.Join(segments[:i]...)
+	if root == "" {
+		root = "."
+	}
+	err = fs.WalkDir(fsys, root, func(fpath string, de fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		parts := splitPath(fpath)
+		if matchSegments(segments, parts) {
+			matches = append(matches, fpath)
+		}
+		// Don't read directories that can't contain a match
+		if de.IsDir() && !matchPrefix(segments, parts) {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+func hasMeta(segment string) bool {
+	return strings.ContainsAny(segment, `*?[\`)
+}
+
+func splitPath(fpath string) []string {
+	if fpath == "." {
+		return nil
+	}
+	return strings.Split(fpath, "/")
+}
+
+// matchSegments returns true if the path segments match the pattern segments
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pattern[0], segments[0])
+	return ok && matchSegments(pattern[1:], segments[1:])
+}
+
+// matchPrefix returns true if paths within the directory could match the
+// pattern segments
+func matchPrefix(pattern, dir []string) bool {
+	if len(pattern) == 0 {
+		return false
+	} else if pattern[0] == "**" {
+		return true
+	} else if len(dir) == 0 {
+		return true
+	}
+	ok, _ := path.Match(pattern[0], dir[0])
+	return ok && matchPrefix(pattern[1:], dir[1:])
+}
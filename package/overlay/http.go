@@ -0,0 +1,72 @@
+package overlay
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Handler serves the files in fsys over HTTP without syncing them to disk
+// first. Responses have an ETag derived from the file's contents, so clients
+// revalidate with If-None-Match and unchanged files aren't sent again.
+func Handler(fsys fs.FS) http.Handler {
+	return &handler{fsys}
+}
+
+type handler struct {
+	fsys fs.FS
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	urlPath := path.Clean(strings.TrimPrefix(r.URL.Path, "/"))
+	if urlPath == "" || !fs.ValidPath(urlPath) {
+		http.Error(w, "400 bad request", http.StatusBadRequest)
+		return
+	}
+	file, err := h.fsys.Open(urlPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			http.Error(w, "404 page not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Directories aren't listed
+	if stat.IsDir() {
+		http.Error(w, "404 page not found", http.StatusNotFound)
+		return
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	header := w.Header()
+	if contentType := mime.TypeByExtension(path.Ext(urlPath)); contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	header.Set("ETag", etag(data))
+	// Generated files can change at any time, so always revalidate
+	header.Set("Cache-Control", "no-cache")
+	http.ServeContent(w, r, urlPath, stat.ModTime(), bytes.NewReader(data))
+}
+
+// etag is a strong validator of the contents
+func etag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
@@ -0,0 +1,34 @@
+package overlay
+
+import "io/fs"
+
+// Invalidate the paths that changed along with the generated files that
+// depend on them, directly or transitively. The invalidated files are
+// regenerated the next time they're read, while the rest of the generated
+// files stay cached.
+func (f *FileSystem) Invalidate(paths ...string) {
+	for _, path := range f.dependents(paths) {
+		// Keep the cached contents to notify subscribers when they change
+		if data, err := fs.ReadFile(f.cache, path); err == nil {
+			f.changes.invalidated(path, data)
+		}
+		f.cache.Delete(path)
+	}
+}
+
+// dependents returns the paths along with every path that links to them
+func (f *FileSystem) dependents(paths []string) (dependents []string) {
+	seen := map[string]bool{}
+	queue := append([]string{}, paths...)
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		dependents = append(dependents, path)
+		queue = append(queue, f.dag.Parents(path)...)
+	}
+	return dependents
+}
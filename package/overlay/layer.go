@@ -0,0 +1,120 @@
+package overlay
+
+import (
+	"errors"
+	"io/fs"
+	"sync"
+
+	"github.com/livebud/bud/package/gomod"
+	"github.com/livebud/bud/package/merged"
+	"github.com/livebud/bud/package/pluginfs"
+)
+
+// The overlay is a union of layers. From highest to lowest priority:
+//
+//  1. generated: files and directories from generators
+//  2. mount: filesystems added with Mount
+//  3. project: files within the project module
+//  4. plugin:<import path>: files within plugins, in go.mod order
+//  5. default:<name>: framework defaults added with Default, in the order they
+//     were added
+//
+// A file in a higher layer shadows the same path in every lower layer, so
+// users can override plugin files and plugins can override framework
+// defaults. Directories are merged, with entries from higher layers shadowing
+// entries with the same name from lower layers.
+
+// Default adds the framework defaults as the lowest priority layer
+func (f *FileSystem) Default(name string, fsys fs.FS) {
+	f.layers.Add("default:"+name, fsys)
+	// Clear the cache, since the defaults may change cached directories
+	f.cache.Clear()
+}
+
+// WhichLayer returns the name of the layer that path is read from. Paths to
+// directories return the highest layer that contains the directory.
+func (f *FileSystem) WhichLayer(path string) (string, error) {
+	layers := append([]*layer{{"generated", f.cfs}, {"mount", f.mounts}}, f.layers.List()...)
+	dirLayer := ""
+	for _, layer := range layers {
+		isDir, err := statLayer(layer.fsys, path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return "", err
+		}
+		// Files shadow everything below them
+		if !isDir {
+			return layer.name, nil
+		}
+		if dirLayer == "" {
+			dirLayer = layer.name
+		}
+	}
+	if dirLayer == "" {
+		return "", &fs.PathError{Op: "which", Path: path, Err: fs.ErrNotExist}
+	}
+	return dirLayer, nil
+}
+
+func statLayer(fsys fs.FS, path string) (isDir bool, err error) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+	return stat.IsDir(), nil
+}
+
+// loadLayers loads the project and plugin layers
+func loadLayers(module *gomod.Module) (*layerFS, error) {
+	plugins, err := pluginfs.Plugins(module)
+	if err != nil {
+		return nil, err
+	}
+	layers := &layerFS{}
+	layers.Add("project", module)
+	for _, plugin := range plugins {
+		layers.Add("plugin:"+plugin.Import(), plugin)
+	}
+	return layers, nil
+}
+
+type layer struct {
+	name string
+	fsys fs.FS
+}
+
+// layerFS merges the layers below the mounts in priority order
+type layerFS struct {
+	mu     sync.RWMutex
+	layers []*layer
+}
+
+func (l *layerFS) Add(name string, fsys fs.FS) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.layers = append(l.layers, &layer{name, fsys})
+}
+
+func (l *layerFS) List() []*layer {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append([]*layer{}, l.layers...)
+}
+
+func (l *layerFS) Open(name string) (fs.File, error) {
+	layers := l.List()
+	fileSystems := make([]fs.FS, len(layers))
+	for i, layer := range layers {
+		fileSystems[i] = layer.fsys
+	}
+	return merged.Merge(fileSystems...).Open(name)
+}
+
+var _ fs.FS = (*layerFS)(nil)
@@ -0,0 +1,61 @@
+package overlay
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"testing/fstest"
+
+	"github.com/livebud/bud/package/merged"
+)
+
+// Mount an external filesystem at dir, e.g. embedded assets at public/assets.
+// Generated files take priority over mounted files. Directories that contain
+// mount points list the mounts alongside their other entries.
+func (f *FileSystem) Mount(dir string, fsys fs.FS) {
+	f.mounts.Mount(dir, fsys)
+	// Clear the cache, since the mount may change cached directories
+	f.cache.Clear()
+}
+
+// mountFS merges the mounted filesystems
+type mountFS struct {
+	mu     sync.RWMutex
+	mounts []fs.FS
+}
+
+func (m *mountFS) Mount(dir string, fsys fs.FS) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mounts = append(m.mounts, &mount{path.Clean(dir), fsys})
+}
+
+func (m *mountFS) Open(name string) (fs.File, error) {
+	m.mu.RLock()
+	mounts := merged.Merge(m.mounts...)
+	m.mu.RUnlock()
+	return mounts.Open(name)
+}
+
+// mount serves the filesystem at dir
+type mount struct {
+	dir  string
+	fsys fs.FS
+}
+
+func (m *mount) Open(name string) (fs.File, error) {
+	switch {
+	case name == m.dir:
+		return m.fsys.Open(".")
+	case strings.HasPrefix(name, m.dir+"/"):
+		return m.fsys.Open(strings.TrimPrefix(name, m.dir+"/"))
+	case name == "." || strings.HasPrefix(m.dir, name+"/"):
+		// Directories above the mount point contain the mount
+		return fstest.MapFS{m.dir: &fstest.MapFile{Mode: fs.ModeDir}}.Open(name)
+	default:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+}
+
+var _ fs.FS = (*mount)(nil)
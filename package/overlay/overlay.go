@@ -5,7 +5,6 @@ import (
 
 	"github.com/livebud/bud/internal/dsync"
 	"github.com/livebud/bud/internal/fscache"
-	"github.com/livebud/bud/internal/pubsub"
 
 	"io/fs"
 
@@ -14,35 +13,43 @@ import (
 
 	"github.com/livebud/bud/package/conjure"
 	"github.com/livebud/bud/package/gomod"
-	"github.com/livebud/bud/package/pluginfs"
+	"github.com/livebud/bud/package/vfs"
 )
 
 // Load the overlay filesystem
-func Load(module *gomod.Module) (*FileSystem, error) {
-	cache := fscache.New()
-	pluginFS, err := pluginfs.Load(module)
+func Load(module *gomod.Module, options ...Option) (*FileSystem, error) {
+	opt := &option{}
+	for _, option := range options {
+		option(opt)
+	}
+	cache := fscache.New(fscache.WithLimit(opt.cacheLimit))
+	layers, err := loadLayers(module)
 	if err != nil {
 		return nil, err
 	}
 	cfs := conjure.New()
-	merged := merged.Merge(cache.Wrap("cfs", cfs), cache.Wrap("pluginfs", pluginFS))
+	mounts := &mountFS{}
+	merged := merged.Merge(cache.Wrap("cfs", cfs), mounts, cache.Wrap("layers", layers))
 	dag := dag.New()
-	ps := pubsub.New()
-	return &FileSystem{cache, cfs, dag, cache.Wrap("merged", merged), module, ps}, nil
+	changes := newChanges()
+	diagnostics := newDiagnostics()
+	return &FileSystem{cache, cfs, dag, cache.Wrap("merged", merged), module, changes, mounts, layers, nil, diagnostics, newGenerators()}, nil
 }
 
 // Serve is just load without the cache
 // TODO: consolidate
 func Serve(module *gomod.Module) (*Server, error) {
-	pluginFS, err := pluginfs.Load(module)
+	layers, err := loadLayers(module)
 	if err != nil {
 		return nil, err
 	}
 	cfs := conjure.New()
-	merged := merged.Merge(cfs, pluginFS)
+	mounts := &mountFS{}
+	merged := merged.Merge(cfs, mounts, layers)
 	dag := dag.New()
-	ps := pubsub.New()
-	return &FileSystem{fscache.New(), cfs, dag, merged, module, ps}, nil
+	changes := newChanges()
+	diagnostics := newDiagnostics()
+	return &FileSystem{fscache.New(), cfs, dag, merged, module, changes, mounts, layers, nil, diagnostics, newGenerators()}, nil
 }
 
 type Server = FileSystem
@@ -52,21 +59,35 @@ type F interface {
 	Link(from, to string)
 }
 
+// FileSystem composes generated files with plugins and mounted filesystems.
+// It's safe for concurrent use. When loaded with Load, concurrent reads of the
+// same path share a single generation and the result is cached until it's
+// invalidated.
 type FileSystem struct {
-	cache  *fscache.Cache
-	cfs    *conjure.FileSystem
-	dag    *dag.Graph
-	fsys   fs.FS
-	module *gomod.Module
-	ps     pubsub.Client
+	cache   *fscache.Cache
+	cfs     *conjure.FileSystem
+	dag     *dag.Graph
+	fsys    fs.FS
+	module  *gomod.Module
+	changes *changes
+	mounts  *mountFS
+	layers  *layerFS
+	tracer  *Tracer
+
+	diagnostics *diagnostics
+	generators  *generators
 }
 
+// Link the generated path to a path it depends on
 func (f *FileSystem) Link(from, to string) {
+	f.dag.Link(from, to)
 }
 
 func (f *FileSystem) Open(name string) (fs.File, error) {
 	// fmt.Println("overlay opening", name)
-	return f.fsys.Open(name)
+	file, err := f.fsys.Open(name)
+	f.changes.check(f.fsys, name, err)
+	return file, err
 }
 
 var _ fs.FS = (*FileSystem)(nil)
@@ -78,8 +99,10 @@ func (fn GenerateFile) GenerateFile(ctx context.Context, fsys F, file *File) err
 }
 
 func (f *FileSystem) GenerateFile(path string, fn func(ctx context.Context, fsys F, file *File) error) {
+	f.generators.add(path)
 	f.cfs.GenerateFile(path, func(file *conjure.File) error {
-		return fn(context.TODO(), f, &File{File: file})
+		err := fn(context.TODO(), f.traced(path), &File{file, f})
+		return f.diagnostics.record(path, file.Path(), err)
 	})
 }
 
@@ -94,8 +117,11 @@ func (fn GenerateDir) GenerateDir(ctx context.Context, fsys F, dir *Dir) error {
 }
 
 func (f *FileSystem) GenerateDir(path string, fn func(ctx context.Context, fsys F, dir *Dir) error) {
+	f.generators.add(path)
 	f.cfs.GenerateDir(path, func(dir *conjure.Dir) error {
-		return fn(context.TODO(), f, &Dir{f, dir})
+		fsys := f.traced(path)
+		err := fn(context.TODO(), fsys, &Dir{fsys, dir, path, f.diagnostics})
+		return f.diagnostics.record(path, dir.Path(), err)
 	})
 }
 
@@ -105,7 +131,8 @@ func (f *FileSystem) DirGenerator(path string, generator DirGenerator) {
 
 func (f *FileSystem) ServeFile(path string, fn func(ctx context.Context, fsys F, file *File) error) {
 	f.cfs.ServeFile(path, func(file *conjure.File) error {
-		return fn(context.TODO(), f, &File{file})
+		err := fn(context.TODO(), f.traced(path), &File{file, f})
+		return f.diagnostics.record(path, file.Path(), err)
 	})
 }
 
@@ -117,5 +144,11 @@ func (f *FileSystem) FileServer(path string, server FileServer) {
 func (f *FileSystem) Sync(dir string) error {
 	// Clear the filesystem cache before syncing again
 	f.cache.Clear()
-	return dsync.Dir(f.fsys, dir, f.module.DirFS(dir), ".")
+	// Run the independent generators concurrently before writing them out
+	if err := f.Generate(context.TODO(), dir); err != nil {
+		return err
+	}
+	// Jail the target, so generated paths can't be written outside of dir
+	_, err := dsync.Dir(f, dir, vfs.Jail(f.module.Directory(dir)), ".")
+	return err
 }
@@ -1,17 +1,36 @@
 package overlay_test
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
+	"time"
 
+	"go/format"
 	"io/fs"
 
 	"github.com/livebud/bud/package/overlay"
 
 	"github.com/livebud/bud/package/gomod"
 	"github.com/livebud/bud/package/modcache"
+	"github.com/livebud/bud/package/vfs"
 	"github.com/matryer/is"
 )
 
@@ -73,3 +92,626 @@ func TestContextPropagation(t *testing.T) {
 	is.NoErr(err)
 	is.Equal(string(code), `/* normalize */`)
 }
+
+func TestMount(t *testing.T) {
+	is := is.New(t)
+	appDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(appDir, "go.mod"), []byte(`module app.com`), 0644)
+	is.NoErr(err)
+	module, err := gomod.Find(appDir)
+	is.NoErr(err)
+	ofs, err := overlay.Load(module)
+	is.NoErr(err)
+	ofs.GenerateFile("public/index.css", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		file.Data = []byte("/* index */")
+		return nil
+	})
+	ofs.Mount("public/assets", fstest.MapFS{
+		"logo.svg":       &fstest.MapFile{Data: []byte("<svg></svg>")},
+		"fonts/mono.ttf": &fstest.MapFile{Data: []byte("mono")},
+	})
+	code, err := fs.ReadFile(ofs, "public/assets/logo.svg")
+	is.NoErr(err)
+	is.Equal(string(code), "<svg></svg>")
+	code, err = fs.ReadFile(ofs, "public/assets/fonts/mono.ttf")
+	is.NoErr(err)
+	is.Equal(string(code), "mono")
+	des, err := fs.ReadDir(ofs, "public")
+	is.NoErr(err)
+	is.Equal(len(des), 2)
+	is.Equal(des[0].Name(), "assets")
+	is.True(des[0].IsDir())
+	is.Equal(des[1].Name(), "index.css")
+	des, err = fs.ReadDir(ofs, ".")
+	is.NoErr(err)
+	is.Equal(len(des), 1)
+	is.Equal(des[0].Name(), "public")
+	_, err = fs.Stat(ofs, "public/assets/missing.svg")
+	is.True(errors.Is(err, fs.ErrNotExist))
+}
+
+func TestInvalidate(t *testing.T) {
+	is := is.New(t)
+	appDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(appDir, "go.mod"), []byte(`module app.com`), 0644)
+	is.NoErr(err)
+	module, err := gomod.Find(appDir)
+	is.NoErr(err)
+	ofs, err := overlay.Load(module)
+	is.NoErr(err)
+	counts := map[string]int{}
+	ofs.GenerateFile("controller/posts.go", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		counts[file.Path()]++
+		file.Data = []byte("package posts")
+		return nil
+	})
+	ofs.GenerateFile("bud/controller/controller.go", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		counts[file.Path()]++
+		file.Link("controller/posts.go")
+		code, err := fs.ReadFile(fsys, "controller/posts.go")
+		if err != nil {
+			return err
+		}
+		file.Data = []byte("// " + string(code))
+		return nil
+	})
+	ofs.GenerateFile("bud/main.go", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		counts[file.Path()]++
+		file.Link("bud/controller/controller.go")
+		file.Data = []byte("package main")
+		return nil
+	})
+	ofs.GenerateFile("bud/view/view.go", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		counts[file.Path()]++
+		file.Data = []byte("package view")
+		return nil
+	})
+	read := func() {
+		for _, path := range []string{"bud/main.go", "bud/controller/controller.go", "bud/view/view.go"} {
+			_, err := fs.ReadFile(ofs, path)
+			is.NoErr(err)
+		}
+	}
+	read()
+	read()
+	is.Equal(counts["controller/posts.go"], 1)
+	is.Equal(counts["bud/controller/controller.go"], 1)
+	is.Equal(counts["bud/main.go"], 1)
+	is.Equal(counts["bud/view/view.go"], 1)
+	ofs.Invalidate("controller/posts.go")
+	read()
+	is.Equal(counts["controller/posts.go"], 2)
+	is.Equal(counts["bud/controller/controller.go"], 2)
+	is.Equal(counts["bud/main.go"], 2)
+	is.Equal(counts["bud/view/view.go"], 1)
+}
+
+func TestGlob(t *testing.T) {
+	is := is.New(t)
+	appDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(appDir, "go.mod"), []byte(`module app.com`), 0644)
+	is.NoErr(err)
+	module, err := gomod.Find(appDir)
+	is.NoErr(err)
+	ofs, err := overlay.Load(module)
+	is.NoErr(err)
+	ofs.GenerateDir("view", func(ctx context.Context, fsys overlay.F, dir *overlay.Dir) error {
+		dir.GenerateFile("index.svelte", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+			file.Data = []byte("<h1>index</h1>")
+			return nil
+		})
+		dir.GenerateFile("layout.go", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+			file.Data = []byte("package view")
+			return nil
+		})
+		dir.GenerateDir("posts", func(ctx context.Context, fsys overlay.F, dir *overlay.Dir) error {
+			dir.GenerateFile("show.svelte", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+				file.Data = []byte("<h1>show</h1>")
+				return nil
+			})
+			return nil
+		})
+		return nil
+	})
+	// Directories outside of the pattern aren't generated
+	ofs.GenerateDir("public", func(ctx context.Context, fsys overlay.F, dir *overlay.Dir) error {
+		return errors.New("public shouldn't be generated")
+	})
+	matches, err := overlay.Glob(ofs, "view/**/*.svelte")
+	is.NoErr(err)
+	is.Equal(matches, []string{"view/index.svelte", "view/posts/show.svelte"})
+	matches, err = overlay.Glob(ofs, "view/*.go")
+	is.NoErr(err)
+	is.Equal(matches, []string{"view/layout.go"})
+	matches, err = overlay.Glob(ofs, "controller/**/*.go")
+	is.NoErr(err)
+	is.Equal(len(matches), 0)
+	_, err = overlay.Glob(ofs, "view/[")
+	is.True(errors.Is(err, path.ErrBadPattern))
+}
+
+func TestHandler(t *testing.T) {
+	is := is.New(t)
+	appDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(appDir, "go.mod"), []byte(`module app.com`), 0644)
+	is.NoErr(err)
+	module, err := gomod.Find(appDir)
+	is.NoErr(err)
+	ofs, err := overlay.Load(module)
+	is.NoErr(err)
+	ofs.GenerateFile("bud/.app/entry.js", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		file.Data = []byte("console.log('entry')")
+		return nil
+	})
+	ofs.GenerateFile("bud/.app/broken.js", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		return errors.New("unable to bundle")
+	})
+	server := httptest.NewServer(overlay.Handler(ofs))
+	defer server.Close()
+	res, err := http.Get(server.URL + "/bud/.app/entry.js")
+	is.NoErr(err)
+	body, err := io.ReadAll(res.Body)
+	is.NoErr(err)
+	is.NoErr(res.Body.Close())
+	is.Equal(res.StatusCode, 200)
+	is.Equal(string(body), "console.log('entry')")
+	is.True(strings.HasPrefix(res.Header.Get("Content-Type"), "text/javascript"))
+	etag := res.Header.Get("ETag")
+	is.True(etag != "")
+	// Unchanged files aren't sent again
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/bud/.app/entry.js", nil)
+	is.NoErr(err)
+	req.Header.Set("If-None-Match", etag)
+	res, err = http.DefaultClient.Do(req)
+	is.NoErr(err)
+	is.NoErr(res.Body.Close())
+	is.Equal(res.StatusCode, http.StatusNotModified)
+	res, err = http.Get(server.URL + "/bud/.app/missing.js")
+	is.NoErr(err)
+	is.NoErr(res.Body.Close())
+	is.Equal(res.StatusCode, 404)
+	res, err = http.Get(server.URL + "/bud/.app/broken.js")
+	is.NoErr(err)
+	body, err = io.ReadAll(res.Body)
+	is.NoErr(err)
+	is.NoErr(res.Body.Close())
+	is.Equal(res.StatusCode, 500)
+	is.True(strings.Contains(string(body), "unable to bundle"))
+}
+
+func TestSubscribe(t *testing.T) {
+	is := is.New(t)
+	appDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(appDir, "go.mod"), []byte(`module app.com`), 0644)
+	is.NoErr(err)
+	module, err := gomod.Find(appDir)
+	is.NoErr(err)
+	ofs, err := overlay.Load(module)
+	is.NoErr(err)
+	title := "index"
+	ofs.GenerateFile("bud/view/index.js", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		file.Data = []byte("document.title = '" + title + "'")
+		return nil
+	})
+	ofs.GenerateFile("bud/view/about.js", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		file.Data = []byte("document.title = 'about'")
+		return nil
+	})
+	var changes []string
+	unsubscribe := ofs.Subscribe(func(path string) {
+		changes = append(changes, path)
+	})
+	read := func() {
+		for _, path := range []string{"bud/view/index.js", "bud/view/about.js"} {
+			_, err := fs.ReadFile(ofs, path)
+			is.NoErr(err)
+		}
+	}
+	read()
+	is.Equal(len(changes), 0)
+	// Only the files that changed are published
+	title = "home"
+	ofs.Invalidate("bud/view/index.js", "bud/view/about.js")
+	read()
+	is.Equal(changes, []string{"bud/view/index.js"})
+	// Regenerating the same contents isn't a change
+	ofs.Invalidate("bud/view/index.js")
+	read()
+	is.Equal(changes, []string{"bud/view/index.js"})
+	unsubscribe()
+	title = "welcome"
+	ofs.Invalidate("bud/view/index.js")
+	read()
+	is.Equal(changes, []string{"bud/view/index.js"})
+}
+
+func TestEmbed(t *testing.T) {
+	is := is.New(t)
+	appDir := t.TempDir()
+	err := vfs.Write(appDir, vfs.Map{
+		"go.mod":                           []byte(`module app.com`),
+		"controller/controller.go":         []byte("package controller"),
+		"node_modules/svelte/package.json": []byte("{}"),
+	})
+	is.NoErr(err)
+	module, err := gomod.Find(appDir)
+	is.NoErr(err)
+	ofs, err := overlay.Load(module)
+	is.NoErr(err)
+	ofs.GenerateFile("bud/.app/main.go", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		file.Data = []byte("package main\n\nfunc main() {}\n")
+		return nil
+	})
+	ofs.GenerateFile("bud/.app/view/index.js", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		file.Data = []byte("console.log(\"index\")")
+		file.Mode = 0644
+		return nil
+	})
+	ofs.GenerateDir("bud/.app/public", func(ctx context.Context, fsys overlay.F, dir *overlay.Dir) error {
+		dir.GenerateFile("favicon.ico", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+			file.Data = []byte("ico")
+			return nil
+		})
+		return nil
+	})
+	// Generators that don't generate anything are skipped
+	ofs.GenerateFile("bud/.app/missing.go", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		return fs.ErrNotExist
+	})
+	is.NoErr(overlay.EmbedFile(ofs, "bud/embed.go"))
+	code, err := os.ReadFile(filepath.Join(appDir, "bud", "embed.go"))
+	is.NoErr(err)
+	// Only the generated files are embedded, not the project's files
+	is.Equal(string(code), strings.Join([]string{
+		"package bud",
+		"",
+		"// GENERATED. DO NOT EDIT.",
+		"",
+		"import (",
+		"\t\"github.com/livebud/bud/package/vfs\"",
+		")",
+		"",
+		"// FS contains the generated files, so they don't need to exist on disk",
+		"var FS = vfs.Memory{",
+		"\t\"bud/.app/main.go\": &vfs.File{Data: []byte(\"package main\\n\\nfunc main() {}\\n\"), Mode: 0},",
+		"\t\"bud/.app/public/favicon.ico\": &vfs.File{Data: []byte(\"ico\"), Mode: 0},",
+		"\t\"bud/.app/view/index.js\": &vfs.File{Data: []byte(\"console.log(\\\"index\\\")\"), Mode: 0644},",
+		"}",
+		"",
+	}, "\n"))
+	// The generated code is valid Go
+	_, err = parser.ParseFile(token.NewFileSet(), "embed.go", code, 0)
+	is.NoErr(err)
+}
+
+func TestArchives(t *testing.T) {
+	is := is.New(t)
+	// Zip
+	zipData := new(bytes.Buffer)
+	zw := zip.NewWriter(zipData)
+	w, err := zw.Create("tailwind/preflight.css")
+	is.NoErr(err)
+	_, err = w.Write([]byte("/* preflight */"))
+	is.NoErr(err)
+	is.NoErr(zw.Close())
+	zipFS, err := overlay.Zip(bytes.NewReader(zipData.Bytes()), int64(zipData.Len()))
+	is.NoErr(err)
+	// Tar
+	tarData := new(bytes.Buffer)
+	gz := gzip.NewWriter(tarData)
+	tw := tar.NewWriter(gz)
+	is.NoErr(tw.WriteHeader(&tar.Header{Name: "./theme/", Typeflag: tar.TypeDir, Mode: 0755}))
+	is.NoErr(tw.WriteHeader(&tar.Header{Name: "./theme/layout.svelte", Typeflag: tar.TypeReg, Mode: 0644, Size: 6}))
+	_, err = tw.Write([]byte("<slot>"))
+	is.NoErr(err)
+	is.NoErr(tw.Close())
+	is.NoErr(gz.Close())
+	tarFS, err := overlay.Tar(tarData)
+	is.NoErr(err)
+	// Mount the archives
+	appDir := t.TempDir()
+	err = os.WriteFile(filepath.Join(appDir, "go.mod"), []byte(`module app.com`), 0644)
+	is.NoErr(err)
+	module, err := gomod.Find(appDir)
+	is.NoErr(err)
+	ofs, err := overlay.Load(module)
+	is.NoErr(err)
+	ofs.Mount("public", zipFS)
+	ofs.Mount("view", tarFS)
+	code, err := fs.ReadFile(ofs, "public/tailwind/preflight.css")
+	is.NoErr(err)
+	is.Equal(string(code), "/* preflight */")
+	code, err = fs.ReadFile(ofs, "view/theme/layout.svelte")
+	is.NoErr(err)
+	is.Equal(string(code), "<slot>")
+	stat, err := fs.Stat(ofs, "view/theme/layout.svelte")
+	is.NoErr(err)
+	is.Equal(stat.Mode(), fs.FileMode(0644))
+	// Uncompressed tar archives work too
+	tarData.Reset()
+	tw = tar.NewWriter(tarData)
+	is.NoErr(tw.WriteHeader(&tar.Header{Name: "a.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: 1}))
+	_, err = tw.Write([]byte("a"))
+	is.NoErr(err)
+	is.NoErr(tw.Close())
+	tarFS, err = overlay.Tar(tarData)
+	is.NoErr(err)
+	code, err = fs.ReadFile(tarFS, "a.txt")
+	is.NoErr(err)
+	is.Equal(string(code), "a")
+}
+
+func TestTrace(t *testing.T) {
+	is := is.New(t)
+	appDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(appDir, "go.mod"), []byte(`module app.com`), 0644)
+	is.NoErr(err)
+	module, err := gomod.Find(appDir)
+	is.NoErr(err)
+	ofs, err := overlay.Load(module)
+	is.NoErr(err)
+	tracer := ofs.Trace()
+	ofs.GenerateFile("bud/view/index.svelte", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		file.Data = []byte("<h1>index</h1>")
+		return nil
+	})
+	ofs.GenerateFile("bud/view/view.go", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		index, err := fs.ReadFile(fsys, "bud/view/index.svelte")
+		if err != nil {
+			return err
+		}
+		file.Data = []byte("package view\n\n// " + string(index))
+		return nil
+	})
+	code, err := fs.ReadFile(ofs, "bud/view/view.go")
+	is.NoErr(err)
+	is.Equal(string(code), "package view\n\n// <h1>index</h1>")
+	events := tracer.Events()
+	is.Equal(len(events), 1)
+	is.Equal(events[0].Op, "open")
+	is.Equal(events[0].Path, "bud/view/index.svelte")
+	is.Equal(events[0].Generator, "bud/view/view.go")
+	is.NoErr(events[0].Err)
+	buf := new(bytes.Buffer)
+	_, err = tracer.WriteTo(buf)
+	is.NoErr(err)
+	is.True(strings.Contains(buf.String(), "OP"))
+	is.True(strings.Contains(buf.String(), "bud/view/view.go"))
+}
+
+func TestErrors(t *testing.T) {
+	is := is.New(t)
+	appDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(appDir, "go.mod"), []byte(`module app.com`), 0644)
+	is.NoErr(err)
+	module, err := gomod.Find(appDir)
+	is.NoErr(err)
+	ofs, err := overlay.Load(module)
+	is.NoErr(err)
+	code := "package view\n\nfunc {"
+	ofs.GenerateFile("bud/view/view.go", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		formatted, err := format.Source([]byte(code))
+		if err != nil {
+			return err
+		}
+		file.Data = formatted
+		return nil
+	})
+	ofs.GenerateDir("bud/public", func(ctx context.Context, fsys overlay.F, dir *overlay.Dir) error {
+		dir.GenerateFile("index.css", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+			return errors.New("unable to bundle index.css:3:5")
+		})
+		return nil
+	})
+	is.Equal(len(ofs.Errors()), 0)
+	_, err = fs.ReadFile(ofs, "bud/view/view.go")
+	is.True(err != nil)
+	var gerr *overlay.GenerateError
+	is.True(errors.As(err, &gerr))
+	is.Equal(gerr.Path, "bud/view/view.go")
+	_, err = fs.ReadFile(ofs, "bud/public/index.css")
+	is.True(err != nil)
+	diagnostics := ofs.Errors()
+	is.Equal(len(diagnostics), 2)
+	is.Equal(diagnostics[0].Path, "bud/public/index.css")
+	is.Equal(diagnostics[0].Generator, "bud/public/index.css")
+	is.Equal(diagnostics[0].Err.Error(), "unable to bundle index.css:3:5")
+	is.Equal(len(diagnostics[0].Positions), 1)
+	is.Equal(diagnostics[0].Positions[0].String(), "index.css:3:5")
+	is.Equal(diagnostics[1].Path, "bud/view/view.go")
+	is.Equal(diagnostics[1].Generator, "bud/view/view.go")
+	is.Equal(len(diagnostics[1].Positions), 1)
+	is.Equal(diagnostics[1].Positions[0].Line, 3)
+	// Fix the error
+	code = "package view\n\nfunc main() {}\n"
+	ofs.Invalidate("bud/view/view.go")
+	_, err = fs.ReadFile(ofs, "bud/view/view.go")
+	is.NoErr(err)
+	diagnostics = ofs.Errors()
+	is.Equal(len(diagnostics), 1)
+	is.Equal(diagnostics[0].Path, "bud/public/index.css")
+}
+
+func TestWhichLayer(t *testing.T) {
+	is := is.New(t)
+	appDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(appDir, "go.mod"), []byte(`module app.com`), 0644)
+	is.NoErr(err)
+	err = os.MkdirAll(filepath.Join(appDir, "view"), 0755)
+	is.NoErr(err)
+	err = os.WriteFile(filepath.Join(appDir, "view", "layout.svelte"), []byte(`<slot />`), 0644)
+	is.NoErr(err)
+	module, err := gomod.Find(appDir)
+	is.NoErr(err)
+	ofs, err := overlay.Load(module)
+	is.NoErr(err)
+	ofs.Default("bud", fstest.MapFS{
+		"view/layout.svelte": &fstest.MapFile{Data: []byte(`<main><slot /></main>`)},
+		"view/error.svelte":  &fstest.MapFile{Data: []byte(`<h1>error</h1>`)},
+	})
+	ofs.Mount("public", fstest.MapFS{
+		"favicon.ico": &fstest.MapFile{Data: []byte("ico")},
+	})
+	ofs.GenerateFile("bud/view/view.go", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		file.Data = []byte("package view")
+		return nil
+	})
+	// Project files shadow the defaults
+	layer, err := ofs.WhichLayer("view/layout.svelte")
+	is.NoErr(err)
+	is.Equal(layer, "project")
+	data, err := fs.ReadFile(ofs, "view/layout.svelte")
+	is.NoErr(err)
+	is.Equal(string(data), `<slot />`)
+	layer, err = ofs.WhichLayer("view/error.svelte")
+	is.NoErr(err)
+	is.Equal(layer, "default:bud")
+	data, err = fs.ReadFile(ofs, "view/error.svelte")
+	is.NoErr(err)
+	is.Equal(string(data), `<h1>error</h1>`)
+	// Directories are merged
+	des, err := fs.ReadDir(ofs, "view")
+	is.NoErr(err)
+	is.Equal(len(des), 2)
+	layer, err = ofs.WhichLayer("view")
+	is.NoErr(err)
+	is.Equal(layer, "project")
+	layer, err = ofs.WhichLayer("public/favicon.ico")
+	is.NoErr(err)
+	is.Equal(layer, "mount")
+	layer, err = ofs.WhichLayer("bud/view/view.go")
+	is.NoErr(err)
+	is.Equal(layer, "generated")
+	_, err = ofs.WhichLayer("view/missing.svelte")
+	is.True(errors.Is(err, fs.ErrNotExist))
+}
+
+func TestFileMetadata(t *testing.T) {
+	is := is.New(t)
+	appDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(appDir, "go.mod"), []byte(`module app.com`), 0644)
+	is.NoErr(err)
+	module, err := gomod.Find(appDir)
+	is.NoErr(err)
+	ofs, err := overlay.Load(module)
+	is.NoErr(err)
+	modTime := time.Date(2021, 8, 4, 14, 56, 0, 0, time.UTC)
+	ofs.GenerateFile("bud/app", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		file.Data = []byte("#!/bin/sh\necho app")
+		file.Mode = 0755
+		file.ModTime = modTime
+		return nil
+	})
+	stat, err := fs.Stat(ofs, "bud/app")
+	is.NoErr(err)
+	is.Equal(stat.Mode(), fs.FileMode(0755))
+	is.True(stat.ModTime().Equal(modTime))
+	// Synced files keep the mode and modtime
+	err = ofs.Sync("bud")
+	is.NoErr(err)
+	stat, err = os.Stat(filepath.Join(appDir, "bud", "app"))
+	is.NoErr(err)
+	is.Equal(stat.Mode(), fs.FileMode(0755))
+	is.True(stat.ModTime().Equal(modTime))
+}
+
+func TestCacheLimit(t *testing.T) {
+	is := is.New(t)
+	appDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(appDir, "go.mod"), []byte(`module app.com`), 0644)
+	is.NoErr(err)
+	module, err := gomod.Find(appDir)
+	is.NoErr(err)
+	ofs, err := overlay.Load(module, overlay.WithCacheLimit(1024))
+	is.NoErr(err)
+	generated := 0
+	for i := 0; i < 4; i++ {
+		ofs.GenerateFile(fmt.Sprintf("bud/view/%d.js", i), func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+			generated++
+			file.Data = bytes.Repeat([]byte("a"), 300)
+			return nil
+		})
+	}
+	for i := 0; i < 4; i++ {
+		_, err := fs.ReadFile(ofs, fmt.Sprintf("bud/view/%d.js", i))
+		is.NoErr(err)
+	}
+	is.Equal(generated, 4)
+	stats := ofs.CacheStats()
+	is.True(stats.Evictions > 0)
+	is.True(stats.Size <= 1024)
+	// Evicted files are regenerated
+	_, err = fs.ReadFile(ofs, "bud/view/0.js")
+	is.NoErr(err)
+	is.Equal(generated, 5)
+}
+
+func TestGenerate(t *testing.T) {
+	is := is.New(t)
+	appDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(appDir, "go.mod"), []byte(`module app.com`), 0644)
+	is.NoErr(err)
+	module, err := gomod.Find(appDir)
+	is.NoErr(err)
+	ofs, err := overlay.Load(module)
+	is.NoErr(err)
+	// a and b only finish once both have started, so they must run concurrently
+	started := new(sync.WaitGroup)
+	started.Add(2)
+	bothStarted := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(bothStarted)
+	}()
+	var finished int32
+	independent := func(name string) func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		return func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+			started.Done()
+			select {
+			case <-bothStarted:
+			case <-time.After(5 * time.Second):
+				return errors.New("generators didn't run concurrently")
+			}
+			atomic.AddInt32(&finished, 1)
+			file.Data = []byte(name)
+			return nil
+		}
+	}
+	ofs.GenerateFile("bud/a.txt", independent("a"))
+	ofs.GenerateFile("bud/b.txt", independent("b"))
+	ofs.GenerateFile("bud/c.txt", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		is.Equal(atomic.LoadInt32(&finished), int32(2)) // a and b should have finished
+		file.Data = []byte("c")
+		return nil
+	})
+	ofs.DependsOn("bud/c.txt", "bud/a.txt", "bud/b.txt")
+	err = ofs.Sync("bud")
+	is.NoErr(err)
+	for _, name := range []string{"a", "b", "c"} {
+		data, err := os.ReadFile(filepath.Join(appDir, "bud", name+".txt"))
+		is.NoErr(err)
+		is.Equal(string(data), name)
+	}
+}
+
+func TestGenerateCycle(t *testing.T) {
+	is := is.New(t)
+	appDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(appDir, "go.mod"), []byte(`module app.com`), 0644)
+	is.NoErr(err)
+	module, err := gomod.Find(appDir)
+	is.NoErr(err)
+	ofs, err := overlay.Load(module)
+	is.NoErr(err)
+	generate := func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		file.Data = []byte("cycle")
+		return nil
+	}
+	ofs.GenerateFile("bud/a.txt", generate)
+	ofs.GenerateFile("bud/b.txt", generate)
+	ofs.DependsOn("bud/a.txt", "bud/b.txt")
+	ofs.DependsOn("bud/b.txt", "bud/a.txt")
+	err = ofs.Generate(context.Background(), "bud")
+	is.True(err != nil)
+	is.Equal(err.Error(), "overlay: generators depend on each other bud/a.txt -> bud/b.txt -> bud/a.txt")
+}
@@ -0,0 +1,13 @@
+package overlay
+
+// Snapshot the generated files that are currently cached. This is useful for
+// checkpointing before a build that may fail.
+func (f *FileSystem) Snapshot() ([]byte, error) {
+	return f.cache.Snapshot()
+}
+
+// Restore the cached files from a snapshot, discarding files that were
+// generated since the snapshot was taken
+func (f *FileSystem) Restore(snapshot []byte) error {
+	return f.cache.Restore(snapshot)
+}
@@ -1,7 +1,90 @@
 package overlay
 
-import "github.com/livebud/bud/internal/pubsub"
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"sync"
+)
 
-func (f *FileSystem) Subscribe(name string) pubsub.Subscription {
-	return f.ps.Subscribe(name)
+// Subscribe calls fn with the path of each invalidated file whose regenerated
+// contents differ from the contents before it was invalidated. Files that no
+// longer exist after being regenerated are also reported. Call the returned
+// function to unsubscribe.
+func (f *FileSystem) Subscribe(fn func(path string)) (unsubscribe func()) {
+	return f.changes.subscribe(fn)
+}
+
+func newChanges() *changes {
+	return &changes{
+		subscribers: map[int]func(path string){},
+		previous:    map[string][]byte{},
+	}
+}
+
+// changes notifies subscribers when invalidated files change
+type changes struct {
+	mu          sync.Mutex
+	id          int
+	subscribers map[int]func(path string)
+	previous    map[string][]byte // contents of the invalidated files
+}
+
+func (c *changes) subscribe(fn func(path string)) func() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := c.id
+	c.subscribers[id] = fn
+	c.id++
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.subscribers, id)
+	}
+}
+
+// invalidated keeps the contents of the file before it's regenerated
+func (c *changes) invalidated(path string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Keep the oldest contents when invalidated more than once
+	if _, ok := c.previous[path]; !ok {
+		c.previous[path] = data
+	}
+}
+
+// check if the opened file changed since it was invalidated
+func (c *changes) check(fsys fs.FS, path string, err error) {
+	c.mu.Lock()
+	previous, ok := c.previous[path]
+	if !ok {
+		c.mu.Unlock()
+		return
+	}
+	delete(c.previous, path)
+	c.mu.Unlock()
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		c.publish(path)
+	case err != nil:
+		// Generation failed, so check again once it succeeds
+		c.invalidated(path, previous)
+	default:
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil || !bytes.Equal(data, previous) {
+			c.publish(path)
+		}
+	}
+}
+
+func (c *changes) publish(path string) {
+	c.mu.Lock()
+	subscribers := make([]func(path string), 0, len(c.subscribers))
+	for _, fn := range c.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	c.mu.Unlock()
+	for _, fn := range subscribers {
+		fn(path)
+	}
 }
@@ -0,0 +1,145 @@
+package overlay
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Trace records the Open, ReadDir and Stat calls that generators make, along
+// with how long they took. Call Trace before reading from the filesystem.
+func (f *FileSystem) Trace() *Tracer {
+	if f.tracer == nil {
+		f.tracer = &Tracer{}
+	}
+	return f.tracer
+}
+
+// traced returns the filesystem passed to the generator at path
+func (f *FileSystem) traced(generator string) F {
+	if f.tracer == nil {
+		return f
+	}
+	return &tracedF{f, f.tracer.Wrap(generator, f)}
+}
+
+// TraceEvent is a traced filesystem call
+type TraceEvent struct {
+	Op        string // open, readdir or stat
+	Path      string
+	Generator string // Path of the generator that made the call
+	Duration  time.Duration
+	Err       error
+}
+
+// Tracer records filesystem calls
+type Tracer struct {
+	mu     sync.Mutex
+	events []*TraceEvent
+}
+
+// Wrap the filesystem, recording its calls as coming from the generator. Use
+// an empty generator for calls made outside of generators.
+func (t *Tracer) Wrap(generator string, fsys fs.FS) fs.FS {
+	return &tracedFS{t, generator, fsys}
+}
+
+// Events returns the recorded events in the order the calls finished
+func (t *Tracer) Events() []*TraceEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	events := make([]*TraceEvent, len(t.events))
+	copy(events, t.events)
+	return events
+}
+
+// WriteTo writes the recorded events as a table
+func (t *Tracer) WriteTo(w io.Writer) (int64, error) {
+	counter := &countWriter{w: w}
+	tw := tabwriter.NewWriter(counter, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "OP\tPATH\tDURATION\tGENERATOR\tERROR")
+	for _, event := range t.Events() {
+		generator := event.Generator
+		if generator == "" {
+			generator = "-"
+		}
+		errMessage := "-"
+		if event.Err != nil {
+			errMessage = event.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", event.Op, event.Path, event.Duration, generator, errMessage)
+	}
+	if err := tw.Flush(); err != nil {
+		return counter.n, err
+	}
+	return counter.n, nil
+}
+
+func (t *Tracer) record(op, path, generator string, start time.Time, err error) {
+	event := &TraceEvent{op, path, generator, time.Since(start), err}
+	t.mu.Lock()
+	t.events = append(t.events, event)
+	t.mu.Unlock()
+}
+
+type tracedFS struct {
+	t         *Tracer
+	generator string
+	fsys      fs.FS
+}
+
+var _ fs.ReadDirFS = (*tracedFS)(nil)
+var _ fs.StatFS = (*tracedFS)(nil)
+
+func (t *tracedFS) Open(name string) (fs.File, error) {
+	start := time.Now()
+	file, err := t.fsys.Open(name)
+	t.t.record("open", name, t.generator, start, err)
+	return file, err
+}
+
+func (t *tracedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	start := time.Now()
+	des, err := fs.ReadDir(t.fsys, name)
+	t.t.record("readdir", name, t.generator, start, err)
+	return des, err
+}
+
+func (t *tracedFS) Stat(name string) (fs.FileInfo, error) {
+	start := time.Now()
+	info, err := fs.Stat(t.fsys, name)
+	t.t.record("stat", name, t.generator, start, err)
+	return info, err
+}
+
+// tracedF traces the calls a generator makes to the overlay
+type tracedF struct {
+	f *FileSystem
+	fs.FS
+}
+
+func (t *tracedF) Link(from, to string) {
+	t.f.Link(from, to)
+}
+
+func (t *tracedF) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(t.FS, name)
+}
+
+func (t *tracedF) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(t.FS, name)
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
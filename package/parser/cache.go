@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"go/ast"
+	"sync"
+)
+
+// Stats about the parsed package cache
+type Stats struct {
+	Hits   int
+	Misses int
+}
+
+// cache of parsed packages keyed by directory. Entries are only reused while
+// the hash of the package's files is unchanged, so only packages that changed
+// since the last parse are parsed again. It's safe for concurrent use.
+type cache struct {
+	mu       sync.Mutex
+	packages map[string]*cacheEntry
+	stats    Stats
+}
+
+type cacheEntry struct {
+	hash uint64
+	node *ast.Package
+}
+
+func newCache() *cache {
+	return &cache{
+		packages: map[string]*cacheEntry{},
+	}
+}
+
+// Get the parsed package if the hash of its files matches
+func (c *cache) Get(dir string, hash uint64) (*ast.Package, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.packages[dir]
+	if !ok || entry.hash != hash {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	return entry.node, true
+}
+
+// Set the parsed package, replacing a stale entry
+func (c *cache) Set(dir string, hash uint64, node *ast.Package) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.packages[dir] = &cacheEntry{hash, node}
+}
+
+func (c *cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
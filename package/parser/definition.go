@@ -14,51 +14,31 @@ func (pkg *Package) definition(name string) (decl Declaration, err error) {
 		return builtin(name), nil
 	}
 	err = fmt.Errorf("parser: unable to find declaration for %q in %q", name, pkg.Name())
-	var ts *ast.TypeSpec
 	for _, file := range pkg.Files() {
-		file := file
-		ast.Inspect(pkg.node, func(node ast.Node) bool {
-			switch n := node.(type) {
-			case *ast.TypeSpec:
-				// TODO: handle type declarations (e.g. type A string)
-				if n.Assign == 0 {
-					ts = n
-					return true
-				}
-				decl = &Alias{
-					file: file,
-					ts:   n,
-				}
-				err = nil
-				return false
-			case *ast.StructType:
-				if ts == nil || ts.Name.Name != name {
-					return true
-				}
-				decl = &Struct{
-					file: file,
-					ts:   ts,
-					node: n,
+		for _, d := range file.node.Decls {
+			node, ok := d.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range node.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok || ts.Name.Name != name {
+					continue
 				}
-				err = nil
-				return false
-			case *ast.InterfaceType:
-				if ts == nil || ts.Name.Name != name {
-					return true
+				if ts.Assign != 0 {
+					return &Alias{file: file, ts: ts}, nil
 				}
-				decl = &Interface{
-					file: file,
-					ts:   ts,
-					node: n,
+				switch n := ts.Type.(type) {
+				case *ast.StructType:
+					return &Struct{file: file, ts: ts, node: n}, nil
+				case *ast.InterfaceType:
+					return &Interface{file: file, ts: ts, node: n}, nil
 				}
-				err = nil
-				return false
-			// TODO: support const and var
-			case *ast.ValueSpec:
+				// TODO: handle type declarations (e.g. type A string)
 			}
-			return true
-		})
+		}
 	}
+	// TODO: support const and var
 	return decl, err
 }
 
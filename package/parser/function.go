@@ -36,6 +36,11 @@ func (fn *Function) Name() string {
 	return fn.node.Name.Name
 }
 
+// Doc returns the doc comment above the function, if any
+func (fn *Function) Doc() string {
+	return fn.node.Doc.Text()
+}
+
 // Receiver returns the receiver field, if any
 func (fn *Function) Receiver() *Receiver {
 	if fn.node.Recv == nil {
@@ -68,14 +73,14 @@ func (fn *Function) Params() (fields []*Param) {
 	}
 	// List of fields
 	for _, field := range params.List {
+		if len(field.Names) == 0 {
+			fields = append(fields, &Param{
+				parent: fn,
+				node:   field,
+			})
+			continue
+		}
 		for _, name := range field.Names {
-			if len(field.Names) == 0 {
-				fields = append(fields, &Param{
-					parent: fn,
-					node:   field,
-				})
-				continue
-			}
 			fields = append(fields, &Param{
 				parent: fn,
 				name:   name.Name,
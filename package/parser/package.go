@@ -129,6 +129,16 @@ func (pkg *Package) Functions() (fns []*Function) {
 	return fns
 }
 
+// Function returns a function by name, ignoring methods
+func (pkg *Package) Function(name string) *Function {
+	for _, fn := range pkg.Functions() {
+		if fn.Name() == name && fn.Receiver() == nil {
+			return fn
+		}
+	}
+	return nil
+}
+
 // PublicFunctions returns all public functions in the package
 func (pkg *Package) PublicFunctions() (fns []*Function) {
 	for _, file := range pkg.Files() {
@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"unicode"
 
+	"github.com/cespare/xxhash"
 	"github.com/livebud/bud/package/gomod"
 )
 
@@ -20,6 +21,7 @@ func New(fsys fs.FS, module *gomod.Module) *Parser {
 	return &Parser{
 		fsys:   fsys,
 		module: module,
+		cache:  newCache(),
 	}
 }
 
@@ -27,32 +29,61 @@ func New(fsys fs.FS, module *gomod.Module) *Parser {
 type Parser struct {
 	fsys   fs.FS
 	module *gomod.Module
+	cache  *cache
 }
 
-// Parse a dir containing Go files.
+// In returns a parser for another module that shares this parser's cache
+func (p *Parser) In(fsys fs.FS, module *gomod.Module) *Parser {
+	return &Parser{
+		fsys:   fsys,
+		module: module,
+		cache:  p.cache,
+	}
+}
+
+// Stats returns the hits and misses of the parsed package cache
+func (p *Parser) Stats() Stats {
+	return p.cache.Stats()
+}
+
+// Parse a dir containing Go files. Packages whose files haven't changed since
+// they were last parsed are loaded from the cache.
 func (p *Parser) Parse(dir string) (*Package, error) {
 	imported, err := p.Import(dir)
 	if err != nil {
 		return nil, err
 	}
+	// Read and hash each valid Go file
+	codes := make([][]byte, len(imported.GoFiles))
+	hash := xxhash.New()
+	for i, filename := range imported.GoFiles {
+		code, err := fs.ReadFile(p.fsys, path.Join(dir, filename))
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		fmt.Fprintf(hash, "%s %d\n", filename, len(code))
+		hash.Write(code)
+	}
+	key := filepath.Join(p.module.Directory(), dir)
+	if parsedPackage, ok := p.cache.Get(key, hash.Sum64()); ok {
+		return newPackage(dir, p, p.module, parsedPackage), nil
+	}
 	parsedPackage := &ast.Package{
 		Name:  imported.Name,
 		Files: make(map[string]*ast.File),
 	}
 	fset := token.NewFileSet()
 	// Parse each valid Go file
-	for _, filename := range imported.GoFiles {
+	for i, filename := range imported.GoFiles {
 		filename = path.Join(dir, filename)
-		code, err := fs.ReadFile(p.fsys, filename)
-		if err != nil {
-			return nil, err
-		}
-		parsedFile, err := parser.ParseFile(fset, filename, code, parser.DeclarationErrors)
+		parsedFile, err := parser.ParseFile(fset, filename, codes[i], parser.DeclarationErrors|parser.ParseComments)
 		if err != nil {
 			return nil, err
 		}
 		parsedPackage.Files[filename] = parsedFile
 	}
+	p.cache.Set(key, hash.Sum64(), parsedPackage)
 	pkg := newPackage(dir, p, p.module, parsedPackage)
 	return pkg, nil
 }
@@ -255,3 +255,118 @@ func TestGenerate(t *testing.T) {
 	is.True(alias != nil)
 	is.Equal(alias.Name(), "Answer")
 }
+
+func TestDoc(t *testing.T) {
+	is := is.New(t)
+	appDir := t.TempDir()
+	err := vfs.Write(appDir, vfs.Map{
+		"go.mod": []byte(`module app.com/app`),
+		"app.go": []byte(`
+			package app
+
+			// A is documented
+			type A struct{}
+
+			type (
+				// B is grouped
+				B struct{}
+				C struct{}
+			)
+
+			type D struct{}
+
+			// Run A
+			func (a *A) Run() {}
+		`),
+	})
+	is.NoErr(err)
+	module, err := gomod.Find(appDir)
+	is.NoErr(err)
+	p := parser.New(module, module)
+	pkg, err := p.Parse(".")
+	is.NoErr(err)
+	is.Equal(pkg.Struct("A").Doc(), "A is documented\n")
+	is.Equal(pkg.Struct("B").Doc(), "B is grouped\n")
+	is.Equal(pkg.Struct("C").Doc(), "")
+	is.Equal(pkg.Struct("D").Doc(), "")
+	is.Equal(pkg.Struct("A").Method("Run").Doc(), "Run A\n")
+	is.Equal(pkg.Struct("B").Method("Run"), nil)
+}
+
+func TestParams(t *testing.T) {
+	is := is.New(t)
+	appDir := t.TempDir()
+	err := vfs.Write(appDir, vfs.Map{
+		"go.mod": []byte(`module app.com/app`),
+		"app.go": []byte(`
+			package app
+
+			import "net/http"
+
+			func Named(a, b string, c *http.Request) {}
+			func Unnamed(string, *http.Request) {}
+		`),
+	})
+	is.NoErr(err)
+	module, err := gomod.Find(appDir)
+	is.NoErr(err)
+	p := parser.New(module, module)
+	pkg, err := p.Parse(".")
+	is.NoErr(err)
+	params := pkg.Function("Named").Params()
+	is.Equal(len(params), 3)
+	is.Equal(params[0].Name(), "a")
+	is.Equal(params[1].Name(), "b")
+	is.Equal(params[2].Name(), "c")
+	is.Equal(params[2].Type().String(), "*http.Request")
+	params = pkg.Function("Unnamed").Params()
+	is.Equal(len(params), 2)
+	is.Equal(params[0].Name(), "")
+	is.Equal(params[0].Type().String(), "string")
+	is.Equal(params[1].Type().String(), "*http.Request")
+}
+
+func TestCache(t *testing.T) {
+	is := is.New(t)
+	appDir := t.TempDir()
+	err := vfs.Write(appDir, vfs.Map{
+		"go.mod": []byte(`module app.com/app`),
+		"app.go": []byte(`
+			package app
+
+			import "net/http"
+
+			type A struct {
+				*http.Request
+			}
+		`),
+	})
+	is.NoErr(err)
+	module, err := gomod.Find(appDir)
+	is.NoErr(err)
+	p := parser.New(module, module)
+	pkg, err := p.Parse(".")
+	is.NoErr(err)
+	is.True(pkg.Struct("A") != nil)
+	is.Equal(p.Stats(), parser.Stats{Hits: 0, Misses: 1})
+	// Unchanged packages are cached
+	pkg, err = p.Parse(".")
+	is.NoErr(err)
+	is.True(pkg.Struct("A") != nil)
+	is.Equal(p.Stats(), parser.Stats{Hits: 1, Misses: 1})
+	// Definitions in other modules share the cache
+	_, err = pkg.Struct("A").Field("Request").Definition()
+	is.NoErr(err)
+	is.Equal(p.Stats(), parser.Stats{Hits: 1, Misses: 2})
+	_, err = pkg.Struct("A").Field("Request").Definition()
+	is.NoErr(err)
+	is.Equal(p.Stats(), parser.Stats{Hits: 2, Misses: 2})
+	// Changed packages are parsed again
+	err = os.WriteFile(filepath.Join(appDir, "app.go"), []byte("package app\ntype B struct{}"), 0644)
+	is.NoErr(err)
+	pkg, err = p.Parse(".")
+	is.NoErr(err)
+	is.True(pkg.Struct("A") == nil)
+	is.True(pkg.Struct("B") != nil)
+	is.Equal(p.Stats(), parser.Stats{Hits: 2, Misses: 3})
+}
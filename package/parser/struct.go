@@ -37,6 +37,24 @@ func (stct *Struct) Name() string {
 	return stct.ts.Name.Name
 }
 
+// Doc returns the doc comment above the struct, if any
+func (stct *Struct) Doc() string {
+	if stct.ts.Doc != nil {
+		return stct.ts.Doc.Text()
+	}
+	// Comments above a single type declaration are attached to the declaration
+	for _, decl := range stct.file.node.Decls {
+		node, ok := decl.(*ast.GenDecl)
+		if !ok || node.Doc == nil || len(node.Specs) != 1 {
+			continue
+		}
+		if node.Specs[0] == stct.ts {
+			return node.Doc.Text()
+		}
+	}
+	return ""
+}
+
 func (stct *Struct) Kind() Kind {
 	return KindStruct
 }
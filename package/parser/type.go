@@ -361,7 +361,7 @@ func (t *SelectorType) Definition() (Declaration, error) {
 	if err != nil {
 		return nil, err
 	}
-	newPkg, err := New(fsys, module).Parse(rel)
+	newPkg, err := pkg.Parser().In(fsys, module).Parse(rel)
 	if err != nil {
 		return nil, err
 	}
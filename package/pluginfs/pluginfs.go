@@ -29,19 +29,24 @@ func Load(module *gomod.Module, options ...Option) (fs.FS, error) {
 	opt := &option{
 		fsCache: nil,
 	}
-	plugins, err := loadPlugins(module)
+	plugins, err := Plugins(module)
 	if err != nil {
 		return nil, err
 	}
-	merged := merged.Merge(append([]fs.FS{module}, plugins...)...)
+	fileSystems := []fs.FS{module}
+	for _, plugin := range plugins {
+		fileSystems = append(fileSystems, plugin)
+	}
+	merged := merged.Merge(fileSystems...)
 	return &FS{
 		opt:    opt,
 		merged: merged,
 	}, nil
 }
 
-// Load plugins
-func loadPlugins(module *gomod.Module) (plugins []fs.FS, err error) {
+// Plugins returns the plugin modules required by the module in go.mod order.
+// Plugins are modules whose last path element starts with "bud-".
+func Plugins(module *gomod.Module) (plugins []*gomod.Module, err error) {
 	modfile := module.File()
 	var importPaths []string
 	for _, req := range modfile.Requires() {
@@ -52,7 +57,7 @@ func loadPlugins(module *gomod.Module) (plugins []fs.FS, err error) {
 		importPaths = append(importPaths, req.Mod.Path)
 	}
 	// Concurrently resolve directories
-	plugins = make([]fs.FS, len(importPaths))
+	plugins = make([]*gomod.Module, len(importPaths))
 	eg := new(errgroup.Group)
 	for i, importPath := range importPaths {
 		i, importPath := i, importPath
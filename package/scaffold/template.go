@@ -1,7 +1,6 @@
 package scaffold
 
 import (
-	"go/format"
 	"path"
 	"path/filepath"
 
@@ -29,7 +28,12 @@ type Template struct {
 }
 
 func (t *Template) Write(fsys vfs.ReadWritable) error {
-	generator, err := gotemplate.Parse(t.Path, t.Code)
+	var options []gotemplate.Option
+	// Format Go code automatically
+	if filepath.Ext(t.Path) == ".go" {
+		options = append(options, gotemplate.WithFormat())
+	}
+	generator, err := gotemplate.Parse(t.Path, t.Code, options...)
 	if err != nil {
 		return err
 	}
@@ -37,13 +41,6 @@ func (t *Template) Write(fsys vfs.ReadWritable) error {
 	if err != nil {
 		return err
 	}
-	// Format Go code automatically
-	if filepath.Ext(t.Path) == ".go" {
-		code, err = format.Source(code)
-		if err != nil {
-			return err
-		}
-	}
 	if err := fsys.MkdirAll(path.Dir(t.Path), 0755); err != nil {
 		return err
 	}
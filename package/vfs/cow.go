@@ -0,0 +1,302 @@
+package vfs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CopyOnWrite layers writes in memory on top of a read-only base filesystem.
+// The base is never written to. Use Changes to see what would have changed.
+func CopyOnWrite(base fs.FS) *COW {
+	return &COW{
+		base:    base,
+		upper:   Memory{},
+		removed: map[string]bool{},
+	}
+}
+
+// COW is a copy-on-write filesystem. It's safe for concurrent use.
+type COW struct {
+	mu      sync.RWMutex
+	base    fs.FS
+	upper   Memory          // files and directories that were written
+	removed map[string]bool // paths removed from the base
+}
+
+var _ ReadWritable = (*COW)(nil)
+
+// ChangeType is the type of change made to a path
+type ChangeType uint8
+
+const (
+	CreateChange ChangeType = iota + 1
+	UpdateChange
+	DeleteChange
+)
+
+func (c ChangeType) String() string {
+	switch c {
+	case CreateChange:
+		return "create"
+	case UpdateChange:
+		return "update"
+	case DeleteChange:
+		return "delete"
+	default:
+		return "unknown"
+	}
+}
+
+// Change to a path in the base
+type Change struct {
+	Type ChangeType
+	Path string
+}
+
+func (c Change) String() string {
+	return c.Type.String() + ":" + c.Path
+}
+
+func (c *COW) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	upper, err := c.upper.Open(name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	var upperDir bool
+	if upper != nil {
+		stat, err := upper.Stat()
+		if err != nil {
+			upper.Close()
+			return nil, err
+		}
+		// Written files shadow the base
+		if !stat.IsDir() {
+			return upper, nil
+		}
+		upperDir = true
+	}
+	if c.hidden(name) {
+		if upper == nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		return upper, nil
+	}
+	base, err := c.base.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && upper != nil {
+			return upper, nil
+		}
+		return nil, err
+	}
+	stat, err := base.Stat()
+	if err != nil {
+		base.Close()
+		return nil, err
+	}
+	if !stat.IsDir() {
+		// Written directories shadow base files
+		if upperDir {
+			base.Close()
+			return upper, nil
+		}
+		return base, nil
+	}
+	return c.mergeDir(name, stat, base, upper)
+}
+
+// mergeDir merges the written entries with the base entries that weren't
+// removed
+func (c *COW) mergeDir(name string, stat fs.FileInfo, base, upper fs.File) (fs.File, error) {
+	defer base.Close()
+	entries := map[string]fs.DirEntry{}
+	if upper != nil {
+		defer upper.Close()
+		des, err := readDir(upper)
+		if err != nil {
+			return nil, err
+		}
+		for _, de := range des {
+			entries[de.Name()] = de
+		}
+	}
+	des, err := readDir(base)
+	if err != nil {
+		return nil, err
+	}
+	for _, de := range des {
+		if _, ok := entries[de.Name()]; ok {
+			continue
+		} else if c.removed[path.Join(name, de.Name())] {
+			continue
+		}
+		entries[de.Name()] = de
+	}
+	dir := &cowDir{info: stat}
+	for _, de := range entries {
+		dir.entries = append(dir.entries, de)
+	}
+	sort.Slice(dir.entries, func(i, j int) bool {
+		return dir.entries[i].Name() < dir.entries[j].Name()
+	})
+	return dir, nil
+}
+
+func readDir(file fs.File) ([]fs.DirEntry, error) {
+	dir, ok := file.(fs.ReadDirFile)
+	if !ok {
+		return nil, errors.New("vfs: directory doesn't implement ReadDirFile")
+	}
+	return dir.ReadDir(-1)
+}
+
+// hidden returns true if the path or one of its parents was removed from the
+// base
+func (c *COW) hidden(name string) bool {
+	for p := name; p != "."; p = path.Dir(p) {
+		if c.removed[p] {
+			return true
+		}
+	}
+	return c.removed["."]
+}
+
+func (c *COW) MkdirAll(dir string, perm fs.FileMode) error {
+	if !fs.ValidPath(dir) {
+		return &fs.PathError{Op: "mkdir", Path: dir, Err: fs.ErrInvalid}
+	}
+	// Nothing to do if the directory is already visible
+	if stat, err := fs.Stat(c, dir); err == nil && stat.IsDir() {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.upper[dir] = &File{ModTime: Now(), Mode: perm | fs.ModeDir}
+	return nil
+}
+
+func (c *COW) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.upper[name] = &File{Data: append([]byte{}, data...), ModTime: Now(), Mode: perm}
+	return nil
+}
+
+func (c *COW) RemoveAll(name string) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrInvalid}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for p := range c.upper {
+		if name == "." || p == name || strings.HasPrefix(p, name+"/") {
+			delete(c.upper, p)
+		}
+	}
+	c.removed[name] = true
+	return nil
+}
+
+// Changes returns the paths that were created, updated or deleted compared to
+// the base, sorted by path. Files that were rewritten with the same contents
+// aren't changes.
+func (c *COW) Changes() (changes []Change, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for p, file := range c.upper {
+		stat, err := fs.Stat(c.base, p)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+			changes = append(changes, Change{CreateChange, p})
+			continue
+		}
+		if file.Mode.IsDir() {
+			if !stat.IsDir() {
+				changes = append(changes, Change{UpdateChange, p})
+			}
+			continue
+		}
+		if stat.IsDir() {
+			changes = append(changes, Change{UpdateChange, p})
+			continue
+		}
+		data, err := fs.ReadFile(c.base, p)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(data, file.Data) {
+			changes = append(changes, Change{UpdateChange, p})
+		}
+	}
+	for p := range c.removed {
+		if _, ok := c.upper[p]; ok {
+			continue
+		}
+		// Only paths within the base were deleted
+		if _, err := fs.Stat(c.base, p); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		// Skip paths within directories that were deleted too
+		if p != "." && c.hidden(path.Dir(p)) {
+			continue
+		}
+		changes = append(changes, Change{DeleteChange, p})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+// cowDir is a directory with merged entries
+type cowDir struct {
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+var _ fs.ReadDirFile = (*cowDir)(nil)
+
+func (d *cowDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *cowDir) Read(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.Name(), Err: fs.ErrInvalid}
+}
+
+func (d *cowDir) Close() error {
+	return nil
+}
+
+func (d *cowDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	n := len(d.entries) - d.offset
+	if count > 0 && n > count {
+		n = count
+	}
+	if n == 0 && count > 0 {
+		return nil, io.EOF
+	}
+	list := make([]fs.DirEntry, n)
+	copy(list, d.entries[d.offset:d.offset+n])
+	d.offset += n
+	return list, nil
+}
@@ -0,0 +1,64 @@
+package vfs_test
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/livebud/bud/package/vfs"
+	"github.com/matryer/is"
+)
+
+func TestCopyOnWrite(t *testing.T) {
+	is := is.New(t)
+	base := fstest.MapFS{
+		"go.mod":                &fstest.MapFile{Data: []byte("module app.com")},
+		"view/index.svelte":     &fstest.MapFile{Data: []byte("<h1>index</h1>")},
+		"view/about.svelte":     &fstest.MapFile{Data: []byte("<h1>about</h1>")},
+		"bud/.cli/main.go":      &fstest.MapFile{Data: []byte("package main")},
+		"bud/.cli/program/a.go": &fstest.MapFile{Data: []byte("package program")},
+	}
+	fsys := vfs.CopyOnWrite(base)
+	// Writes are layered on top of the base
+	is.NoErr(fsys.WriteFile("view/index.svelte", []byte("<h1>new index</h1>"), 0644))
+	is.NoErr(fsys.WriteFile("view/about.svelte", []byte("<h1>about</h1>"), 0644))
+	is.NoErr(fsys.MkdirAll("controller", 0755))
+	is.NoErr(fsys.WriteFile("controller/controller.go", []byte("package controller"), 0644))
+	is.NoErr(fsys.RemoveAll("bud/.cli"))
+	code, err := fs.ReadFile(fsys, "view/index.svelte")
+	is.NoErr(err)
+	is.Equal(string(code), "<h1>new index</h1>")
+	code, err = fs.ReadFile(fsys, "go.mod")
+	is.NoErr(err)
+	is.Equal(string(code), "module app.com")
+	_, err = fs.ReadFile(fsys, "bud/.cli/main.go")
+	is.True(errors.Is(err, fs.ErrNotExist))
+	des, err := fs.ReadDir(fsys, ".")
+	is.NoErr(err)
+	is.Equal(len(des), 4)
+	is.Equal(des[0].Name(), "bud")
+	is.Equal(des[1].Name(), "controller")
+	is.Equal(des[2].Name(), "go.mod")
+	is.Equal(des[3].Name(), "view")
+	des, err = fs.ReadDir(fsys, "bud")
+	is.NoErr(err)
+	is.Equal(len(des), 0)
+	// Recreate a removed directory
+	is.NoErr(fsys.WriteFile("bud/.cli/main.go", []byte("package main\n"), 0644))
+	des, err = fs.ReadDir(fsys, "bud/.cli")
+	is.NoErr(err)
+	is.Equal(len(des), 1)
+	is.NoErr(fsys.RemoveAll("bud/.cli/main.go"))
+	// The base is untouched
+	code, err = fs.ReadFile(base, "view/index.svelte")
+	is.NoErr(err)
+	is.Equal(string(code), "<h1>index</h1>")
+	changes, err := fsys.Changes()
+	is.NoErr(err)
+	is.Equal(len(changes), 4)
+	is.Equal(changes[0].String(), "delete:bud/.cli")
+	is.Equal(changes[1].String(), "create:controller")
+	is.Equal(changes[2].String(), "create:controller/controller.go")
+	is.Equal(changes[3].String(), "update:view/index.svelte")
+}
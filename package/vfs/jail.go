@@ -0,0 +1,179 @@
+package vfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrEscape is returned when a path resolves outside of the jail
+var ErrEscape = errors.New("vfs: path escapes the jail")
+
+// Jail is an OS filesystem that refuses paths that escape the root directory,
+// either through ".." or through symlinks. Use it to make sure generated
+// files are only ever written within the project directory.
+type Jail string
+
+var _ ReadWritable = (Jail)("")
+
+func (dir Jail) Open(name string) (fs.File, error) {
+	path, err := dir.resolve("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (dir Jail) MkdirAll(name string, perm fs.FileMode) error {
+	path, err := dir.resolve("mkdir", name, true)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(path, perm)
+}
+
+func (dir Jail) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	path, err := dir.resolve("write", name, true)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, perm)
+}
+
+// RemoveAll removes symlinks rather than what they point to
+func (dir Jail) RemoveAll(name string) error {
+	path, err := dir.resolve("remove", name, false)
+	if err != nil {
+		return err
+	}
+	// Never remove the root itself
+	if name == "." {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrPermission}
+	}
+	return os.RemoveAll(path)
+}
+
+func (dir Jail) Readlink(name string) (string, error) {
+	path, err := dir.resolve("readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+	return os.Readlink(path)
+}
+
+// Symlink creates newname within the jail. Links that point outside of the
+// jail can be created, but they can't be followed.
+func (dir Jail) Symlink(oldname, newname string) error {
+	path, err := dir.resolve("symlink", newname, false)
+	if err != nil {
+		return err
+	}
+	return os.Symlink(oldname, path)
+}
+
+func (dir Jail) Chmod(name string, mode fs.FileMode) error {
+	path, err := dir.resolve("chmod", name, true)
+	if err != nil {
+		return err
+	}
+	return os.Chmod(path, mode)
+}
+
+func (dir Jail) Chtimes(name string, atime, mtime time.Time) error {
+	path, err := dir.resolve("chtimes", name, true)
+	if err != nil {
+		return err
+	}
+	return os.Chtimes(path, atime, mtime)
+}
+
+func (dir Jail) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	path, err := dir.resolve("create", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+}
+
+func (dir Jail) Rename(oldname, newname string) error {
+	oldpath, err := dir.resolve("rename", oldname, false)
+	if err != nil {
+		return err
+	}
+	newpath, err := dir.resolve("rename", newname, false)
+	if err != nil {
+		return err
+	}
+	return os.Rename(oldpath, newpath)
+}
+
+// resolve the name to a path on the OS, returning an error if the path is
+// outside of the jail after resolving symlinks. The last element is only
+// resolved when follow is true, so links themselves can be removed and
+// replaced.
+func (dir Jail) resolve(op, name string, follow bool) (string, error) {
+	if !fs.ValidPath(filepath.ToSlash(name)) {
+		return "", &fs.PathError{Op: op, Path: name, Err: ErrEscape}
+	}
+	root, err := filepath.Abs(string(dir))
+	if err != nil {
+		return "", &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	// The root may not exist yet
+	root, err = evalExisting(root)
+	if err != nil {
+		return "", &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	if name == "." {
+		return root, nil
+	}
+	parent, err := evalExisting(filepath.Join(root, filepath.Dir(name)))
+	if err != nil {
+		return "", &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	path := filepath.Join(parent, filepath.Base(name))
+	if follow {
+		if path, err = evalExisting(path); err != nil {
+			return "", &fs.PathError{Op: op, Path: name, Err: err}
+		}
+	}
+	if !within(root, path) {
+		return "", &fs.PathError{Op: op, Path: name, Err: ErrEscape}
+	}
+	return path, nil
+}
+
+// evalExisting resolves the symlinks in the part of the path that exists
+func evalExisting(path string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return resolved, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path, nil
+	}
+	resolved, err = evalExisting(parent)
+	if err != nil {
+		return "", err
+	}
+	// Dangling links resolve to where they would be created
+	if target, err := os.Readlink(path); err == nil {
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(resolved, target)
+		}
+		return evalExisting(target)
+	}
+	return filepath.Join(resolved, filepath.Base(path)), nil
+}
+
+// within returns true if path is root or inside of root
+func within(root, path string) bool {
+	return path == root || strings.HasPrefix(path, root+string(filepath.Separator))
+}
@@ -0,0 +1,66 @@
+package vfs_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/livebud/bud/package/vfs"
+	"github.com/matryer/is"
+)
+
+func TestJail(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	appDir := filepath.Join(dir, "app")
+	outsideDir := filepath.Join(dir, "outside")
+	is.NoErr(os.MkdirAll(filepath.Join(appDir, "view"), 0755))
+	is.NoErr(os.MkdirAll(outsideDir, 0755))
+	is.NoErr(os.WriteFile(filepath.Join(outsideDir, "secret.txt"), []byte("secret"), 0644))
+	// Links within the jail are followed
+	is.NoErr(os.Symlink("view", filepath.Join(appDir, "ui")))
+	// Links outside of the jail aren't
+	is.NoErr(os.Symlink(outsideDir, filepath.Join(appDir, "outside")))
+	is.NoErr(os.Symlink(filepath.Join(outsideDir, "new.txt"), filepath.Join(appDir, "dangling.txt")))
+	fsys := vfs.Jail(appDir)
+
+	err := fsys.WriteFile("ui/index.svelte", []byte("<h1>index</h1>"), 0644)
+	is.NoErr(err)
+	code, err := fs.ReadFile(fsys, "view/index.svelte")
+	is.NoErr(err)
+	is.Equal(string(code), "<h1>index</h1>")
+	err = fsys.MkdirAll("bud/.cli", 0755)
+	is.NoErr(err)
+	des, err := fs.ReadDir(fsys, ".")
+	is.NoErr(err)
+	is.Equal(len(des), 5)
+
+	// Paths that escape the jail
+	err = fsys.WriteFile("../outside/secret.txt", []byte("hacked"), 0644)
+	is.True(errors.Is(err, vfs.ErrEscape))
+	err = fsys.WriteFile("/tmp/secret.txt", []byte("hacked"), 0644)
+	is.True(errors.Is(err, vfs.ErrEscape))
+	err = fsys.WriteFile("outside/secret.txt", []byte("hacked"), 0644)
+	is.True(errors.Is(err, vfs.ErrEscape))
+	err = fsys.MkdirAll("outside/dir", 0755)
+	is.True(errors.Is(err, vfs.ErrEscape))
+	_, err = fs.ReadFile(fsys, "outside/secret.txt")
+	is.True(errors.Is(err, vfs.ErrEscape))
+	err = fsys.WriteFile("dangling.txt", []byte("hacked"), 0644)
+	is.True(errors.Is(err, vfs.ErrEscape))
+	err = fsys.RemoveAll("outside/secret.txt")
+	is.True(errors.Is(err, vfs.ErrEscape))
+	secret, err := os.ReadFile(filepath.Join(outsideDir, "secret.txt"))
+	is.NoErr(err)
+	is.Equal(string(secret), "secret")
+	_, err = os.Stat(filepath.Join(outsideDir, "new.txt"))
+	is.True(errors.Is(err, fs.ErrNotExist))
+
+	// Removing a link removes the link, not what it points to
+	err = fsys.RemoveAll("outside")
+	is.NoErr(err)
+	_, err = os.Stat(filepath.Join(outsideDir, "secret.txt"))
+	is.NoErr(err)
+}
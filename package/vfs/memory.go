@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 	"testing/fstest"
+	"time"
 )
 
 type Memory fstest.MapFS
@@ -31,6 +32,15 @@ func (m Memory) WriteFile(name string, data []byte, perm fs.FileMode) error {
 	return nil
 }
 
+func (m Memory) Chtimes(name string, atime, mtime time.Time) error {
+	file, ok := m[name]
+	if !ok {
+		return &fs.PathError{Op: "chtimes", Path: name, Err: fs.ErrNotExist}
+	}
+	file.ModTime = mtime
+	return nil
+}
+
 func (m Memory) RemoveAll(path string) error {
 	stat, err := fs.Stat(m, path)
 	if err != nil {
@@ -54,3 +64,28 @@ func (m Memory) RemoveAll(path string) error {
 	}
 	return nil
 }
+
+func (m Memory) Rename(oldname, newname string) error {
+	if _, err := fs.Stat(m, oldname); err != nil {
+		return err
+	}
+	// Move the path and everything within it
+	moves := map[string]string{}
+	dirpath := oldname + "/"
+	for fpath := range m {
+		if fpath == oldname {
+			moves[fpath] = newname
+		} else if strings.HasPrefix(fpath, dirpath) {
+			moves[fpath] = newname + "/" + strings.TrimPrefix(fpath, dirpath)
+		}
+	}
+	files := make(map[string]*fstest.MapFile, len(moves))
+	for from := range moves {
+		files[from] = m[from]
+		delete(m, from)
+	}
+	for from, to := range moves {
+		m[to] = files[from]
+	}
+	return nil
+}
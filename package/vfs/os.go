@@ -1,9 +1,11 @@
 package vfs
 
 import (
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // TODO: create an os_windows for opening on multiple drives
@@ -30,3 +32,27 @@ func (dir OS) WriteFile(name string, data []byte, perm fs.FileMode) error {
 func (dir OS) RemoveAll(path string) error {
 	return os.RemoveAll(filepath.Join(string(dir), path))
 }
+
+func (dir OS) Readlink(name string) (string, error) {
+	return os.Readlink(filepath.Join(string(dir), name))
+}
+
+func (dir OS) Symlink(oldname, newname string) error {
+	return os.Symlink(oldname, filepath.Join(string(dir), newname))
+}
+
+func (dir OS) Chmod(name string, mode fs.FileMode) error {
+	return os.Chmod(filepath.Join(string(dir), name), mode)
+}
+
+func (dir OS) Chtimes(name string, atime, mtime time.Time) error {
+	return os.Chtimes(filepath.Join(string(dir), name), atime, mtime)
+}
+
+func (dir OS) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	return os.OpenFile(filepath.Join(string(dir), name), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+}
+
+func (dir OS) Rename(oldname, newname string) error {
+	return os.Rename(filepath.Join(string(dir), oldname), filepath.Join(string(dir), newname))
+}
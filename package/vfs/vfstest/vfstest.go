@@ -0,0 +1,358 @@
+// Package vfstest checks that vfs.ReadWritable implementations behave the way
+// dsync and the overlay expect them to.
+package vfstest
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/livebud/bud/package/vfs"
+)
+
+// NewFS returns an empty filesystem to test
+type NewFS = func(t testing.TB) vfs.ReadWritable
+
+// Test runs the conformance suite against fresh filesystems from newFS
+func Test(t *testing.T, newFS NewFS) {
+	t.Helper()
+	t.Run("MkdirAll", func(t *testing.T) { testMkdirAll(t, newFS(t)) })
+	t.Run("WriteFile", func(t *testing.T) { testWriteFile(t, newFS(t)) })
+	t.Run("RemoveAll", func(t *testing.T) { testRemoveAll(t, newFS(t)) })
+	t.Run("InvalidPaths", func(t *testing.T) { testInvalidPaths(t, newFS(t)) })
+	t.Run("TestFS", func(t *testing.T) { testFS(t, newFS(t)) })
+	t.Run("Random", func(t *testing.T) { testRandom(t, newFS, time.Now().UnixNano(), 200) })
+}
+
+// TestConcurrent checks that concurrent writes to different paths don't
+// interfere with each other. Only run this against filesystems that are safe
+// for concurrent use.
+func TestConcurrent(t *testing.T, newFS NewFS) {
+	t.Helper()
+	fsys := newFS(t)
+	const writers = 8
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		go func(i int) {
+			dir := fmt.Sprintf("bud/%d", i)
+			if err := fsys.MkdirAll(dir, 0755); err != nil {
+				errs <- err
+				return
+			}
+			for j := 0; j < 10; j++ {
+				name := fmt.Sprintf("%s/%d.go", dir, j)
+				if err := fsys.WriteFile(name, []byte(name), 0644); err != nil {
+					errs <- err
+					return
+				}
+			}
+			errs <- nil
+		}(i)
+	}
+	for i := 0; i < writers; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < writers; i++ {
+		for j := 0; j < 10; j++ {
+			expectFile(t, fsys, fmt.Sprintf("bud/%d/%d.go", i, j), fmt.Sprintf("bud/%d/%d.go", i, j))
+		}
+	}
+}
+
+func testMkdirAll(t *testing.T, fsys vfs.ReadWritable) {
+	if err := fsys.MkdirAll("a/b/c", 0755); err != nil {
+		t.Fatalf("vfstest: unable to mkdir a/b/c. %s", err)
+	}
+	for _, dir := range []string{"a", "a/b", "a/b/c"} {
+		expectDir(t, fsys, dir)
+	}
+	// Creating an existing directory is a no-op
+	if err := fsys.MkdirAll("a/b", 0755); err != nil {
+		t.Fatalf("vfstest: unable to mkdir existing a/b. %s", err)
+	}
+	if err := fsys.MkdirAll(".", 0755); err != nil {
+		t.Fatalf("vfstest: unable to mkdir the root. %s", err)
+	}
+	expectDir(t, fsys, "a/b/c")
+}
+
+func testWriteFile(t *testing.T, fsys vfs.ReadWritable) {
+	if err := fsys.MkdirAll("view", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.WriteFile("view/index.svelte", []byte("<h1>index</h1>"), 0644); err != nil {
+		t.Fatalf("vfstest: unable to write view/index.svelte. %s", err)
+	}
+	expectFile(t, fsys, "view/index.svelte", "<h1>index</h1>")
+	// Overwrite with shorter contents
+	if err := fsys.WriteFile("view/index.svelte", []byte("<h1/>"), 0644); err != nil {
+		t.Fatalf("vfstest: unable to overwrite view/index.svelte. %s", err)
+	}
+	expectFile(t, fsys, "view/index.svelte", "<h1/>")
+	// Empty files
+	if err := fsys.WriteFile("view/empty.txt", nil, 0644); err != nil {
+		t.Fatalf("vfstest: unable to write view/empty.txt. %s", err)
+	}
+	expectFile(t, fsys, "view/empty.txt", "")
+	des, err := fs.ReadDir(fsys, "view")
+	if err != nil {
+		t.Fatalf("vfstest: unable to read dir view. %s", err)
+	}
+	if names := entryNames(des); strings.Join(names, ",") != "empty.txt,index.svelte" {
+		t.Fatalf("vfstest: expected view to contain empty.txt and index.svelte, got %v", names)
+	}
+}
+
+func testRemoveAll(t *testing.T, fsys vfs.ReadWritable) {
+	writeFiles(t, fsys, map[string]string{
+		"view/index.svelte":       "index",
+		"view/about/index.svelte": "about",
+		"view.go":                 "package view",
+	})
+	// Removing a missing path is a no-op
+	if err := fsys.RemoveAll("missing/path"); err != nil {
+		t.Fatalf("vfstest: expected removing a missing path to succeed. %s", err)
+	}
+	if err := fsys.RemoveAll("view"); err != nil {
+		t.Fatalf("vfstest: unable to remove view. %s", err)
+	}
+	for _, name := range []string{"view", "view/index.svelte", "view/about", "view/about/index.svelte"} {
+		expectMissing(t, fsys, name)
+	}
+	// Paths sharing a prefix aren't removed
+	expectFile(t, fsys, "view.go", "package view")
+	if err := fsys.RemoveAll("view.go"); err != nil {
+		t.Fatalf("vfstest: unable to remove view.go. %s", err)
+	}
+	expectMissing(t, fsys, "view.go")
+}
+
+func testInvalidPaths(t *testing.T, fsys vfs.ReadWritable) {
+	writeFiles(t, fsys, map[string]string{"a/b.txt": "b"})
+	for _, name := range []string{"/a/b.txt", "a/./b.txt", "a//b.txt", "a/../a/b.txt", "a/b.txt/", ""} {
+		file, err := fsys.Open(name)
+		if err == nil {
+			file.Close()
+			t.Fatalf("vfstest: expected opening the invalid path %q to fail", name)
+		}
+	}
+}
+
+// testFS runs the standard library's fs.FS checks over written files
+func testFS(t *testing.T, fsys vfs.ReadWritable) {
+	writeFiles(t, fsys, map[string]string{
+		"go.mod":                  "module app.com",
+		"view/index.svelte":       "index",
+		"view/about/index.svelte": "about",
+	})
+	if err := fstest.TestFS(fsys, "go.mod", "view/index.svelte", "view/about/index.svelte"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testRandom applies random operations to the filesystem and to a model of
+// the filesystem, checking that they agree after each operation
+func testRandom(t *testing.T, newFS NewFS, seed int64, steps int) {
+	fsys := newFS(t)
+	rnd := rand.New(rand.NewSource(seed))
+	m := newModel()
+	dirs := []string{"a", "a/b", "a/b/c", "d", "d/e"}
+	bases := []string{"x.txt", "y.txt", "z"}
+	for i := 0; i < steps; i++ {
+		dir := dirs[rnd.Intn(len(dirs))]
+		var op string
+		switch rnd.Intn(3) {
+		case 0:
+			op = "mkdir " + dir
+			if err := fsys.MkdirAll(dir, 0755); err != nil {
+				t.Fatalf("vfstest: seed %d: %s failed. %s", seed, op, err)
+			}
+			m.mkdirAll(dir)
+		case 1:
+			name := path.Join(dir, bases[rnd.Intn(len(bases))])
+			data := fmt.Sprintf("%d", rnd.Int())
+			op = "write " + name
+			if err := fsys.MkdirAll(dir, 0755); err != nil {
+				t.Fatalf("vfstest: seed %d: %s failed. %s", seed, op, err)
+			}
+			if err := fsys.WriteFile(name, []byte(data), 0644); err != nil {
+				t.Fatalf("vfstest: seed %d: %s failed. %s", seed, op, err)
+			}
+			m.mkdirAll(dir)
+			m.files[name] = data
+		case 2:
+			name := dir
+			if rnd.Intn(2) == 0 {
+				name = path.Join(dir, bases[rnd.Intn(len(bases))])
+			}
+			op = "remove " + name
+			if err := fsys.RemoveAll(name); err != nil {
+				t.Fatalf("vfstest: seed %d: %s failed. %s", seed, op, err)
+			}
+			m.removeAll(name)
+		}
+		if err := m.check(fsys); err != nil {
+			t.Fatalf("vfstest: seed %d: after step %d (%s): %s", seed, i, op, err)
+		}
+	}
+}
+
+// model of the expected files and directories. Parent directories that were
+// created implicitly may be dropped once they're empty, like vfs.Memory does,
+// so they're allowed but not required.
+type model struct {
+	files   map[string]string
+	dirs    map[string]bool // directories that must exist
+	parents map[string]bool // directories that may exist
+}
+
+func newModel() *model {
+	return &model{map[string]string{}, map[string]bool{}, map[string]bool{}}
+}
+
+// mkdirAll requires the directory, unless it already existed implicitly
+func (m *model) mkdirAll(dir string) {
+	if m.exists(dir) {
+		m.parents[dir] = true
+	} else {
+		m.dirs[dir] = true
+	}
+	for dir = path.Dir(dir); dir != "."; dir = path.Dir(dir) {
+		m.parents[dir] = true
+	}
+}
+
+// exists returns true if the directory is in the model
+func (m *model) exists(dir string) bool {
+	if m.dirs[dir] || m.parents[dir] {
+		return true
+	}
+	for file := range m.files {
+		if strings.HasPrefix(file, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *model) removeAll(name string) {
+	delete(m.files, name)
+	delete(m.dirs, name)
+	for file := range m.files {
+		if strings.HasPrefix(file, name+"/") {
+			delete(m.files, file)
+		}
+	}
+	delete(m.parents, name)
+	for dir := range m.dirs {
+		if strings.HasPrefix(dir, name+"/") {
+			delete(m.dirs, dir)
+		}
+	}
+	for dir := range m.parents {
+		if strings.HasPrefix(dir, name+"/") {
+			delete(m.parents, dir)
+		}
+	}
+}
+
+// check the filesystem against the model
+func (m *model) check(fsys fs.FS) error {
+	files := map[string]string{}
+	err := fs.WalkDir(fsys, ".", func(name string, de fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if de.IsDir() {
+			if name != "." && !m.dirs[name] && !m.parents[name] {
+				return fmt.Errorf("unexpected directory %q", name)
+			}
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		files[name] = string(data)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for name, data := range m.files {
+		if files[name] != data {
+			return fmt.Errorf("expected %q to contain %q, got %q", name, data, files[name])
+		}
+		delete(files, name)
+	}
+	for name := range files {
+		return fmt.Errorf("unexpected file %q", name)
+	}
+	for dir := range m.dirs {
+		stat, err := fs.Stat(fsys, dir)
+		if err != nil {
+			return fmt.Errorf("expected directory %q. %w", dir, err)
+		} else if !stat.IsDir() {
+			return fmt.Errorf("expected %q to be a directory", dir)
+		}
+	}
+	return nil
+}
+
+func writeFiles(t testing.TB, fsys vfs.ReadWritable, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		if err := fsys.MkdirAll(path.Dir(name), 0755); err != nil {
+			t.Fatalf("vfstest: unable to mkdir %q. %s", path.Dir(name), err)
+		}
+		if err := fsys.WriteFile(name, []byte(data), 0644); err != nil {
+			t.Fatalf("vfstest: unable to write %q. %s", name, err)
+		}
+	}
+}
+
+func expectFile(t testing.TB, fsys fs.FS, name, expect string) {
+	t.Helper()
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		t.Fatalf("vfstest: unable to read %q. %s", name, err)
+	}
+	if string(data) != expect {
+		t.Fatalf("vfstest: expected %q to contain %q, got %q", name, expect, data)
+	}
+}
+
+func expectDir(t testing.TB, fsys fs.FS, name string) {
+	t.Helper()
+	stat, err := fs.Stat(fsys, name)
+	if err != nil {
+		t.Fatalf("vfstest: unable to stat %q. %s", name, err)
+	}
+	if !stat.IsDir() {
+		t.Fatalf("vfstest: expected %q to be a directory", name)
+	}
+}
+
+func expectMissing(t testing.TB, fsys fs.FS, name string) {
+	t.Helper()
+	if _, err := fs.Stat(fsys, name); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("vfstest: expected %q to not exist, got %v", name, err)
+	}
+}
+
+func entryNames(des []fs.DirEntry) (names []string) {
+	for _, de := range des {
+		names = append(names, de.Name())
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,39 @@
+package vfstest_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/livebud/bud/package/vfs"
+	"github.com/livebud/bud/package/vfs/vfstest"
+)
+
+func TestMemory(t *testing.T) {
+	vfstest.Test(t, func(t testing.TB) vfs.ReadWritable {
+		return vfs.Memory{}
+	})
+}
+
+func TestOS(t *testing.T) {
+	newFS := func(t testing.TB) vfs.ReadWritable {
+		return vfs.OS(t.TempDir())
+	}
+	vfstest.Test(t, newFS)
+	vfstest.TestConcurrent(t, newFS)
+}
+
+func TestJail(t *testing.T) {
+	newFS := func(t testing.TB) vfs.ReadWritable {
+		return vfs.Jail(t.TempDir())
+	}
+	vfstest.Test(t, newFS)
+	vfstest.TestConcurrent(t, newFS)
+}
+
+func TestCopyOnWrite(t *testing.T) {
+	newFS := func(t testing.TB) vfs.ReadWritable {
+		return vfs.CopyOnWrite(fstest.MapFS{})
+	}
+	vfstest.Test(t, newFS)
+	vfstest.TestConcurrent(t, newFS)
+}
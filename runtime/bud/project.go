@@ -32,6 +32,12 @@ type Project struct {
 	Stderr io.Writer
 }
 
+// Invalidate the cached module lookups and reload go.mod and go.work, so the
+// next compile picks up changes to them
+func (c *Project) Invalidate() error {
+	return c.module.Reload()
+}
+
 func (c *Project) Compile(ctx context.Context, flag *Flag) (*App, error) {
 	// Sync the app
 	if err := c.fsys.Sync("bud/.app"); err != nil {
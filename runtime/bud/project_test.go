@@ -0,0 +1,57 @@
+package bud_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/livebud/bud/package/gomod"
+	"github.com/livebud/bud/package/overlay"
+	"github.com/livebud/bud/package/vfs"
+	"github.com/livebud/bud/runtime/bud"
+	"github.com/matryer/is"
+)
+
+func TestInvalidateModule(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	t.Setenv("GOWORK", "off")
+	appDir := t.TempDir()
+	err := vfs.Write(appDir, vfs.Map{
+		"go.mod": []byte("module app.com\n\ngo 1.17\n"),
+	})
+	is.NoErr(err)
+	module, err := gomod.Find(appDir)
+	is.NoErr(err)
+	fsys, err := overlay.Load(module)
+	is.NoErr(err)
+	// The generated app depends on the replace directives in go.mod
+	fsys.GenerateFile("bud/.app/main.go", func(ctx context.Context, fsys overlay.F, file *overlay.File) error {
+		file.Data = []byte(fmt.Sprintf("package main\n\n// replaces: %d\nfunc main() {}\n", len(module.File().Replaces())))
+		return nil
+	})
+	project := bud.New(fsys, module)
+	_, err = project.Compile(ctx, &bud.Flag{})
+	is.NoErr(err)
+	code, err := os.ReadFile(filepath.Join(appDir, "bud", ".app", "main.go"))
+	is.NoErr(err)
+	is.Equal(string(code), "package main\n\n// replaces: 0\nfunc main() {}\n")
+	// Change go.mod between compiles
+	err = vfs.Write(appDir, vfs.Map{
+		"go.mod":     []byte("module app.com\n\ngo 1.17\n\nreplace mod.test/lib => ./lib\n"),
+		"lib/go.mod": []byte("module mod.test/lib\n"),
+		"lib/lib.go": []byte("package lib\n"),
+	})
+	is.NoErr(err)
+	is.NoErr(project.Invalidate())
+	_, err = project.Compile(ctx, &bud.Flag{})
+	is.NoErr(err)
+	code, err = os.ReadFile(filepath.Join(appDir, "bud", ".app", "main.go"))
+	is.NoErr(err)
+	is.Equal(string(code), "package main\n\n// replaces: 1\nfunc main() {}\n")
+	dir, err := module.ResolveDirectory("mod.test/lib")
+	is.NoErr(err)
+	is.Equal(dir, filepath.Join(appDir, "lib"))
+}
@@ -106,7 +106,7 @@ func (c *Command) Load() (state *State, err error) {
 
 func (c *Command) controller() *Controller {
 	controller := new(Controller)
-	imports := imports.New()
+	imports := imports.New(imports.WithLocal(c.module.Import()))
 	imports.AddStd("context")
 	controller.Imports = imports.List()
 	key, resource := splitKeyAndResource(c.Path)
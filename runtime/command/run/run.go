@@ -75,6 +75,14 @@ func (c *Command) startApp(ctx context.Context, hotServer *hot.Server) error {
 	// Start watching
 	if err := watcher.Watch(ctx, ".", func(path string) error {
 		switch filepath.Ext(path) {
+		// Dependencies may have changed, so reload the module before
+		// re-compiling
+		case ".mod", ".work":
+			if err := c.Project.Invalidate(); err != nil {
+				console.Error(err.Error())
+				return nil
+			}
+			fallthrough
 		// Re-compile the app and restart the Go server
 		case ".go":
 			// Trigger a reload if there's a hot reload server configured
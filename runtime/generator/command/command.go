@@ -4,6 +4,7 @@ import (
 	"context"
 	_ "embed"
 
+	"github.com/livebud/bud/internal/bail"
 	"github.com/livebud/bud/internal/gotemplate"
 	"github.com/livebud/bud/package/gomod"
 	"github.com/livebud/bud/package/overlay"
@@ -13,7 +14,7 @@ import (
 //go:embed command.gotext
 var template string
 
-var generator = gotemplate.MustParse("command.gotext", template)
+var generator = gotemplate.MustParse("command.gotext", template, gotemplate.WithFormat())
 
 // TODO: rename to Command
 type Generator struct {
@@ -25,7 +26,7 @@ func (g *Generator) GenerateFile(ctx context.Context, fsys overlay.F, file *over
 	// Load command state
 	state, err := Load(fsys, g.Module, g.Parser)
 	if err != nil {
-		return err
+		return bail.Generating(err, "command", file.Path())
 	}
 	// Generate our template
 	code, err := generator.Generate(state)
@@ -2,10 +2,14 @@ package command_test
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/livebud/bud/internal/budtest"
+	"github.com/livebud/bud/internal/testgen"
+	"github.com/livebud/bud/runtime/generator/command"
 
 	"github.com/lithammer/dedent"
 	"github.com/matryer/is"
@@ -188,9 +192,274 @@ func TestNested(t *testing.T) {
 		Usage:
 		  view [flags] <name>
 
+		Args:
+		  name  name of the view
+
 		Flags:
 		  --with-test  include a view test
 	`)
 }
 
-// TODO: test deeply nested
+func TestDeeplyNested(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	bud := budtest.New(dir)
+	bud.Files["command/migrate/new/new.go"] = `
+		package new
+
+		import (
+			"context"
+			"fmt"
+		)
+
+		// Create a new migration
+		type Command struct {
+			Name string ` + "`" + `arg:"name" help:"name of the migration"` + "`" + `
+		}
+
+		func (c *Command) Run(ctx context.Context) error {
+			fmt.Println("creating migration", c.Name)
+			return nil
+		}
+	`
+	bud.Files["command/migrate/db/reset/reset.go"] = `
+		package reset
+
+		import (
+			"context"
+			"fmt"
+		)
+
+		// Reset the database
+		type Command struct {
+			Force bool ` + "`" + `flag:"force" short:"f" help:"skip the prompt"` + "`" + `
+		}
+
+		func (c *Command) Run(ctx context.Context) error {
+			fmt.Println("resetting", c.Force)
+			return nil
+		}
+	`
+	project, err := bud.Compile(ctx)
+	is.NoErr(err)
+	app, err := project.Build(ctx)
+	is.NoErr(err)
+	stdout, stderr, err := app.Execute(ctx, "migrate", "-h")
+	is.NoErr(err)
+	is.NoErr(stderr.Expect(""))
+	isEqual(t, stdout.String(), `
+		Usage:
+		  migrate [command]
+
+		Commands:
+		  db
+		  new  Create a new migration
+	`)
+	stdout, stderr, err = app.Execute(ctx, "migrate", "db", "-h")
+	is.NoErr(err)
+	is.NoErr(stderr.Expect(""))
+	isEqual(t, stdout.String(), `
+		Usage:
+		  db [command]
+
+		Commands:
+		  reset  Reset the database
+	`)
+	stdout, stderr, err = app.Execute(ctx, "migrate", "db", "reset", "-f")
+	is.NoErr(err)
+	is.NoErr(stderr.Expect(""))
+	is.NoErr(stdout.Expect("resetting true"))
+}
+
+func TestDocHelp(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	bud := budtest.New(dir)
+	bud.Files["command/migrate/migrate.go"] = `
+		package migrate
+
+		import (
+			"context"
+		)
+
+		// Migrate the database.
+		//
+		// Migrations are read from the migrate/ directory
+		// and run in order.
+		type Command struct {
+			Dir string ` + "`" + `flag:"dir" help:"migrations directory" default:"migrate"` + "`" + `
+		}
+
+		// Run the migrations
+		//
+		// Examples:
+		//
+		//	# run the migrations
+		//	$ app migrate
+		//
+		//	$ app migrate --dir=db
+		func (c *Command) Run(ctx context.Context) error {
+			return nil
+		}
+	`
+	project, err := bud.Compile(ctx)
+	is.NoErr(err)
+	app, err := project.Build(ctx)
+	is.NoErr(err)
+	stdout, stderr, err := app.Execute(ctx, "-h")
+	is.NoErr(err)
+	is.NoErr(stderr.Expect(""))
+	isEqual(t, stdout.String(), `
+		Usage:
+		  app [command]
+
+		Commands:
+		  migrate  Migrate the database.
+	`)
+	stdout, stderr, err = app.Execute(ctx, "migrate", "-h")
+	is.NoErr(err)
+	is.NoErr(stderr.Expect(""))
+	isEqual(t, stdout.String(), `
+		Usage:
+		  migrate [flags]
+
+		Description:
+		  Migrations are read from the migrate/ directory and run in order.
+
+		Flags:
+		  --dir  migrations directory
+
+		Examples:
+		  # run the migrations
+		  $ app migrate
+
+		  $ app migrate --dir=db
+	`)
+}
+
+func TestConstructor(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	bud := budtest.New(dir)
+	bud.Files["command/routes/routes.go"] = `
+		package routes
+
+		import (
+			"context"
+			"fmt"
+
+			router "github.com/livebud/bud/package/router"
+		)
+
+		func New(router *router.Router) (*Command, error) {
+			return &Command{router, "routes"}, nil
+		}
+
+		type Command struct {
+			router *router.Router
+			name   string
+			Prefix string ` + "`" + `flag:"prefix" default:"/"` + "`" + `
+		}
+
+		func (c *Command) Run(ctx context.Context) error {
+			fmt.Println(c.name, c.router != nil, c.Prefix)
+			return nil
+		}
+	`
+	project, err := bud.Compile(ctx)
+	is.NoErr(err)
+	app, err := project.Build(ctx)
+	is.NoErr(err)
+	stdout, stderr, err := app.Execute(ctx, "routes", "--prefix=/api")
+	is.NoErr(err)
+	is.NoErr(stderr.Expect(""))
+	is.NoErr(stdout.Expect("routes true /api"))
+}
+
+func TestTags(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	bud := budtest.New(dir)
+	bud.Env["DEPLOY_REGION"] = "eu-west-1"
+	bud.Files["command/deploy/deploy.go"] = `
+		package deploy
+
+		import (
+			"context"
+			"fmt"
+		)
+
+		type Command struct {
+			Rate   float64  ` + "`" + `flag:"rate" default:"0.5"` + "`" + `
+			Tags   []string ` + "`" + `flag:"tags" default:"a,b"` + "`" + `
+			Region string   ` + "`" + `flag:"region" env:"DEPLOY_REGION" default:"us-east-1"` + "`" + `
+			Target string   ` + "`" + `arg:"target"` + "`" + `
+			Files  []string ` + "`" + `args:"files"` + "`" + `
+		}
+
+		func (c *Command) Run(ctx context.Context) error {
+			fmt.Println(c.Rate, c.Tags, c.Region, c.Target, c.Files)
+			return nil
+		}
+	`
+	project, err := bud.Compile(ctx)
+	is.NoErr(err)
+	app, err := project.Build(ctx)
+	is.NoErr(err)
+	// Defaults, the environment and the rest of the args
+	stdout, stderr, err := app.Execute(ctx, "deploy", "prod", "a.go", "b.go")
+	is.NoErr(err)
+	is.NoErr(stderr.Expect(""))
+	is.NoErr(stdout.Expect("0.5 [a b] eu-west-1 prod [a.go b.go]"))
+	// Flags override the defaults and the environment
+	stdout, stderr, err = app.Execute(ctx, "deploy", "--rate=2.5", "--tags=c", "--region=us-west-2", "prod")
+	is.NoErr(err)
+	is.NoErr(stderr.Expect(""))
+	is.NoErr(stdout.Expect("2.5 [c] us-west-2 prod []"))
+	// Args without a default are required
+	_, _, err = app.Execute(ctx, "deploy")
+	is.True(err != nil)
+}
+
+func TestInvalidTags(t *testing.T) {
+	tests := []struct {
+		field  string
+		expect string
+	}{
+		{"Rate float64 `flag:\"rate\" default:\"fast\"`", `command: invalid float64 default "fast"`},
+		{"Count int `flag:\"count\" default:\"1.5\"`", `command: invalid int default "1.5"`},
+		{"Force bool `flag:\"force\" default:\"yes\"`", `command: invalid bool default "yes"`},
+		{"Weight float64 `arg:\"weight\" default:\"heavy\"`", `command: invalid float64 default "heavy"`},
+		{"Region string `help:\"region to deploy to\"`", `command: field "Region" in "deploy" must have a flag, arg or args tag`},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.field, func(t *testing.T) {
+			is := is.New(t)
+			fixture := filepath.Join(t.TempDir(), "fixture.txt")
+			err := os.WriteFile(fixture, []byte(redent(`
+				-- command/deploy/deploy.go --
+				package deploy
+
+				import "context"
+
+				type Command struct {
+					`+test.field+`
+				}
+
+				func (c *Command) Run(ctx context.Context) error {
+					return nil
+				}
+			`)), 0644)
+			is.NoErr(err)
+			project := testgen.Load(t, fixture)
+			_, err = command.Load(project.FS, project.Module, project.Parser)
+			is.True(err != nil)
+			is.True(strings.Contains(err.Error(), test.expect))
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package command
+
+import (
+	"strings"
+)
+
+// doc is a parsed doc comment
+type doc struct {
+	Summary     string // First paragraph on a single line
+	Description string // Paragraphs before the examples
+	Examples    []*Example
+}
+
+// parseDoc splits a doc comment into its summary, description and examples.
+// The identifier a doc comment conventionally starts with, like "Command" in
+// "Command migrates the database", is left out of the summary. Examples
+// follow an "Examples:" line. Each example is a "$ " command, optionally
+// preceded by a "# " comment describing it:
+//
+//	// Examples:
+//	//
+//	//	# create a users migration
+//	//	$ app migrate new create_users
+func parseDoc(text, ident string) *doc {
+	doc := new(doc)
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		if heading := strings.TrimSpace(line); heading == "Example:" || heading == "Examples:" {
+			doc.Examples = parseExamples(lines[i+1:])
+			lines = lines[:i]
+			break
+		}
+	}
+	paragraphs := parseParagraphs(lines)
+	if len(paragraphs) == 0 {
+		return doc
+	}
+	words := strings.Fields(paragraphs[0])
+	if len(words) > 1 && words[0] == ident {
+		words = words[1:]
+	}
+	doc.Summary = strings.Join(words, " ")
+	// The summary is already shown as the help text
+	doc.Description = strings.Join(paragraphs[1:], "\n\n")
+	return doc
+}
+
+// parseParagraphs joins the wrapped lines of each paragraph. Indented lines
+// are preformatted, so they're kept as-is.
+func parseParagraphs(lines []string) (paragraphs []string) {
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			paragraphs = append(paragraphs, strings.Join(paragraph, "\n"))
+		}
+		paragraph = nil
+	}
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		switch {
+		case line == "":
+			flush()
+		case isIndented(line):
+			paragraph = append(paragraph, line)
+		case len(paragraph) > 0 && !isIndented(paragraph[len(paragraph)-1]):
+			paragraph[len(paragraph)-1] += " " + line
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	flush()
+	return paragraphs
+}
+
+func isIndented(line string) bool {
+	return line != "" && (line[0] == ' ' || line[0] == '\t')
+}
+
+// parseExamples parses the "# usage" and "$ command" lines of the examples
+func parseExamples(lines []string) (examples []*Example) {
+	usage := ""
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "#"):
+			usage = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		case strings.HasPrefix(line, "$"):
+			examples = append(examples, &Example{
+				Command: strings.TrimSpace(strings.TrimPrefix(line, "$")),
+				Usage:   usage,
+			})
+			usage = ""
+		}
+	}
+	return examples
+}
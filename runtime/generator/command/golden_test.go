@@ -0,0 +1,28 @@
+package command_test
+
+import (
+	"testing"
+
+	"github.com/livebud/bud/internal/testgen"
+	"github.com/livebud/bud/runtime/generator/command"
+)
+
+func TestGolden(t *testing.T) {
+	project := testgen.Load(t, "testdata/nested.txt")
+	project.FS.FileGenerator("bud/.app/command/command.go", &command.Generator{
+		Module: project.Module,
+		Parser: project.Parser,
+	})
+	project.Golden("bud/.app/command")
+	project.Vet("bud/.app/command")
+}
+
+func TestGoldenTags(t *testing.T) {
+	project := testgen.Load(t, "testdata/tags.txt")
+	project.FS.FileGenerator("bud/.app/command/command.go", &command.Generator{
+		Module: project.Module,
+		Parser: project.Parser,
+	})
+	project.Golden("bud/.app/command")
+	project.Vet("bud/.app/command")
+}
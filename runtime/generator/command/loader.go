@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
-	"path/filepath"
+	"path"
+	"strconv"
+	"strings"
 
 	"github.com/matthewmueller/text"
 
@@ -19,7 +21,7 @@ import (
 func Load(fsys fs.FS, module *gomod.Module, parser *parser.Parser) (*State, error) {
 	loader := &loader{
 		fsys:    fsys,
-		imports: imports.New(),
+		imports: imports.New(imports.WithLocal(module.Import())),
 		parser:  parser,
 		module:  module,
 	}
@@ -75,7 +77,7 @@ func (l *loader) loadRoot(base string) *Command {
 		if !de.IsDir() || !valid.Dir(de.Name()) {
 			continue
 		}
-		sub := l.loadSub(base, de.Name())
+		sub := l.loadSub(nil, path.Join(base, de.Name()))
 		if sub == nil {
 			continue
 		}
@@ -84,19 +86,47 @@ func (l *loader) loadRoot(base string) *Command {
 	return command
 }
 
-// Load the subcommand
-func (l *loader) loadSub(base, dir string) *Command {
-	commandDir := filepath.Join(base, dir)
+// Load the subcommand and its subcommands. Directories without a Command
+// struct group their subcommands, e.g. command/migrate/new.
+func (l *loader) loadSub(parents []string, commandDir string) *Command {
+	des, err := fs.ReadDir(l.fsys, commandDir)
+	if err != nil {
+		l.Bail(err)
+	}
 	command := new(Command)
+	command.Parents = parents
 	// Get the command name
-	command.Name = filepath.Base(dir)
-	// Load the import
-	importPath := l.module.Import(filepath.SplitList(commandDir)...)
-	importName := l.imports.Add(importPath)
-	command.Import = &imports.Import{
-		Name: importName,
-		Path: importPath,
+	command.Name = path.Base(commandDir)
+	// Load the subcommands
+	shouldParse := false
+	for _, de := range des {
+		if !de.IsDir() {
+			shouldParse = shouldParse || valid.CommandFile(de.Name())
+			continue
+		}
+		if !valid.Dir(de.Name()) {
+			continue
+		}
+		// Copy the parents, so siblings don't share a backing array
+		subParents := append(append([]string{}, parents...), command.Name)
+		sub := l.loadSub(subParents, path.Join(commandDir, de.Name()))
+		if sub == nil {
+			continue
+		}
+		command.Subs = append(command.Subs, sub)
 	}
+	if shouldParse {
+		l.loadStruct(command, commandDir)
+	}
+	// Ignore directories without any commands
+	if command.Import == nil && len(command.Subs) == 0 {
+		return nil
+	}
+	return command
+}
+
+// Load the command from the Command struct, if there is one
+func (l *loader) loadStruct(command *Command, commandDir string) {
 	// Parse the command directory
 	pkg, err := l.parser.Parse(commandDir)
 	if err != nil {
@@ -105,19 +135,42 @@ func (l *loader) loadSub(base, dir string) *Command {
 	// Find the Command struct, ignore if it doesn't exist
 	stct := pkg.Struct("Command")
 	if stct == nil {
-		return nil
+		return
 	}
+	// Load the import
+	importPath := l.module.Import(commandDir)
+	importName := l.imports.Add(importPath)
+	command.Import = &imports.Import{
+		Name: importName,
+		Path: importPath,
+	}
+	// Use the doc comment on the struct as the help text and description
+	doc := parseDoc(stct.Doc(), "Command")
+	command.Help = doc.Summary
+	command.Description = doc.Description
+	command.Examples = doc.Examples
+	// Load the constructor, if there is one
+	command.Constructor = l.loadConstructor(pkg)
 	// Check that the command is runnable
 	command.Runnable = isRunnable(stct)
+	// Add the examples from the doc comment on the Run method
+	if command.Runnable {
+		run := parseDoc(stct.Method("Run").Doc(), "Run")
+		command.Examples = append(command.Examples, run.Examples...)
+	}
 	// Gather the fields
 	for _, field := range stct.PublicFields() {
 		tags, err := field.Tags()
 		if err != nil {
 			l.Bail(err)
 		}
+		// Fields without tags are set by the constructor
+		if len(tags) == 0 && command.Constructor != nil {
+			continue
+		}
 		// Is a dependency
 		if len(tags) == 0 && !parser.IsBuiltin(field.Type()) {
-			dep := l.loadCommandDep(field)
+			dep := l.loadCommandDep(field.Name(), field.Type())
 			command.Deps = append(command.Deps, dep)
 			continue
 		}
@@ -133,31 +186,54 @@ func (l *loader) loadSub(base, dir string) *Command {
 			command.Args = append(command.Args, arg)
 			continue
 		}
+		// Is the rest of the args
+		if tags.Has("args") {
+			arg := l.loadCommandArgs(field, tags)
+			command.Args = append(command.Args, arg)
+			continue
+		}
+		// Tags like help describe a flag or an arg, so they can't be used alone
+		if len(tags) > 0 {
+			l.Bail(fmt.Errorf("command: field %q in %q must have a flag, arg or args tag", field.Name(), command.Name))
+		}
 		l.Bail(fmt.Errorf("command: %q has an unacceptable type %q", command.Name, field.Type()))
 	}
+}
 
-	// Read the subdirectories
-	des, err := fs.ReadDir(l.fsys, commandDir)
-	if err != nil {
-		l.Bail(err)
-	}
-	// Load the subcommands
-	for _, de := range des {
-		if !de.IsDir() || !valid.Dir(de.Name()) {
+// Load the constructor that returns the command, if any. Constructors declare
+// their dependencies as parameters, which are then injected, e.g.
+// func New(log *log.Logger, db *sql.DB) *Command
+func (l *loader) loadConstructor(pkg *parser.Package) *Constructor {
+	for _, fn := range pkg.PublicFunctions() {
+		if fn.Receiver() != nil {
 			continue
 		}
-		sub := l.loadSub(base, filepath.Join(dir, de.Name()))
-		if sub == nil {
+		results := fn.Results()
+		if len(results) == 0 || results[0].Type().String() != "*Command" {
 			continue
 		}
-		sub.Parents = append(sub.Parents, command.Name)
-		command.Subs = append(command.Subs, sub)
+		constructor := new(Constructor)
+		constructor.Name = fn.Name()
+		switch {
+		case len(results) == 2 && results[1].IsError():
+			constructor.Error = true
+		case len(results) != 1:
+			l.Bail(fmt.Errorf("command: constructor %q must return *Command or (*Command, error)", fn.Name()))
+		}
+		for _, param := range fn.Params() {
+			if parser.IsBuiltin(param.Type()) {
+				l.Bail(fmt.Errorf("command: constructor %q has a parameter %q that can't be injected", fn.Name(), param.Type()))
+			}
+			dep := l.loadCommandDep(param.Name(), param.Type())
+			constructor.Params = append(constructor.Params, dep)
+		}
+		return constructor
 	}
-	return command
+	return nil
 }
 
-func (l *loader) loadCommandDep(field *parser.Field) *Dep {
-	def, err := parser.Definition(field.Type())
+func (l *loader) loadCommandDep(name string, dataType parser.Type) *Dep {
+	def, err := parser.Definition(dataType)
 	if err != nil {
 		l.Bail(fmt.Errorf("command: %w", err))
 	}
@@ -165,22 +241,25 @@ func (l *loader) loadCommandDep(field *parser.Field) *Dep {
 	if err != nil {
 		l.Bail(fmt.Errorf("command: %w", err))
 	}
+	// Standard library packages are prefixed with std/
+	importPath = strings.TrimPrefix(importPath, "std/")
 	importName := l.imports.Add(importPath)
 	// Change original import name to new import name if needed
-	fieldType := parser.Requalify(field.Type(), importName)
+	fieldType := parser.Requalify(dataType, importName)
 	return &Dep{
 		Import: &imports.Import{
 			Path: importPath,
 			Name: importName,
 		},
 		Type: fieldType.String(),
-		Name: field.Name(),
+		Name: name,
 	}
 }
 
 // Load the command flag
 func (l *loader) loadCommandFlag(field *parser.Field, tags parser.Tags) *Flag {
 	flag := new(Flag)
+	flag.Field = field.Name()
 	// Set the flag name
 	flag.Name = tags.Get("flag")
 	// Use the field name (flags are the default)
@@ -196,38 +275,109 @@ func (l *loader) loadCommandFlag(field *parser.Field, tags parser.Tags) *Flag {
 		flag.Short = short[0]
 	}
 	// Set the flag type
-	// TODO: add more types
 	switch t := field.Type().String(); t {
-	case "string", "bool", "int":
+	case "string", "bool", "int", "float64", "[]string":
 		flag.Type = t
 	default:
-		l.Bail(fmt.Errorf("command: flag must be a string, bool or int, not %q", t))
+		l.Bail(fmt.Errorf("command: flag must be a string, bool, int, float64 or []string, not %q", t))
 	}
 	// Set the usage
 	flag.Help = tags.Get("help")
-	// Set the default
-	flag.Default = tags.Get("default")
+	// Set the environment variable
+	flag.Env = tags.Get("env")
+	// Set the default. Flags without a default are required, except for
+	// boolean flags, which are off unless they're passed in.
+	if tags.Has("default") {
+		flag.Default = l.loadDefault(flag.Type, tagValue(tags, "default"))
+	} else if flag.Type == "bool" {
+		flag.Default = "false"
+	}
 	return flag
 }
 
 // Load the command arg
 func (l *loader) loadCommandArg(field *parser.Field, tags parser.Tags) *Arg {
 	arg := new(Arg)
+	arg.Field = field.Name()
 	// Set the arg name
 	arg.Name = tags.Get("arg")
 	// Set the arg type
 	switch t := field.Type().String(); t {
-	case "string", "bool", "int":
+	case "string", "bool", "int", "float64":
 		arg.Type = t
 	default:
-		err := fmt.Errorf("command: arg must be a string, bool or int not %q", t)
+		err := fmt.Errorf("command: arg must be a string, bool, int or float64 not %q", t)
 		l.Bail(err)
 	}
 	// Set the usage
 	arg.Help = tags.Get("help")
+	// Set the default. Args without a default are required.
+	if tags.Has("default") {
+		arg.Default = l.loadDefault(arg.Type, tagValue(tags, "default"))
+	}
 	return arg
 }
 
+// Load the remaining command args
+func (l *loader) loadCommandArgs(field *parser.Field, tags parser.Tags) *Arg {
+	arg := new(Arg)
+	arg.Field = field.Name()
+	arg.Name = tags.Get("args")
+	arg.Variadic = true
+	if t := field.Type().String(); t != "[]string" {
+		l.Bail(fmt.Errorf("command: args must be a []string not %q", t))
+	}
+	arg.Type = "[]string"
+	arg.Help = tags.Get("help")
+	return arg
+}
+
+// loadDefault turns the default tag into a Go literal of the given type
+func (l *loader) loadDefault(dataType, value string) string {
+	switch dataType {
+	case "string":
+		return strconv.Quote(value)
+	case "bool":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			l.Bail(fmt.Errorf("command: invalid bool default %q. %w", value, err))
+		}
+		return strconv.FormatBool(b)
+	case "int":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			l.Bail(fmt.Errorf("command: invalid int default %q. %w", value, err))
+		}
+		return strconv.Itoa(n)
+	case "float64":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			l.Bail(fmt.Errorf("command: invalid float64 default %q. %w", value, err))
+		}
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	case "[]string":
+		values := strings.Split(value, ",")
+		for i, value := range values {
+			values[i] = strconv.Quote(value)
+		}
+		return strings.Join(values, ", ")
+	default:
+		l.Bail(fmt.Errorf("command: unable to default %q", dataType))
+		return ""
+	}
+}
+
+// tagValue returns the full value of the tag, since commas are parsed as
+// options, e.g. `default:"a,b"`
+func tagValue(tags parser.Tags, key string) string {
+	for _, tag := range tags {
+		if tag.Key == key {
+			return strings.Join(append([]string{tag.Value}, tag.Options...), ",")
+		}
+	}
+	return ""
+}
+
 // Returns true if the command is runnable
 func isRunnable(stct *parser.Struct) bool {
 	run := stct.Method("Run")
@@ -15,6 +15,12 @@ func methodName(dataType string) (string, error) {
 		return "Bool", nil
 	case "string":
 		return "String", nil
+	case "int":
+		return "Int", nil
+	case "float64":
+		return "Float64", nil
+	case "[]string":
+		return "Strings", nil
 	default:
 		return "", fmt.Errorf("command: unhandled type for method %q", dataType)
 	}
@@ -38,22 +44,32 @@ type State struct {
 
 // Flatten out the commands, intentionally ignoring the root command
 // because that is custom generated.
-func (s *State) Commands() []*Command {
-	return flatten(s.Command.Subs)
+func (s *State) Commands() (commands []*Command) {
+	for _, command := range flatten(s.Command.Subs) {
+		// Skip directories that only group subcommands
+		if command.Import == nil {
+			continue
+		}
+		commands = append(commands, command)
+	}
+	return commands
 }
 
 type Command struct {
-	Parents  []string
-	Import   *imports.Import
-	Name     string
-	Slug     string
-	Help     string
-	Flags    []*Flag
-	Args     []*Arg
-	Subs     []*Command
-	Deps     []*Dep
-	Context  bool
-	Runnable bool
+	Parents     []string
+	Import      *imports.Import
+	Name        string
+	Slug        string
+	Help        string
+	Description string
+	Examples    []*Example
+	Flags       []*Flag
+	Args        []*Arg
+	Subs        []*Command
+	Deps        []*Dep
+	Constructor *Constructor
+	Context     bool
+	Runnable    bool
 }
 
 func (c *Command) Pascal() string {
@@ -76,11 +92,13 @@ func (f Full) Pascal() string {
 }
 
 type Flag struct {
+	Field   string // Name of the struct field
 	Name    string
 	Help    string
 	Type    string
-	Default string
+	Default string // Go literal of the default value
 	Short   byte
+	Env     string
 }
 
 func (f *Flag) Pascal() string {
@@ -96,10 +114,12 @@ func (f *Flag) Method() (string, error) {
 }
 
 type Arg struct {
-	Name    string
-	Help    string
-	Type    string
-	Default string
+	Field    string // Name of the struct field
+	Name     string
+	Help     string
+	Type     string
+	Default  string // Go literal of the default value
+	Variadic bool   // Collects the rest of the args
 }
 
 func (a *Arg) Pascal() string {
@@ -114,6 +134,18 @@ func (a *Arg) Method() (string, error) {
 	return methodName(a.Type)
 }
 
+// Constructor is a function in the command package that returns *Command
+type Constructor struct {
+	Name   string
+	Params []*Dep
+	Error  bool // Also returns an error
+}
+
+type Example struct {
+	Command string
+	Usage   string
+}
+
 type Dep struct {
 	Import *imports.Import
 	Name   string
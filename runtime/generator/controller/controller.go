@@ -6,6 +6,7 @@ import (
 	"context"
 	_ "embed"
 
+	"github.com/livebud/bud/internal/bail"
 	"github.com/livebud/bud/internal/gotemplate"
 	"github.com/livebud/bud/package/di"
 	"github.com/livebud/bud/package/gomod"
@@ -16,7 +17,7 @@ import (
 //go:embed controller.gotext
 var template string
 
-var generator = gotemplate.MustParse("controller.gotext", template)
+var generator = gotemplate.MustParse("controller.gotext", template, gotemplate.WithFormat())
 
 type Generator struct {
 	Injector *di.Injector
@@ -27,7 +28,7 @@ type Generator struct {
 func (g *Generator) GenerateFile(ctx context.Context, fsys overlay.F, file *overlay.File) error {
 	state, err := Load(fsys, g.Injector, g.Module, g.Parser)
 	if err != nil {
-		return err
+		return bail.Generating(err, "controller", file.Path())
 	}
 	code, err := generator.Generate(state)
 	if err != nil {
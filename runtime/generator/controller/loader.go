@@ -32,7 +32,7 @@ func Load(fsys fs.FS, injector *di.Injector, module *gomod.Module, parser *parse
 	loader := &loader{
 		fsys:     fsys,
 		contexts: newContextSet(),
-		imports:  imports.New(),
+		imports:  imports.New(imports.WithLocal(module.Import())),
 		injector: injector,
 		module:   module,
 		parser:   parser,
@@ -14,7 +14,7 @@ import (
 //go:embed main.gotext
 var template string
 
-var generator = gotemplate.MustParse("main.gotext", template)
+var generator = gotemplate.MustParse("main.gotext", template, gotemplate.WithFormat())
 
 // State for the generator
 type State struct {
@@ -30,7 +30,7 @@ type Main struct {
 }
 
 func (m *Main) Parse(ctx context.Context) (*State, error) {
-	imports := imports.New()
+	imports := imports.New(imports.WithLocal(m.module.Import()))
 	// TODO: only generate program if it exists
 	imports.AddStd("os", "context")
 	imports.AddNamed("program", m.module.Import("bud/.app/program"))
@@ -18,7 +18,7 @@ import (
 //go:embed program.gotext
 var template string
 
-var generator = gotemplate.MustParse("program.gotext", template)
+var generator = gotemplate.MustParse("program.gotext", template, gotemplate.WithFormat())
 
 type Program struct {
 	Flag     *bud.Flag
@@ -36,7 +36,7 @@ func (p *Program) GenerateFile(ctx context.Context, fsys overlay.F, file *overla
 		return err
 	}
 	// Add the imports
-	imports := imports.New()
+	imports := imports.New(imports.WithLocal(p.Module.Import()))
 	imports.AddStd("errors", "context")
 	imports.AddNamed("console", "github.com/livebud/bud/package/log/console")
 	imports.Add(p.Module.Import("bud/.app/command"))
@@ -19,7 +19,7 @@ func Load(flag *bud.Flag, fsys fs.FS, module *gomod.Module) (*State, error) {
 	loader := &loader{
 		fsys:    fsys,
 		flag:    flag,
-		imports: imports.New(),
+		imports: imports.New(imports.WithLocal(module.Import())),
 		module:  module,
 	}
 	return loader.Load()
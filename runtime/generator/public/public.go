@@ -4,6 +4,7 @@ import (
 	"context"
 	_ "embed"
 
+	"github.com/livebud/bud/internal/bail"
 	"github.com/livebud/bud/package/gomod"
 	"github.com/livebud/bud/package/overlay"
 
@@ -31,7 +32,7 @@ type Generator struct {
 func (g *Generator) GenerateFile(ctx context.Context, fsys overlay.F, file *overlay.File) error {
 	state, err := Load(g.flag, fsys, g.module)
 	if err != nil {
-		return err
+		return bail.Generating(err, "public", file.Path())
 	}
 	code, err := generator.Generate(state)
 	if err != nil {
@@ -33,7 +33,7 @@ func (c *Compiler) Parse(fsys fs.FS, ctx context.Context) (*State, error) {
 	return (&parser{
 		Flag:      c.Flag,
 		Module:    c.Module,
-		Imports:   imports.New(),
+		Imports:   imports.New(imports.WithLocal(c.Module.Import())),
 		Transform: c.Transform,
 	}).Parse(fsys, ctx)
 }
@@ -17,7 +17,7 @@ import (
 
 func Load(fsys fs.FS, module *gomod.Module, parser *parser.Parser) (*State, error) {
 	loader := &loader{
-		imports: imports.New(),
+		imports: imports.New(imports.WithLocal(module.Import())),
 		fsys:    fsys,
 		module:  module,
 		parser:  parser,
@@ -4,6 +4,7 @@ import (
 	"context"
 	_ "embed"
 
+	"github.com/livebud/bud/internal/bail"
 	"github.com/livebud/bud/internal/gotemplate"
 	"github.com/livebud/bud/package/gomod"
 	"github.com/livebud/bud/package/overlay"
@@ -13,7 +14,7 @@ import (
 //go:embed web.gotext
 var template string
 
-var generator = gotemplate.MustParse("web", template)
+var generator = gotemplate.MustParse("web", template, gotemplate.WithFormat())
 
 type Generator struct {
 	Module *gomod.Module
@@ -23,7 +24,7 @@ type Generator struct {
 func (g *Generator) GenerateFile(ctx context.Context, fsys overlay.F, file *overlay.File) error {
 	state, err := Load(fsys, g.Module, g.Parser)
 	if err != nil {
-		return err
+		return bail.Generating(err, "web", file.Path())
 	}
 	code, err := generator.Generate(state)
 	if err != nil {
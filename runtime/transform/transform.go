@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	esbuild "github.com/evanw/esbuild/pkg/api"
@@ -178,8 +179,14 @@ func (t *transformer) Transform(fromPath, toPath string, code []byte) ([]byte, e
 }
 
 func (t *transformer) Plugins() (plugins []esbuild.Plugin) {
-	for from, to := range t.pathmap {
-		from := from
+	// Sort the extensions, so the plugins are always in the same order
+	froms := make([]string, 0, len(t.pathmap))
+	for from := range t.pathmap {
+		froms = append(froms, from)
+	}
+	sort.Strings(froms)
+	for _, from := range froms {
+		from, to := from, t.pathmap[from]
 		plugins = append(plugins, esbuild.Plugin{
 			Name: "tranform_" + strings.TrimPrefix(from, ".") + "_to_" + strings.TrimPrefix(to, "."),
 			Setup: func(epb esbuild.PluginBuild) {
@@ -204,3 +204,24 @@ func TestTargets(t *testing.T) {
 	is.Equal(trace[1], ".svelte>.svelte")
 	is.Equal(trace[2], ".svelte>.js(dom)")
 }
+
+func TestPluginOrder(t *testing.T) {
+	is := is.New(t)
+	noop := transform.Platforms{
+		transform.PlatformAll: func(file *transform.File) error { return nil },
+	}
+	transformer, err := transform.Load([]*transform.Transformable{
+		{From: ".svelte", To: ".js", For: noop},
+		{From: ".md", To: ".svelte", For: noop},
+		{From: ".mdx", To: ".jsx", For: noop},
+		{From: ".vue", To: ".js", For: noop},
+	}...)
+	is.NoErr(err)
+	for i := 0; i < 10; i++ {
+		names := []string{}
+		for _, plugin := range transformer.DOM.Plugins() {
+			names = append(names, plugin.Name)
+		}
+		is.Equal(strings.Join(names, " "), "tranform_md_to_js tranform_mdx_to_jsx tranform_svelte_to_js tranform_vue_to_js")
+	}
+}